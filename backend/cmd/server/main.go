@@ -1,15 +1,25 @@
 package main
 
 import (
+	"context"
+	"dental-marketplace/backend/internal/analysis"
+	"dental-marketplace/backend/internal/attachments"
 	"dental-marketplace/backend/internal/auth"
 	"dental-marketplace/backend/internal/config"
 	"dental-marketplace/backend/internal/database"
+	"dental-marketplace/backend/internal/dicom"
 	"dental-marketplace/backend/internal/handlers"
+	"dental-marketplace/backend/internal/jobs"
 	"dental-marketplace/backend/internal/middleware"
 	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/pricing"
 	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/scans"
+	"dental-marketplace/backend/internal/storage"
+	"dental-marketplace/backend/internal/virusscan"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -43,18 +53,75 @@ func main() {
 		cfg.JWT.RefreshExpiry,
 	)
 
+	// Initialize blob storage
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
 	// Initialize repositories
 	repo := repository.NewRepository(db.DB)
 	constantsRepo := repository.NewConstantsRepository(db.DB)
 
+	// Initialize scan processing
+	deidProfile := dicom.BasicProfile()
+	if cfg.Scans.DeidentificationProfile != "" {
+		deidProfile, err = dicom.LoadProfile(cfg.Scans.DeidentificationProfile)
+		if err != nil {
+			log.Fatalf("Failed to load de-identification profile: %v", err)
+		}
+	}
+	scanIngestor := scans.NewIngestor(repo, store, deidProfile)
+	estimator := pricing.NewEstimator(repo)
+	estimateRefresher := jobs.NewEstimateRefresher(estimator)
+
+	// Initialize attachment uploads
+	virusScanner, err := virusscan.New(cfg.Attachments.VirusScanner)
+	if err != nil {
+		log.Fatalf("Failed to initialize virus scanner: %v", err)
+	}
+	uploader := attachments.NewUploader(store, virusScanner, cfg.Attachments)
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(repo, jwtManager)
-	patientHandler := handlers.NewPatientHandler(repo)
-	clinicHandler := handlers.NewClinicHandler(repo)
-	regulatorHandler := handlers.NewRegulatorHandler(repo)
+	patientHandler := handlers.NewPatientHandler(repo, store, scanIngestor, cfg.Storage, cfg.Reviews, cfg.Complaints)
+	clinicHandler := handlers.NewClinicHandler(repo, store, estimateRefresher, cfg.Storage, cfg.Offers)
+	regulatorHandler := handlers.NewRegulatorHandler(repo, cfg.Complaints)
+	calendarHandler := handlers.NewCalendarHandler(repo, cfg.Server.PublicURL)
+	reviewHandler := handlers.NewReviewHandler(repo)
+	notificationHandler := handlers.NewNotificationHandler(repo)
+	attachmentHandler := handlers.NewAttachmentHandler(repo, store, uploader, cfg.Storage, cfg.Attachments)
+
+	// Start background tasks
+	ctx := context.Background()
+	uploadSweeper := jobs.NewUploadSweeper(repo, store)
+	go jobs.RunPeriodic(ctx, "upload-sweeper", 15*time.Minute, uploadSweeper.Sweep)
+	offerSweeper := jobs.NewOfferSweeper(repo)
+	go jobs.RunPeriodic(ctx, "offer-expiry", cfg.Offers.ExpirySweepInterval, offerSweeper.Sweep)
+	go jobs.RunPeriodic(ctx, "plan-estimates", cfg.Pricing.RecomputeInterval, estimateRefresher.Refresh)
+
+	analyzer, err := analysis.New(cfg.Scans.Analyzer)
+	if err != nil {
+		log.Fatalf("Failed to initialize scan analyzer: %v", err)
+	}
+	scanProcessor := jobs.NewScanProcessor(repo, store, analyzer, estimator, cfg.Scans)
+	go scanProcessor.Run(ctx)
+
+	// Bring estimates of open plans in line with current price lists
+	go func() {
+		if err := estimator.RecomputeAll(); err != nil {
+			log.Printf("failed to re-estimate treatment plans: %v", err)
+		}
+	}()
+
+	// Signed downloads are served by the API only for the local driver
+	var fileHandler *handlers.FileHandler
+	if localStore, ok := store.(*storage.LocalStorage); ok {
+		fileHandler = handlers.NewFileHandler(localStore)
+	}
 
 	// Setup router
-	router := setupRouter(authHandler, patientHandler, clinicHandler, regulatorHandler, constantsRepo, jwtManager)
+	router := setupRouter(authHandler, patientHandler, clinicHandler, regulatorHandler, calendarHandler, reviewHandler, notificationHandler, attachmentHandler, fileHandler, constantsRepo, jwtManager)
 
 	// Print startup information
 	printStartupInfo(cfg)
@@ -72,6 +139,11 @@ func setupRouter(
 	patientHandler *handlers.PatientHandler,
 	clinicHandler *handlers.ClinicHandler,
 	regulatorHandler *handlers.RegulatorHandler,
+	calendarHandler *handlers.CalendarHandler,
+	reviewHandler *handlers.ReviewHandler,
+	notificationHandler *handlers.NotificationHandler,
+	attachmentHandler *handlers.AttachmentHandler,
+	fileHandler *handlers.FileHandler,
 	constantsRepo *repository.ConstantsRepository,
 	jwtManager *auth.JWTManager,
 ) *gin.Engine {
@@ -102,6 +174,19 @@ func setupRouter(
 		// Constants endpoint (public - no auth required)
 		commonHandler := handlers.NewCommonHandler(constantsRepo)
 		api.GET("/constants", commonHandler.GetConstants)
+		api.GET("/procedures", commonHandler.SearchProcedures)
+
+		// Signed file downloads (signature in query string, no auth header)
+		if fileHandler != nil {
+			api.GET("/files/*key", fileHandler.Download)
+		}
+
+		// Calendar feeds (feed token in query string, no auth header)
+		api.GET("/clinic/appointments.ics", calendarHandler.ClinicFeed)
+		api.GET("/patient/appointments.ics", calendarHandler.PatientFeed)
+
+		// Published clinic reviews
+		api.GET("/clinics/:id/reviews", reviewHandler.GetClinicReviews)
 
 		// Protected routes - require authentication
 		protected := api.Group("")
@@ -116,12 +201,24 @@ func setupRouter(
 			{
 				// Scans
 				patient.GET("/scans", patientHandler.GetScans)
+				patient.POST("/scans", patientHandler.UploadScan)
 
 				// Scan details - use separate route groups
 				scans := patient.Group("/scans/:id")
 				{
 					scans.GET("", patientHandler.GetScanByID)
 					scans.GET("/plan", patientHandler.GetTreatmentPlan)
+					scans.GET("/download", patientHandler.DownloadScan)
+				}
+
+				// Resumable chunked uploads
+				uploads := patient.Group("/scans/uploads")
+				{
+					uploads.POST("", patientHandler.CreateUpload)
+					uploads.GET("/:upload_id", patientHandler.GetUpload)
+					uploads.HEAD("/:upload_id", patientHandler.GetUploadOffset)
+					uploads.PATCH("/:upload_id", patientHandler.UploadChunk)
+					uploads.DELETE("/:upload_id", patientHandler.AbortUpload)
 				}
 
 				// Plans
@@ -130,14 +227,32 @@ func setupRouter(
 				plans := patient.Group("/plans/:plan_id")
 				{
 					plans.GET("/offers", patientHandler.GetOffers)
+					plans.GET("/offers/compare", patientHandler.CompareOffers)
+					plans.POST("/request-offers", patientHandler.RequestOffers)
+					plans.POST("/cancel", patientHandler.CancelTreatmentPlan)
 				}
 
 				// Other routes
 				patient.POST("/search-criteria", patientHandler.UpdateSearchCriteria)
 				patient.POST("/select-offer", patientHandler.SelectOffer)
 				patient.GET("/appointments", patientHandler.GetAppointments)
+				patient.POST("/appointments", patientHandler.BookAppointment)
+				patient.POST("/appointments/:id/reschedule", patientHandler.RescheduleAppointment)
+				patient.POST("/appointments/:id/cancel", patientHandler.CancelAppointment)
+				patient.POST("/calendar-token", calendarHandler.IssueToken)
+				patient.DELETE("/calendar-token", calendarHandler.RevokeToken)
+				patient.GET("/clinics/:clinic_id/slots", patientHandler.GetClinicSlots)
+				patient.GET("/reviews", patientHandler.GetReviews)
 				patient.POST("/reviews", patientHandler.CreateReview)
+				patient.PUT("/reviews/:id", patientHandler.UpdateReview)
+				patient.POST("/reviews/:id/attachments", attachmentHandler.UploadReviewAttachment)
+				patient.GET("/complaints", patientHandler.GetComplaints)
 				patient.POST("/complaints", patientHandler.CreateComplaint)
+				patient.POST("/complaints/:id/attachments", attachmentHandler.UploadComplaintAttachment)
+				patient.GET("/attachments/:id/download", attachmentHandler.Download)
+				patient.GET("/notifications", notificationHandler.GetNotifications)
+				patient.POST("/notifications/read", notificationHandler.MarkAllRead)
+				patient.POST("/notifications/:id/read", notificationHandler.MarkRead)
 			}
 
 			// Clinic routes
@@ -146,10 +261,47 @@ func setupRouter(
 			{
 				clinic.GET("/dashboard", clinicHandler.GetDashboard)
 				clinic.GET("/incoming-plans", clinicHandler.GetIncomingPlans)
+				clinic.GET("/plans/:plan_id/scan", clinicHandler.GetPlanScan)
+				clinic.POST("/plans/:plan_id/quote", clinicHandler.QuotePlan)
+				clinic.GET("/offers", clinicHandler.GetOffers)
 				clinic.POST("/offers", clinicHandler.CreateOffer)
+				clinic.PUT("/offers/:id", clinicHandler.UpdateOffer)
+				clinic.DELETE("/offers/:id", clinicHandler.WithdrawOffer)
+				clinic.POST("/offers/:id/send", clinicHandler.SendOffer)
+				clinic.GET("/discounts", clinicHandler.GetDiscounts)
+				clinic.PUT("/discounts", clinicHandler.UpdateDiscounts)
+				clinic.GET("/installment-products", clinicHandler.GetInstallmentProducts)
+				clinic.PUT("/installment-products", clinicHandler.UpdateInstallmentProducts)
+				clinic.GET("/schedule", clinicHandler.GetSchedule)
+				clinic.PUT("/schedule/working-hours", clinicHandler.UpdateWorkingHours)
+				clinic.PUT("/schedule/cancellation-window", clinicHandler.UpdateCancellationWindow)
+				clinic.POST("/schedule/resources", clinicHandler.CreateResource)
+				clinic.PUT("/schedule/resources/:id", clinicHandler.UpdateResource)
+				clinic.POST("/schedule/blocked-dates", clinicHandler.CreateBlockedDate)
+				clinic.DELETE("/schedule/blocked-dates/:id", clinicHandler.DeleteBlockedDate)
 				clinic.GET("/leads", clinicHandler.GetLeads)
+				clinic.GET("/leads/:id", clinicHandler.GetLead)
+				clinic.PUT("/leads/:id", clinicHandler.UpdateLead)
+				clinic.POST("/leads/:id/tasks", clinicHandler.CreateLeadTask)
+				clinic.PUT("/leads/:id/tasks/:task_id", clinicHandler.UpdateLeadTask)
+				clinic.POST("/leads/:id/contacts", clinicHandler.CreateLeadContact)
+				clinic.GET("/staff", clinicHandler.GetStaff)
+				clinic.POST("/staff", clinicHandler.CreateStaff)
+				clinic.PUT("/staff/:id", clinicHandler.UpdateStaff)
+				clinic.GET("/reviews", clinicHandler.GetReviews)
+				clinic.PUT("/reviews/:id/reply", clinicHandler.SaveReviewReply)
+				clinic.DELETE("/reviews/:id/reply", clinicHandler.DeleteReviewReply)
+				clinic.GET("/complaints", clinicHandler.GetComplaints)
+				clinic.GET("/complaints/:id", clinicHandler.GetComplaint)
+				clinic.POST("/complaints/:id/response", clinicHandler.RespondToComplaint)
+				clinic.GET("/attachments/:id/download", attachmentHandler.Download)
+				clinic.GET("/notifications", notificationHandler.GetNotifications)
+				clinic.POST("/notifications/read", notificationHandler.MarkAllRead)
+				clinic.POST("/notifications/:id/read", notificationHandler.MarkRead)
 				clinic.GET("/appointments", clinicHandler.GetAppointments)
 				clinic.PUT("/appointments/:id", clinicHandler.UpdateAppointment)
+				clinic.POST("/calendar-token", calendarHandler.IssueToken)
+				clinic.DELETE("/calendar-token", calendarHandler.RevokeToken)
 				clinic.GET("/price-list", clinicHandler.GetPriceList)
 				clinic.PUT("/price-list", clinicHandler.UpdatePriceList)
 				clinic.GET("/analytics", clinicHandler.GetAnalytics)
@@ -164,7 +316,17 @@ func setupRouter(
 				regulator.GET("/clinics", regulatorHandler.GetClinics)
 				regulator.GET("/clinics/:id", regulatorHandler.GetClinicDetails)
 				regulator.GET("/complaints", regulatorHandler.GetComplaints)
+				regulator.GET("/complaints/:id", regulatorHandler.GetComplaint)
+				regulator.PUT("/complaints/:id", regulatorHandler.UpdateComplaint)
+				regulator.POST("/complaints/:id/notes", regulatorHandler.CreateComplaintNote)
+				regulator.POST("/complaints/:id/response-request", regulatorHandler.RequestClinicResponse)
+				regulator.GET("/attachments/:id/download", attachmentHandler.Download)
+				regulator.GET("/reviews", regulatorHandler.GetReviews)
+				regulator.PUT("/reviews/:id", regulatorHandler.ModerateReview)
 				regulator.GET("/disease-analytics", regulatorHandler.GetDiseaseAnalytics)
+				regulator.GET("/notifications", notificationHandler.GetNotifications)
+				regulator.POST("/notifications/read", notificationHandler.MarkAllRead)
+				regulator.POST("/notifications/:id/read", notificationHandler.MarkRead)
 			}
 		}
 	}
@@ -182,6 +344,7 @@ func printStartupInfo(cfg *config.Config) {
 	log.Printf("   Server Port:    %s", cfg.Server.Port)
 	log.Printf("   Environment:    %s", cfg.Server.GinMode)
 	log.Printf("   Database:       %s:%s", cfg.Database.Host, cfg.Database.Port)
+	log.Printf("   Storage:        %s", cfg.Storage.Driver)
 	log.Println("")
 	log.Println("🔐 Demo Credentials:")
 	log.Println("   Patient:        username: patient   | password: password")
@@ -0,0 +1,13 @@
+package analysis
+
+import "fmt"
+
+// New returns the analyzer configured by name
+func New(name string) (Analyzer, error) {
+	switch name {
+	case "fake", "":
+		return NewFakeAnalyzer(), nil
+	default:
+		return nil, fmt.Errorf("unknown scan analyzer: %s", name)
+	}
+}
@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"context"
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"io"
+)
+
+// Analyzer detects dental findings in a CT scan. Implementations wrap an
+// AI provider; they receive only the de-identified copy of the scan.
+type Analyzer interface {
+	Name() string
+	Analyze(ctx context.Context, scan Scan) (*Result, error)
+}
+
+// Scan is the analyzer's view of a CT scan
+type Scan struct {
+	ID       uint
+	Checksum string // hex SHA-256 of the de-identified file
+	Metadata *models.CTScanMetadata
+
+	// Open streams the de-identified DICOM file or archive
+	Open func(ctx context.Context) (io.ReadCloser, error)
+}
+
+// Result is the outcome of analyzing one scan
+type Result struct {
+	Findings []Finding
+}
+
+// Finding is one detected problem and the procedure that treats it
+type Finding struct {
+	Specialization string // therapy, orthopedics, surgery, hygiene, periodontics
+	ToothNumber    string // International notation: 11-48
+	Diagnosis      string
+	Procedure      string
+	ProcedureCode  string // procedure catalog code
+	Urgency        string // high, medium, low
+	EstimatedCost  int
+}
+
+// permanentError marks failures that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job queue dead-letters the scan immediately
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
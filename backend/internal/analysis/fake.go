@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"context"
+	"crypto/sha256"
+	"dental-marketplace/backend/internal/models"
+	"encoding/binary"
+	"fmt"
+)
+
+// FakeAnalyzer returns deterministic findings derived from the scan
+// checksum, so the same file always yields the same plan. It is used in
+// development and tests until a real provider is configured.
+type FakeAnalyzer struct{}
+
+func NewFakeAnalyzer() *FakeAnalyzer {
+	return &FakeAnalyzer{}
+}
+
+func (a *FakeAnalyzer) Name() string {
+	return "fake"
+}
+
+// fakeFindings is the catalogue the fake analyzer draws from
+var fakeFindings = []Finding{
+	{Specialization: models.SpecTherapy, Diagnosis: "Кариес", Procedure: "Лечение кариеса", ProcedureCode: "therapy_caries", Urgency: "medium", EstimatedCost: 5000},
+	{Specialization: models.SpecTherapy, Diagnosis: "Пульпит", Procedure: "Лечение каналов", ProcedureCode: "therapy_pulpitis", Urgency: "high", EstimatedCost: 12000},
+	{Specialization: models.SpecOrthopedics, Diagnosis: "Разрушение коронки", Procedure: "Металлокерамическая коронка", ProcedureCode: "orthopedics_crown_pfm", Urgency: "medium", EstimatedCost: 18000},
+	{Specialization: models.SpecSurgery, Diagnosis: "Отсутствие зуба", Procedure: "Имплантация", ProcedureCode: "surgery_implant", Urgency: "low", EstimatedCost: 45000},
+	{Specialization: models.SpecSurgery, Diagnosis: "Ретинированный зуб мудрости", Procedure: "Удаление зуба", ProcedureCode: "surgery_extraction_complex", Urgency: "medium", EstimatedCost: 7000},
+	{Specialization: models.SpecHygiene, Diagnosis: "Зубной камень", Procedure: "Профессиональная чистка", ProcedureCode: "hygiene_cleaning", Urgency: "low", EstimatedCost: 4000},
+	{Specialization: models.SpecPeriodontics, Diagnosis: "Пародонтит", Procedure: "Кюретаж", ProcedureCode: "periodontics_periodontitis", Urgency: "medium", EstimatedCost: 9000},
+}
+
+// Analyze picks two to five findings on distinct teeth
+func (a *FakeAnalyzer) Analyze(ctx context.Context, scan Scan) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	seed := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", scan.ID, scan.Checksum)))
+	next := func(i int) int {
+		return int(binary.BigEndian.Uint32(seed[(i*4)%28:]) >> 1)
+	}
+
+	count := 2 + next(0)%4
+	result := &Result{}
+	used := map[string]bool{}
+	for i := 1; len(result.Findings) < count && i < 32; i++ {
+		finding := fakeFindings[next(i)%len(fakeFindings)]
+
+		// Permanent teeth: quadrants 1-4, positions 1-8
+		value := next(i + 1)
+		tooth := fmt.Sprintf("%d%d", 1+value%4, 1+(value/4)%8)
+		if used[tooth] {
+			continue
+		}
+		used[tooth] = true
+
+		finding.ToothNumber = tooth
+		result.Findings = append(result.Findings, finding)
+	}
+
+	return result, nil
+}
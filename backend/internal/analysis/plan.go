@@ -0,0 +1,27 @@
+package analysis
+
+import "dental-marketplace/backend/internal/models"
+
+// BuildPlan turns analysis findings into a treatment plan with items. Cost
+// ranges are filled in by the pricing estimator from regional price lists.
+func BuildPlan(scan *models.CTScan, result *Result) *models.TreatmentPlan {
+	plan := &models.TreatmentPlan{
+		PatientID: scan.PatientID,
+		CTScanID:  scan.ID,
+		Status:    models.PlanStatusGenerated,
+	}
+
+	for _, finding := range result.Findings {
+		plan.Items = append(plan.Items, models.TreatmentItem{
+			Specialization: finding.Specialization,
+			ToothNumber:    finding.ToothNumber,
+			Diagnosis:      finding.Diagnosis,
+			Procedure:      finding.Procedure,
+			ProcedureCode:  finding.ProcedureCode,
+			Urgency:        finding.Urgency,
+			EstimatedCost:  finding.EstimatedCost,
+		})
+	}
+
+	return plan
+}
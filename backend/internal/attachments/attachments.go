@@ -0,0 +1,152 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"dental-marketplace/backend/internal/config"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/storage"
+	"dental-marketplace/backend/internal/virusscan"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrInfected        = errors.New("file failed the virus scan")
+)
+
+// allowedTypes maps the accepted content types to the extension files are
+// stored under: photos, scanned documents and X-ray exports
+var allowedTypes = map[string]string{
+	"image/jpeg":        ".jpg",
+	"image/png":         ".png",
+	"image/webp":        ".webp",
+	"image/heic":        ".heic",
+	"application/pdf":   ".pdf",
+	"application/dicom": ".dcm",
+}
+
+// maxFileNameLength bounds the original file name kept for display
+const maxFileNameLength = 255
+
+// Uploader validates, virus-scans and stores attachment files
+type Uploader struct {
+	store   storage.Storage
+	scanner virusscan.Scanner
+	maxSize int64
+}
+
+func NewUploader(store storage.Storage, scanner virusscan.Scanner, cfg config.AttachmentConfig) *Uploader {
+	return &Uploader{store: store, scanner: scanner, maxSize: cfg.MaxSize}
+}
+
+// MaxSize is the largest accepted file in bytes
+func (u *Uploader) MaxSize() int64 {
+	return u.maxSize
+}
+
+// Store checks file and stores it under prefix. The content type is detected
+// from the file itself; the client's claim is ignored. The returned
+// attachment is not yet saved.
+func (u *Uploader) Store(ctx context.Context, prefix, fileName string, file io.ReadSeeker, size int64) (*models.Attachment, error) {
+	if size > u.maxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType, err := detectType(file)
+	if err != nil {
+		return nil, err
+	}
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	result, err := u.scanner.Scan(ctx, io.LimitReader(file, size))
+	if err != nil {
+		return nil, fmt.Errorf("virus scan: %w", err)
+	}
+	if !result.Clean {
+		return nil, fmt.Errorf("%w: %s", ErrInfected, result.Threat)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	key, err := storage.NewKey(prefix, "attachment"+ext)
+	if err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	if err := u.store.Put(ctx, key, io.TeeReader(io.LimitReader(file, size), hasher), size, contentType); err != nil {
+		return nil, err
+	}
+
+	return &models.Attachment{
+		StorageKey:  key,
+		FileName:    displayName(fileName, ext),
+		FileSize:    size,
+		ContentType: contentType,
+		Checksum:    hex.EncodeToString(hasher.Sum(nil)),
+		ScannedBy:   u.scanner.Name(),
+	}, nil
+}
+
+// detectType sniffs the content type from the start of the file
+func detectType(file io.Reader) (string, error) {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	header = header[:n]
+
+	switch {
+	// DICOM part 10 files carry a 128-byte preamble before the magic
+	case len(header) >= 132 && bytes.Equal(header[128:132], []byte("DICM")):
+		return "application/dicom", nil
+	// HEIC photos are ISO media files with a HEIF brand
+	case len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")) && isHEIFBrand(string(header[8:12])):
+		return "image/heic", nil
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(header), ";")
+	return contentType, nil
+}
+
+func isHEIFBrand(brand string) bool {
+	switch brand {
+	case "heic", "heix", "heim", "heis", "mif1", "msf1":
+		return true
+	}
+	return false
+}
+
+// displayName keeps the base name the client sent, trimmed to a safe length,
+// falling back to a generic name
+func displayName(fileName, ext string) string {
+	name := path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if name == "." || name == "/" || !utf8.ValidString(name) {
+		name = ""
+	}
+	for len(name) > maxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" {
+		return "attachment" + ext
+	}
+	return name
+}
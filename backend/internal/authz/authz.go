@@ -0,0 +1,109 @@
+package authz
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+)
+
+// Policies decide whether a patient or clinic may see or act on a resource.
+// Handlers answer a denied policy exactly like a missing resource (404), so
+// IDs belonging to other tenants cannot be probed.
+
+// PatientCanViewScan reports whether the scan was uploaded by the patient
+func PatientCanViewScan(patient *models.Patient, scan *models.CTScan) bool {
+	return scan.PatientID == patient.ID
+}
+
+// PatientCanViewUpload reports whether the upload session was opened by the patient
+func PatientCanViewUpload(patient *models.Patient, session *models.UploadSession) bool {
+	return session.PatientID == patient.ID
+}
+
+// PatientCanViewPlan reports whether the treatment plan belongs to the patient
+func PatientCanViewPlan(patient *models.Patient, plan *models.TreatmentPlan) bool {
+	return plan.PatientID == patient.ID
+}
+
+// PatientCanViewOffer reports whether the offer was made on the patient's
+// plan. Drafts still pending on the clinic's side are never visible.
+func PatientCanViewOffer(patient *models.Patient, offer *models.ClinicOffer, plan *models.TreatmentPlan) bool {
+	return offer.TreatmentPlanID == plan.ID &&
+		PatientCanViewPlan(patient, plan) &&
+		offer.Status != models.OfferStatusPending
+}
+
+// PatientCanViewAppointment reports whether the appointment is the patient's
+func PatientCanViewAppointment(patient *models.Patient, appointment *models.Appointment) bool {
+	return appointment.PatientID == patient.ID
+}
+
+// PatientCanViewComplaint reports whether the patient filed the complaint
+func PatientCanViewComplaint(patient *models.Patient, complaint *models.Complaint) bool {
+	return complaint.PatientID == patient.ID
+}
+
+// PatientCanViewReview reports whether the patient wrote the review
+func PatientCanViewReview(patient *models.Patient, review *models.Review) bool {
+	return review.PatientID == patient.ID
+}
+
+// PatientCanViewAttachment reports whether the attachment belongs to one of
+// the patient's complaints or reviews. Attachments must be loaded with their
+// Complaint and Review.
+func PatientCanViewAttachment(patient *models.Patient, attachment *models.Attachment) bool {
+	return (attachment.Complaint != nil && PatientCanViewComplaint(patient, attachment.Complaint)) ||
+		(attachment.Review != nil && PatientCanViewReview(patient, attachment.Review))
+}
+
+// ClinicCanViewPlan reports whether a plan was published to the clinic or
+// the clinic already made an offer on it. Plans must be loaded with Offers.
+func ClinicCanViewPlan(clinic *models.Clinic, plan *models.TreatmentPlan) bool {
+	for _, offer := range plan.Offers {
+		if offer.ClinicID == clinic.ID {
+			return true
+		}
+	}
+	return plan.OffersRequestedAt != nil && repository.PlanTargetsClinic(plan, clinic)
+}
+
+// ClinicCanManageOffer reports whether the offer was made by the clinic
+func ClinicCanManageOffer(clinic *models.Clinic, offer *models.ClinicOffer) bool {
+	return offer.ClinicID == clinic.ID
+}
+
+// ClinicCanManageAppointment reports whether the appointment is with the clinic
+func ClinicCanManageAppointment(clinic *models.Clinic, appointment *models.Appointment) bool {
+	return appointment.ClinicID == clinic.ID
+}
+
+// ClinicCanManagePriceList reports whether every existing row in an update
+// belongs to the clinic. New rows (ID 0) are always allowed; owned holds the
+// clinic's current price list.
+func ClinicCanManagePriceList(clinic *models.Clinic, owned []models.PriceList, rows []models.PriceList) bool {
+	ownedIDs := make(map[uint]bool, len(owned))
+	for _, row := range owned {
+		if row.ClinicID == clinic.ID {
+			ownedIDs[row.ID] = true
+		}
+	}
+	for _, row := range rows {
+		if row.ID != 0 && !ownedIDs[row.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// ClinicCanViewComplaint reports whether the complaint is about the clinic and
+// regulators have sent it to the clinic for a response
+func ClinicCanViewComplaint(clinic *models.Clinic, complaint *models.Complaint) bool {
+	return complaint.ClinicID == clinic.ID && complaint.ClinicResponseRequestedAt != nil
+}
+
+// ClinicCanViewAttachment reports whether the attachment belongs to a
+// complaint the clinic can see or a review of the clinic. Attachments must be
+// loaded with their Complaint and Review.
+func ClinicCanViewAttachment(clinic *models.Clinic, attachment *models.Attachment) bool {
+	return (attachment.Complaint != nil && ClinicCanViewComplaint(clinic, attachment.Complaint)) ||
+		(attachment.Review != nil && attachment.Review.ClinicID == clinic.ID)
+}
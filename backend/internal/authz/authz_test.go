@@ -0,0 +1,229 @@
+package authz
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"testing"
+	"time"
+)
+
+var (
+	patientA = &models.Patient{ID: 1}
+	patientB = &models.Patient{ID: 2}
+	clinicA  = &models.Clinic{ID: 10, City: "Москва", PriceSegment: "medium", HasTherapy: true}
+	clinicB  = &models.Clinic{ID: 20, City: "Москва", PriceSegment: "medium", HasTherapy: true}
+)
+
+func TestPatientOwnershipPolicies(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow func(*models.Patient) bool
+	}{
+		{"scan", func(p *models.Patient) bool {
+			return PatientCanViewScan(p, &models.CTScan{PatientID: patientA.ID})
+		}},
+		{"upload", func(p *models.Patient) bool {
+			return PatientCanViewUpload(p, &models.UploadSession{PatientID: patientA.ID})
+		}},
+		{"plan", func(p *models.Patient) bool {
+			return PatientCanViewPlan(p, &models.TreatmentPlan{PatientID: patientA.ID})
+		}},
+		{"appointment", func(p *models.Patient) bool {
+			return PatientCanViewAppointment(p, &models.Appointment{PatientID: patientA.ID, ClinicID: clinicA.ID})
+		}},
+		{"complaint", func(p *models.Patient) bool {
+			return PatientCanViewComplaint(p, &models.Complaint{PatientID: patientA.ID, ClinicID: clinicA.ID})
+		}},
+		{"review", func(p *models.Patient) bool {
+			return PatientCanViewReview(p, &models.Review{PatientID: patientA.ID, ClinicID: clinicA.ID})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.allow(patientA) {
+				t.Error("owner was denied")
+			}
+			if tt.allow(patientB) {
+				t.Error("another patient was allowed")
+			}
+		})
+	}
+}
+
+func TestPatientCanViewOffer(t *testing.T) {
+	plan := &models.TreatmentPlan{ID: 100, PatientID: patientA.ID}
+	otherPlan := &models.TreatmentPlan{ID: 200, PatientID: patientB.ID}
+
+	tests := []struct {
+		name    string
+		patient *models.Patient
+		offer   *models.ClinicOffer
+		plan    *models.TreatmentPlan
+		want    bool
+	}{
+		{"sent offer on own plan", patientA, &models.ClinicOffer{TreatmentPlanID: plan.ID, Status: models.OfferStatusSent}, plan, true},
+		{"accepted offer on own plan", patientA, &models.ClinicOffer{TreatmentPlanID: plan.ID, Status: models.OfferStatusAccepted}, plan, true},
+		{"withdrawn offer on own plan", patientA, &models.ClinicOffer{TreatmentPlanID: plan.ID, Status: models.OfferStatusWithdrawn}, plan, true},
+		{"pending draft on own plan", patientA, &models.ClinicOffer{TreatmentPlanID: plan.ID, Status: models.OfferStatusPending}, plan, false},
+		{"offer on another patient's plan", patientB, &models.ClinicOffer{TreatmentPlanID: plan.ID, Status: models.OfferStatusSent}, plan, false},
+		// The plan passed in must be the one the offer was made on
+		{"offer paired with a different plan", patientB, &models.ClinicOffer{TreatmentPlanID: plan.ID, Status: models.OfferStatusSent}, otherPlan, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PatientCanViewOffer(tt.patient, tt.offer, tt.plan); got != tt.want {
+				t.Errorf("PatientCanViewOffer = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatientCanViewAttachment(t *testing.T) {
+	ownComplaint := &models.Complaint{PatientID: patientA.ID}
+	ownReview := &models.Review{PatientID: patientA.ID}
+	foreignComplaint := &models.Complaint{PatientID: patientB.ID}
+	foreignReview := &models.Review{PatientID: patientB.ID}
+
+	tests := []struct {
+		name       string
+		attachment *models.Attachment
+		want       bool
+	}{
+		{"own complaint", &models.Attachment{Complaint: ownComplaint}, true},
+		{"own review", &models.Attachment{Review: ownReview}, true},
+		{"another patient's complaint", &models.Attachment{Complaint: foreignComplaint}, false},
+		{"another patient's review", &models.Attachment{Review: foreignReview}, false},
+		{"unloaded owner", &models.Attachment{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PatientCanViewAttachment(patientA, tt.attachment); got != tt.want {
+				t.Errorf("PatientCanViewAttachment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClinicCanViewPlan(t *testing.T) {
+	requested := time.Now()
+
+	tests := []struct {
+		name string
+		plan *models.TreatmentPlan
+		want bool
+	}{
+		{"published to the clinic's city", &models.TreatmentPlan{OffersRequestedAt: &requested, TargetCity: "Москва", RequiresTherapy: true}, true},
+		{"published to every city", &models.TreatmentPlan{OffersRequestedAt: &requested}, true},
+		{"not yet published", &models.TreatmentPlan{TargetCity: "Москва"}, false},
+		{"published to another city", &models.TreatmentPlan{OffersRequestedAt: &requested, TargetCity: "Казань"}, false},
+		{"published to another price segment", &models.TreatmentPlan{OffersRequestedAt: &requested, TargetPriceSegment: "premium"}, false},
+		{"needs a specialization the clinic lacks", &models.TreatmentPlan{OffersRequestedAt: &requested, RequiresSurgery: true}, false},
+		{"unpublished with the clinic's offer", &models.TreatmentPlan{Offers: []models.ClinicOffer{{ClinicID: clinicA.ID}}}, true},
+		{"unpublished with another clinic's offer", &models.TreatmentPlan{Offers: []models.ClinicOffer{{ClinicID: clinicB.ID}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClinicCanViewPlan(clinicA, tt.plan); got != tt.want {
+				t.Errorf("ClinicCanViewPlan = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClinicOwnershipPolicies(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow func(*models.Clinic) bool
+	}{
+		{"offer", func(c *models.Clinic) bool {
+			return ClinicCanManageOffer(c, &models.ClinicOffer{ClinicID: clinicA.ID})
+		}},
+		{"appointment", func(c *models.Clinic) bool {
+			return ClinicCanManageAppointment(c, &models.Appointment{ClinicID: clinicA.ID, PatientID: patientA.ID})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.allow(clinicA) {
+				t.Error("owner was denied")
+			}
+			if tt.allow(clinicB) {
+				t.Error("another clinic was allowed")
+			}
+		})
+	}
+}
+
+func TestClinicCanManagePriceList(t *testing.T) {
+	owned := []models.PriceList{
+		{ID: 1, ClinicID: clinicA.ID},
+		{ID: 2, ClinicID: clinicA.ID},
+	}
+
+	tests := []struct {
+		name  string
+		owned []models.PriceList
+		rows  []models.PriceList
+		want  bool
+	}{
+		{"own rows", owned, []models.PriceList{{ID: 1}, {ID: 2}}, true},
+		{"new rows", owned, []models.PriceList{{ID: 0}, {ID: 0}}, true},
+		{"own and new rows", owned, []models.PriceList{{ID: 2}, {ID: 0}}, true},
+		{"no rows", owned, nil, true},
+		{"foreign row ID", owned, []models.PriceList{{ID: 1}, {ID: 3}}, false},
+		{"foreign row ID with an empty list", nil, []models.PriceList{{ID: 1}}, false},
+		// Rows of another clinic slipped into owned do not grant access
+		{"foreign row in owned", []models.PriceList{{ID: 3, ClinicID: clinicB.ID}}, []models.PriceList{{ID: 3}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClinicCanManagePriceList(clinicA, tt.owned, tt.rows); got != tt.want {
+				t.Errorf("ClinicCanManagePriceList = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClinicCanViewComplaint(t *testing.T) {
+	requested := time.Now()
+
+	tests := []struct {
+		name      string
+		complaint *models.Complaint
+		want      bool
+	}{
+		{"sent to the clinic", &models.Complaint{ClinicID: clinicA.ID, ClinicResponseRequestedAt: &requested}, true},
+		{"not yet sent to the clinic", &models.Complaint{ClinicID: clinicA.ID}, false},
+		{"about another clinic", &models.Complaint{ClinicID: clinicB.ID, ClinicResponseRequestedAt: &requested}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClinicCanViewComplaint(clinicA, tt.complaint); got != tt.want {
+				t.Errorf("ClinicCanViewComplaint = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClinicCanViewAttachment(t *testing.T) {
+	requested := time.Now()
+
+	tests := []struct {
+		name       string
+		attachment *models.Attachment
+		want       bool
+	}{
+		{"complaint sent to the clinic", &models.Attachment{Complaint: &models.Complaint{ClinicID: clinicA.ID, ClinicResponseRequestedAt: &requested}}, true},
+		{"complaint not yet sent to the clinic", &models.Attachment{Complaint: &models.Complaint{ClinicID: clinicA.ID}}, false},
+		{"complaint about another clinic", &models.Attachment{Complaint: &models.Complaint{ClinicID: clinicB.ID, ClinicResponseRequestedAt: &requested}}, false},
+		{"review of the clinic", &models.Attachment{Review: &models.Review{ClinicID: clinicA.ID}}, true},
+		{"review of another clinic", &models.Attachment{Review: &models.Review{ClinicID: clinicB.ID}}, false},
+		{"unloaded owner", &models.Attachment{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClinicCanViewAttachment(clinicA, tt.attachment); got != tt.want {
+				t.Errorf("ClinicCanViewAttachment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,15 +3,23 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Server   ServerConfig
+	Database    DatabaseConfig
+	JWT         JWTConfig
+	Server      ServerConfig
+	Storage     StorageConfig
+	Scans       ScanConfig
+	Offers      OfferConfig
+	Pricing     PricingConfig
+	Reviews     ReviewConfig
+	Complaints  ComplaintConfig
+	Attachments AttachmentConfig
 }
 
 type DatabaseConfig struct {
@@ -30,8 +38,69 @@ type JWTConfig struct {
 }
 
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port      string
+	GinMode   string
+	PublicURL string // base URL of the server in links handed out, e.g. calendar feeds
+}
+
+type StorageConfig struct {
+	Driver        string // local or s3
+	LocalPath     string
+	PublicURL     string // base URL for signed local downloads
+	SigningSecret string // required in release mode; development falls back to JWT_SECRET
+	SignedURLTTL  time.Duration
+	MaxUploadSize int64
+
+	// Resumable uploads
+	UploadSessionTTL time.Duration
+	MaxChunkSize     int64
+
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UsePathStyle bool
+}
+
+type ScanConfig struct {
+	// JSON de-identification profile merged over the DICOM PS3.15 basic
+	// profile; empty uses the basic profile as is
+	DeidentificationProfile string
+
+	// Analysis job queue
+	Analyzer        string // fake
+	Workers         int
+	MaxAttempts     int
+	JobTimeout      time.Duration
+	RetryBackoff    time.Duration // doubled after each failed attempt
+	JobPollInterval time.Duration
+}
+
+type OfferConfig struct {
+	Validity            time.Duration // default time a sent offer stays open
+	ExpirySweepInterval time.Duration
+}
+
+type PricingConfig struct {
+	// How often plans in cities with edited price lists are re-estimated;
+	// edits in between are collapsed into one recompute per city
+	RecomputeInterval time.Duration
+}
+
+type ReviewConfig struct {
+	EditWindow time.Duration // how long after posting a patient may edit a review
+}
+
+type ComplaintConfig struct {
+	ResolutionSLA     time.Duration // time to resolve a complaint after it is filed
+	ClinicResponseSLA time.Duration // time a clinic has to answer a response request
+}
+
+type AttachmentConfig struct {
+	MaxSize      int64  // bytes per file
+	MaxPerItem   int    // files per complaint or review
+	VirusScanner string // fake
 }
 
 func Load() (*Config, error) {
@@ -51,6 +120,104 @@ func Load() (*Config, error) {
 		refreshExpiry = 168 * time.Hour
 	}
 
+	signedURLTTL, err := time.ParseDuration(getEnv("STORAGE_SIGNED_URL_TTL", "15m"))
+	if err != nil {
+		signedURLTTL = 15 * time.Minute
+	}
+
+	maxUploadSize, err := strconv.ParseInt(getEnv("STORAGE_MAX_UPLOAD_MB", "512"), 10, 64)
+	if err != nil {
+		maxUploadSize = 512
+	}
+
+	uploadSessionTTL, err := time.ParseDuration(getEnv("STORAGE_UPLOAD_SESSION_TTL", "24h"))
+	if err != nil {
+		uploadSessionTTL = 24 * time.Hour
+	}
+
+	maxChunkSize, err := strconv.ParseInt(getEnv("STORAGE_MAX_CHUNK_MB", "64"), 10, 64)
+	if err != nil {
+		maxChunkSize = 64
+	}
+
+	scanWorkers, err := strconv.Atoi(getEnv("SCAN_WORKERS", "2"))
+	if err != nil {
+		scanWorkers = 2
+	}
+
+	scanMaxAttempts, err := strconv.Atoi(getEnv("SCAN_JOB_MAX_ATTEMPTS", "5"))
+	if err != nil {
+		scanMaxAttempts = 5
+	}
+
+	scanJobTimeout, err := time.ParseDuration(getEnv("SCAN_JOB_TIMEOUT", "10m"))
+	if err != nil {
+		scanJobTimeout = 10 * time.Minute
+	}
+
+	scanRetryBackoff, err := time.ParseDuration(getEnv("SCAN_JOB_RETRY_BACKOFF", "30s"))
+	if err != nil {
+		scanRetryBackoff = 30 * time.Second
+	}
+
+	scanPollInterval, err := time.ParseDuration(getEnv("SCAN_JOB_POLL_INTERVAL", "5s"))
+	if err != nil {
+		scanPollInterval = 5 * time.Second
+	}
+
+	offerValidity, err := time.ParseDuration(getEnv("OFFER_VALIDITY", "336h"))
+	if err != nil || offerValidity <= 0 {
+		offerValidity = 14 * 24 * time.Hour
+	}
+
+	offerSweepInterval, err := time.ParseDuration(getEnv("OFFER_EXPIRY_SWEEP_INTERVAL", "5m"))
+	if err != nil || offerSweepInterval <= 0 {
+		offerSweepInterval = 5 * time.Minute
+	}
+
+	priceRecomputeInterval, err := time.ParseDuration(getEnv("PRICE_RECOMPUTE_INTERVAL", "1m"))
+	if err != nil || priceRecomputeInterval <= 0 {
+		priceRecomputeInterval = time.Minute
+	}
+
+	reviewEditWindow, err := time.ParseDuration(getEnv("REVIEW_EDIT_WINDOW", "72h"))
+	if err != nil || reviewEditWindow < 0 {
+		reviewEditWindow = 72 * time.Hour
+	}
+
+	complaintResolutionSLA, err := time.ParseDuration(getEnv("COMPLAINT_RESOLUTION_SLA", "720h"))
+	if err != nil || complaintResolutionSLA <= 0 {
+		complaintResolutionSLA = 30 * 24 * time.Hour
+	}
+
+	clinicResponseSLA, err := time.ParseDuration(getEnv("COMPLAINT_CLINIC_RESPONSE_SLA", "120h"))
+	if err != nil || clinicResponseSLA <= 0 {
+		clinicResponseSLA = 5 * 24 * time.Hour
+	}
+
+	attachmentMaxSize, err := strconv.ParseInt(getEnv("ATTACHMENT_MAX_MB", "20"), 10, 64)
+	if err != nil || attachmentMaxSize <= 0 {
+		attachmentMaxSize = 20
+	}
+
+	attachmentMaxPerItem, err := strconv.Atoi(getEnv("ATTACHMENT_MAX_PER_ITEM", "10"))
+	if err != nil || attachmentMaxPerItem <= 0 {
+		attachmentMaxPerItem = 10
+	}
+
+	// Signed download URLs get their own key; sharing JWT_SECRET is only
+	// tolerated in development
+	signingSecret := os.Getenv("STORAGE_SIGNING_SECRET")
+	if signingSecret == "" {
+		if getEnv("GIN_MODE", "debug") == "release" {
+			return nil, fmt.Errorf("STORAGE_SIGNING_SECRET must be set in release mode")
+		}
+		fmt.Println("WARNING: STORAGE_SIGNING_SECRET is not set, signing storage URLs with JWT_SECRET")
+		signingSecret = getEnv("JWT_SECRET", "change-this-secret")
+	}
+
+	port := getEnv("PORT", "8080")
+
 	config := &Config{
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -66,8 +233,53 @@ func Load() (*Config, error) {
 			RefreshExpiry:  refreshExpiry,
 		},
 		Server: ServerConfig{
-			Port:    getEnv("PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:      port,
+			GinMode:   getEnv("GIN_MODE", "debug"),
+			PublicURL: getEnv("PUBLIC_URL", "http://localhost:"+port),
+		},
+		Storage: StorageConfig{
+			Driver:           getEnv("STORAGE_DRIVER", "local"),
+			LocalPath:        getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+			PublicURL:        getEnv("STORAGE_PUBLIC_URL", "http://localhost:"+port+"/api/files"),
+			SigningSecret:    signingSecret,
+			SignedURLTTL:     signedURLTTL,
+			MaxUploadSize:    maxUploadSize << 20,
+			UploadSessionTTL: uploadSessionTTL,
+			MaxChunkSize:     maxChunkSize << 20,
+			S3Endpoint:       getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			S3Region:         getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:         getEnv("S3_BUCKET", "dental-scans"),
+			S3AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:      getEnv("S3_SECRET_KEY", ""),
+			S3UsePathStyle:   getEnv("S3_USE_PATH_STYLE", "true") == "true",
+		},
+		Scans: ScanConfig{
+			DeidentificationProfile: getEnv("DEID_PROFILE_FILE", ""),
+			Analyzer:                getEnv("SCAN_ANALYZER", "fake"),
+			Workers:                 scanWorkers,
+			MaxAttempts:             scanMaxAttempts,
+			JobTimeout:              scanJobTimeout,
+			RetryBackoff:            scanRetryBackoff,
+			JobPollInterval:         scanPollInterval,
+		},
+		Offers: OfferConfig{
+			Validity:            offerValidity,
+			ExpirySweepInterval: offerSweepInterval,
+		},
+		Pricing: PricingConfig{
+			RecomputeInterval: priceRecomputeInterval,
+		},
+		Reviews: ReviewConfig{
+			EditWindow: reviewEditWindow,
+		},
+		Complaints: ComplaintConfig{
+			ResolutionSLA:     complaintResolutionSLA,
+			ClinicResponseSLA: clinicResponseSLA,
+		},
+		Attachments: AttachmentConfig{
+			MaxSize:      attachmentMaxSize << 20,
+			MaxPerItem:   attachmentMaxPerItem,
+			VirusScanner: getEnv("ATTACHMENT_VIRUS_SCANNER", "fake"),
 		},
 	}
 
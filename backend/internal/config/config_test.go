@@ -0,0 +1,39 @@
+package config
+
+import "testing"
+
+func TestLoadStorageSigningSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "jwt-secret")
+
+	t.Run("separate secret", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "release")
+		t.Setenv("STORAGE_SIGNING_SECRET", "storage-secret")
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if cfg.Storage.SigningSecret != "storage-secret" {
+			t.Errorf("SigningSecret = %q, want storage-secret", cfg.Storage.SigningSecret)
+		}
+	})
+
+	t.Run("missing in release mode", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "release")
+		t.Setenv("STORAGE_SIGNING_SECRET", "")
+		if _, err := Load(); err == nil {
+			t.Error("Load succeeded without a storage signing secret in release mode")
+		}
+	})
+
+	t.Run("development fallback", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "debug")
+		t.Setenv("STORAGE_SIGNING_SECRET", "")
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if cfg.Storage.SigningSecret != "jwt-secret" {
+			t.Errorf("SigningSecret = %q, want the JWT secret", cfg.Storage.SigningSecret)
+		}
+	})
+}
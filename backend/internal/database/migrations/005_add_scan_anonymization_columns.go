@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func AddScanAnonymizationColumns(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.CTScan{},
+	)
+}
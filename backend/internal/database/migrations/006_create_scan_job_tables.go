@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateScanJobTables(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.ScanJob{},
+	)
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateProcedureCatalogTables(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.Procedure{},
+		&models.ProcedureSynonym{},
+		&models.PriceList{},
+		&models.TreatmentItem{},
+	)
+}
@@ -0,0 +1,11 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreatePlanStatusHistoryTables(db *gorm.DB) error {
+	return db.AutoMigrate(&models.TreatmentPlanStatusChange{})
+}
@@ -0,0 +1,11 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func AddPlanTargetingColumns(db *gorm.DB) error {
+	return db.AutoMigrate(&models.TreatmentPlan{}, &models.Clinic{})
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateClinicDiscountTables(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.ClinicDiscount{},
+		&models.ClinicOffer{},
+		&models.OfferItem{},
+	)
+}
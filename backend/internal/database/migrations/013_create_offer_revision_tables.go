@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateOfferRevisionTables(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.ClinicOffer{},
+		&models.ClinicOfferRevision{},
+	)
+}
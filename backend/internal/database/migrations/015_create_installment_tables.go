@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateInstallmentTables(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.InstallmentProduct{},
+		&models.PaymentSchedule{},
+		&models.ScheduledPayment{},
+	)
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateSchedulingTables(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.Clinic{},
+		&models.ClinicResource{},
+		&models.ClinicWorkingHours{},
+		&models.ClinicBlockedDate{},
+		&models.Appointment{},
+	)
+}
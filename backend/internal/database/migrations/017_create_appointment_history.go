@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateAppointmentHistory(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.Clinic{},
+		&models.AppointmentChange{},
+	)
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateLeadTables adds the lead pipeline and opens a lead for every offer
+// accepted before it existed
+func CreateLeadTables(db *gorm.DB) error {
+	if err := db.AutoMigrate(
+		&models.ClinicStaff{},
+		&models.Lead{},
+		&models.LeadStatusChange{},
+		&models.LeadTask{},
+		&models.LeadContact{},
+	); err != nil {
+		return err
+	}
+
+	var offers []models.ClinicOffer
+	if err := db.Preload("TreatmentPlan").
+		Where("status = ?", models.OfferStatusAccepted).
+		Find(&offers).Error; err != nil {
+		return err
+	}
+
+	for _, offer := range offers {
+		if offer.TreatmentPlan == nil {
+			continue
+		}
+		lead := models.Lead{
+			ClinicID:        offer.ClinicID,
+			ClinicOfferID:   offer.ID,
+			TreatmentPlanID: offer.TreatmentPlanID,
+			PatientID:       offer.TreatmentPlan.PatientID,
+			Status:          models.LeadStatusNew,
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lead).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func AddVerifiedReviews(db *gorm.DB) error {
+	return db.AutoMigrate(&models.Review{})
+}
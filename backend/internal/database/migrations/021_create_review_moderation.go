@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/moderation"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CreateReviewModeration adds review moderation and clinic replies. Existing
+// reviews enter the moderation queue with their auto-flags.
+func CreateReviewModeration(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.Review{}, &models.ReviewReply{}); err != nil {
+		return err
+	}
+
+	var reviews []models.Review
+	if err := db.Select("id", "comment").Find(&reviews).Error; err != nil {
+		return err
+	}
+
+	for _, review := range reviews {
+		flags := moderation.Scan(review.Comment)
+		if len(flags) == 0 {
+			continue
+		}
+		if err := db.Model(&models.Review{}).
+			Where("id = ?", review.ID).
+			Update("moderation_flags", strings.Join(flags, ",")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateComplaintWorkflow adds complaint case handling and notifications.
+// Existing complaints get the default 30-day resolution deadline and a
+// timeline that starts when they were filed.
+func CreateComplaintWorkflow(db *gorm.DB) error {
+	if err := db.AutoMigrate(
+		&models.Complaint{},
+		&models.ComplaintEvent{},
+		&models.Notification{},
+	); err != nil {
+		return err
+	}
+
+	var complaints []models.Complaint
+	if err := db.Preload("Patient").Where("due_at IS NULL").Find(&complaints).Error; err != nil {
+		return err
+	}
+
+	for _, complaint := range complaints {
+		dueAt := complaint.CreatedAt.AddDate(0, 0, 30)
+		if err := db.Model(&models.Complaint{}).
+			Where("id = ?", complaint.ID).
+			Update("due_at", dueAt).Error; err != nil {
+			return err
+		}
+
+		event := models.ComplaintEvent{
+			CreatedAt:   complaint.CreatedAt,
+			ComplaintID: complaint.ID,
+			Kind:        models.ComplaintEventCreated,
+			ToStatus:    models.ComplaintStatusOpen,
+			ActorRole:   models.RolePatient,
+		}
+		if complaint.Patient.UserID != 0 {
+			userID := complaint.Patient.UserID
+			event.ActorUserID = &userID
+		}
+		if err := db.Create(&event).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,6 +15,27 @@ func RunAll(db *gorm.DB) error {
 	// Add migrations in order
 	runner.AddMigration("001", "Create Constants Tables", CreateConstantsTables)
 	runner.AddMigration("002", "Create Business Tables", CreateBusinessTables)
+	runner.AddMigration("003", "Create Upload Tables", CreateUploadTables)
+	runner.AddMigration("004", "Create Scan Metadata Tables", CreateScanMetadataTables)
+	runner.AddMigration("005", "Add Scan Anonymization Columns", AddScanAnonymizationColumns)
+	runner.AddMigration("006", "Create Scan Job Tables", CreateScanJobTables)
+	runner.AddMigration("007", "Create Procedure Catalog Tables", CreateProcedureCatalogTables)
+	runner.AddMigration("008", "Create Plan Status History Tables", CreatePlanStatusHistoryTables)
+	runner.AddMigration("009", "Add Plan Targeting Columns", AddPlanTargetingColumns)
+	runner.AddMigration("010", "Add Location Coordinates", AddLocationCoordinates)
+	runner.AddMigration("011", "Create Offer Item Tables", CreateOfferItemTables)
+	runner.AddMigration("012", "Create Clinic Discount Tables", CreateClinicDiscountTables)
+	runner.AddMigration("013", "Create Offer Revision Tables", CreateOfferRevisionTables)
+	runner.AddMigration("014", "Add Offer Wait Days", AddOfferWaitDays)
+	runner.AddMigration("015", "Create Installment Tables", CreateInstallmentTables)
+	runner.AddMigration("016", "Create Scheduling Tables", CreateSchedulingTables)
+	runner.AddMigration("017", "Create Appointment History", CreateAppointmentHistory)
+	runner.AddMigration("018", "Create Calendar Tokens", CreateCalendarTokens)
+	runner.AddMigration("019", "Create Lead Tables", CreateLeadTables)
+	runner.AddMigration("020", "Add Verified Reviews", AddVerifiedReviews)
+	runner.AddMigration("021", "Create Review Moderation", CreateReviewModeration)
+	runner.AddMigration("022", "Create Complaint Workflow", CreateComplaintWorkflow)
+	runner.AddMigration("023", "Create Attachments", CreateAttachments)
 
 	// Run migrations
 	if err := runner.Run(); err != nil {
@@ -33,5 +54,10 @@ func RunAll(db *gorm.DB) error {
 		return err
 	}
 
+	// Link free-text price list rows and plan items to the procedure catalog
+	if err := seeds.LinkProcedureCodes(db); err != nil {
+		return err
+	}
+
 	return nil
 }
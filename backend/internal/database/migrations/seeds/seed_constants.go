@@ -35,14 +35,20 @@ func SeedConstants(db *gorm.DB) error {
 	// Seed Treatment Statuses
 	treatmentStatuses := []models.TreatmentStatus{
 		{Code: "generated", Name: "Сформирован", SortOrder: 1},
-		{Code: "offers_received", Name: "Получены предложения", SortOrder: 2},
-		{Code: "offer_accepted", Name: "Предложение принято", SortOrder: 3},
-		{Code: "in_progress", Name: "В процессе", SortOrder: 4},
-		{Code: "completed", Name: "Завершен", SortOrder: 5},
+		{Code: "offers_requested", Name: "Запрошены предложения", SortOrder: 2},
+		{Code: "offers_received", Name: "Получены предложения", SortOrder: 3},
+		{Code: "offer_selected", Name: "Предложение выбрано", SortOrder: 4},
+		{Code: "in_treatment", Name: "Лечение", SortOrder: 5},
+		{Code: "completed", Name: "Завершен", SortOrder: 6},
+		{Code: "cancelled", Name: "Отменен", SortOrder: 7},
 	}
 	for _, status := range treatmentStatuses {
 		db.Where(models.TreatmentStatus{Code: status.Code}).FirstOrCreate(&status)
 	}
+	// Codes that never matched the plan lifecycle
+	db.Model(&models.TreatmentStatus{}).
+		Where("code IN ?", []string{"offer_accepted", "in_progress"}).
+		Update("is_active", false)
 
 	// Seed Offer Statuses
 	offerStatuses := []models.OfferStatus{
@@ -50,6 +56,8 @@ func SeedConstants(db *gorm.DB) error {
 		{Code: "sent", Name: "Отправлено", SortOrder: 2},
 		{Code: "accepted", Name: "Принято", SortOrder: 3},
 		{Code: "rejected", Name: "Отклонено", SortOrder: 4},
+		{Code: "expired", Name: "Истек срок", SortOrder: 5},
+		{Code: "withdrawn", Name: "Отозвано", SortOrder: 6},
 	}
 	for _, status := range offerStatuses {
 		db.Where(models.OfferStatus{Code: status.Code}).FirstOrCreate(&status)
@@ -61,6 +69,7 @@ func SeedConstants(db *gorm.DB) error {
 		{Code: "confirmed", Name: "Подтвержден", SortOrder: 2},
 		{Code: "completed", Name: "Завершен", SortOrder: 3},
 		{Code: "cancelled", Name: "Отменен", SortOrder: 4},
+		{Code: "no_show", Name: "Неявка", SortOrder: 5},
 	}
 	for _, status := range appointmentStatuses {
 		db.Where(models.AppointmentStatus{Code: status.Code}).FirstOrCreate(&status)
@@ -124,14 +133,16 @@ func SeedConstants(db *gorm.DB) error {
 	db.Where("code = ?", "moscow").First(&moscow)
 
 	moscowDistricts := []models.District{
-		{CityID: moscow.ID, Code: "moscow_central", Name: "Центральный", SortOrder: 1},
-		{CityID: moscow.ID, Code: "moscow_northern", Name: "Северный", SortOrder: 2},
-		{CityID: moscow.ID, Code: "moscow_southern", Name: "Южный", SortOrder: 3},
-		{CityID: moscow.ID, Code: "moscow_eastern", Name: "Восточный", SortOrder: 4},
-		{CityID: moscow.ID, Code: "moscow_western", Name: "Западный", SortOrder: 5},
+		{CityID: moscow.ID, Code: "moscow_central", Name: "Центральный", Latitude: coord(55.7539), Longitude: coord(37.6208), SortOrder: 1},
+		{CityID: moscow.ID, Code: "moscow_northern", Name: "Северный", Latitude: coord(55.8386), Longitude: coord(37.5253), SortOrder: 2},
+		{CityID: moscow.ID, Code: "moscow_southern", Name: "Южный", Latitude: coord(55.6236), Longitude: coord(37.6494), SortOrder: 3},
+		{CityID: moscow.ID, Code: "moscow_eastern", Name: "Восточный", Latitude: coord(55.7870), Longitude: coord(37.7775), SortOrder: 4},
+		{CityID: moscow.ID, Code: "moscow_western", Name: "Западный", Latitude: coord(55.7281), Longitude: coord(37.4433), SortOrder: 5},
 	}
 	for _, district := range moscowDistricts {
-		db.Where(models.District{Code: district.Code}).FirstOrCreate(&district)
+		db.Where(models.District{Code: district.Code}).
+			Assign(models.District{Latitude: district.Latitude, Longitude: district.Longitude}).
+			FirstOrCreate(&district)
 	}
 
 	// Seed Districts for SPb
@@ -139,15 +150,25 @@ func SeedConstants(db *gorm.DB) error {
 	db.Where("code = ?", "spb").First(&spb)
 
 	spbDistricts := []models.District{
-		{CityID: spb.ID, Code: "spb_central", Name: "Центральный", SortOrder: 1},
-		{CityID: spb.ID, Code: "spb_nevsky", Name: "Невский", SortOrder: 2},
-		{CityID: spb.ID, Code: "spb_vasileostrovsky", Name: "Василеостровский", SortOrder: 3},
-		{CityID: spb.ID, Code: "spb_admiralteysky", Name: "Адмиралтейский", SortOrder: 4},
+		{CityID: spb.ID, Code: "spb_central", Name: "Центральный", Latitude: coord(59.9343), Longitude: coord(30.3351), SortOrder: 1},
+		{CityID: spb.ID, Code: "spb_nevsky", Name: "Невский", Latitude: coord(59.8910), Longitude: coord(30.4540), SortOrder: 2},
+		{CityID: spb.ID, Code: "spb_vasileostrovsky", Name: "Василеостровский", Latitude: coord(59.9420), Longitude: coord(30.2500), SortOrder: 3},
+		{CityID: spb.ID, Code: "spb_admiralteysky", Name: "Адмиралтейский", Latitude: coord(59.9160), Longitude: coord(30.2960), SortOrder: 4},
 	}
 	for _, district := range spbDistricts {
-		db.Where(models.District{Code: district.Code}).FirstOrCreate(&district)
+		db.Where(models.District{Code: district.Code}).
+			Assign(models.District{Latitude: district.Latitude, Longitude: district.Longitude}).
+			FirstOrCreate(&district)
 	}
 
+	// Seed Procedure Catalog
+	seedProcedures(db)
+
 	log.Println("✅ Constants seeded successfully")
 	return nil
 }
+
+// coord returns a pointer for nullable coordinate columns
+func coord(v float64) *float64 {
+	return &v
+}
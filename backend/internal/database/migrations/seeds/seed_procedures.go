@@ -0,0 +1,102 @@
+package seeds
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// procedureCatalog is the canonical list of procedures with the names
+// clinics commonly use for them
+var procedureCatalog = []struct {
+	procedure models.Procedure
+	synonyms  []string
+}{
+	// Therapy
+	{models.Procedure{Code: "therapy_caries", Name: "Лечение кариеса", Specialization: models.SpecTherapy, SortOrder: 1},
+		[]string{"Лечение кариеса + пломба", "Лечение поверхностного кариеса", "Лечение глубокого кариеса"}},
+	{models.Procedure{Code: "therapy_pulpitis", Name: "Лечение пульпита (лечение каналов)", Specialization: models.SpecTherapy, SortOrder: 2},
+		[]string{"Лечение пульпита", "Лечение каналов", "Эндодонтическое лечение"}},
+	{models.Procedure{Code: "therapy_periodontitis", Name: "Лечение периодонтита", Specialization: models.SpecTherapy, SortOrder: 3},
+		nil},
+	{models.Procedure{Code: "therapy_filling", Name: "Пломба светоотверждаемая", Specialization: models.SpecTherapy, SortOrder: 4},
+		[]string{"Пломба", "Композитная пломба", "Световая пломба"}},
+
+	// Orthopedics
+	{models.Procedure{Code: "orthopedics_crown_pfm", Name: "Коронка металлокерамическая", Specialization: models.SpecOrthopedics, SortOrder: 1},
+		[]string{"Металлокерамическая коронка"}},
+	{models.Procedure{Code: "orthopedics_crown_zirconia", Name: "Коронка циркониевая", Specialization: models.SpecOrthopedics, SortOrder: 2},
+		[]string{"Циркониевая коронка", "Коронка из диоксида циркония"}},
+	{models.Procedure{Code: "orthopedics_bridge_3", Name: "Мостовидный протез (3 единицы)", Specialization: models.SpecOrthopedics, SortOrder: 3},
+		[]string{"Мостовидный протез", "Мост на 3 единицы"}},
+
+	// Surgery
+	{models.Procedure{Code: "surgery_extraction_simple", Name: "Удаление зуба (простое)", Specialization: models.SpecSurgery, SortOrder: 1},
+		[]string{"Удаление зуба", "Простое удаление зуба"}},
+	{models.Procedure{Code: "surgery_extraction_complex", Name: "Удаление зуба (сложное)", Specialization: models.SpecSurgery, SortOrder: 2},
+		[]string{"Сложное удаление зуба", "Удаление зуба мудрости", "Удаление ретинированного зуба"}},
+	{models.Procedure{Code: "surgery_implant", Name: "Имплантация", Specialization: models.SpecSurgery, SortOrder: 3},
+		[]string{"Имплант", "Установка импланта", "Имплант (Nobel Biocare)", "Имплант (Straumann)"}},
+	{models.Procedure{Code: "surgery_bone_graft", Name: "Костная пластика", Specialization: models.SpecSurgery, SortOrder: 4},
+		[]string{"Наращивание костной ткани"}},
+
+	// Hygiene
+	{models.Procedure{Code: "hygiene_cleaning", Name: "Профессиональная чистка зубов", Specialization: models.SpecHygiene, SortOrder: 1},
+		[]string{"Профессиональная чистка", "Профессиональная гигиена"}},
+	{models.Procedure{Code: "hygiene_air_flow", Name: "Чистка Air Flow", Specialization: models.SpecHygiene, SortOrder: 2},
+		[]string{"Air Flow"}},
+	{models.Procedure{Code: "hygiene_whitening", Name: "Отбеливание зубов", Specialization: models.SpecHygiene, SortOrder: 3},
+		[]string{"Отбеливание"}},
+
+	// Periodontics
+	{models.Procedure{Code: "periodontics_periodontitis", Name: "Лечение пародонтита (за квадрант)", Specialization: models.SpecPeriodontics, SortOrder: 1},
+		[]string{"Лечение пародонтита", "Кюретаж"}},
+	{models.Procedure{Code: "periodontics_gum_graft", Name: "Пластика десны", Specialization: models.SpecPeriodontics, SortOrder: 2},
+		nil},
+}
+
+// seedProcedures creates the procedure catalog and its synonyms
+func seedProcedures(db *gorm.DB) {
+	for _, entry := range procedureCatalog {
+		procedure := entry.procedure
+		db.Where(models.Procedure{Code: procedure.Code}).FirstOrCreate(&procedure)
+
+		for _, name := range entry.synonyms {
+			synonym := models.ProcedureSynonym{ProcedureID: procedure.ID, Name: name}
+			db.Where(synonym).FirstOrCreate(&synonym)
+		}
+	}
+}
+
+// LinkProcedureCodes fills in the procedure code of price list rows and
+// treatment items whose name matches a catalog name or synonym exactly
+func LinkProcedureCodes(db *gorm.DB) error {
+	const match = `
+		SELECT p.code FROM procedures p
+		WHERE p.deleted_at IS NULL AND p.specialization = %[1]s.specialization
+		AND (LOWER(p.name) = LOWER(%[1]s.%[2]s) OR EXISTS (
+			SELECT 1 FROM procedure_synonyms s
+			WHERE s.procedure_id = p.id AND LOWER(s.name) = LOWER(%[1]s.%[2]s)))
+		LIMIT 1`
+
+	targets := []struct{ table, column string }{
+		{"price_lists", "service_name"},
+		{"treatment_items", "procedure"},
+	}
+	for _, t := range targets {
+		subquery := fmt.Sprintf(match, t.table, t.column)
+		result := db.Exec(fmt.Sprintf(
+			"UPDATE %s SET procedure_code = (%s) WHERE (procedure_code IS NULL OR procedure_code = '') AND EXISTS (%s)",
+			t.table, subquery, subquery,
+		))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("🔗 Linked %d %s rows to the procedure catalog", result.RowsAffected, t.table)
+		}
+	}
+	return nil
+}
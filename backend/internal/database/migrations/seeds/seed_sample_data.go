@@ -78,6 +78,9 @@ func SeedSampleData(db *gorm.DB) error {
 		City:              "Москва",
 		District:          "Центральный",
 		Address:           "ул. Тверская, д. 15",
+		Latitude:          coord(55.7645),
+		Longitude:         coord(37.6053),
+		PriceSegment:      "премиум",
 		HasTherapy:        true,
 		HasOrthopedics:    true,
 		HasSurgery:        true,
@@ -113,6 +116,9 @@ func SeedSampleData(db *gorm.DB) error {
 		City:              "Москва",
 		District:          "Северный",
 		Address:           "Дмитровское шоссе, д. 89",
+		Latitude:          coord(55.8740),
+		Longitude:         coord(37.5400),
+		PriceSegment:      "средний",
 		HasTherapy:        true,
 		HasOrthopedics:    true,
 		HasSurgery:        true,
@@ -210,6 +216,53 @@ func SeedSampleData(db *gorm.DB) error {
 		return fmt.Errorf("failed to create price list for clinic2: %w", err)
 	}
 
+	installmentProducts := []models.InstallmentProduct{
+		{ClinicID: clinic1.ID, Name: "Рассрочка 0% на 6 месяцев", TermMonths: 6, IsActive: true},
+		{ClinicID: clinic1.ID, Name: "Рассрочка на 12 месяцев", TermMonths: 12, MarkupPercent: 5, IsActive: true},
+		{ClinicID: clinic2.ID, Name: "Кредит на 12 месяцев", TermMonths: 12, AnnualInterestRate: 14.9, DownPaymentPercent: 20, IsActive: true},
+	}
+	if err := db.Create(&installmentProducts).Error; err != nil {
+		return fmt.Errorf("failed to create installment products: %w", err)
+	}
+
+	resources := []models.ClinicResource{
+		{ClinicID: clinic1.ID, Kind: models.ResourceKindChair, Name: "Кабинет 1", IsActive: true},
+		{ClinicID: clinic1.ID, Kind: models.ResourceKindChair, Name: "Кабинет 2", IsActive: true},
+		{ClinicID: clinic1.ID, Kind: models.ResourceKindDoctor, Name: "Хирург Смирнов А.В.", Specialization: models.SpecSurgery, IsActive: true},
+		{ClinicID: clinic2.ID, Kind: models.ResourceKindChair, Name: "Кресло 1", IsActive: true},
+		{ClinicID: clinic2.ID, Kind: models.ResourceKindChair, Name: "Кресло 2", IsActive: true},
+	}
+	if err := db.Create(&resources).Error; err != nil {
+		return fmt.Errorf("failed to create clinic resources: %w", err)
+	}
+
+	staff := []models.ClinicStaff{
+		{ClinicID: clinic1.ID, Name: "Ольга Кузнецова", Position: "Администратор", Phone: "+7 (495) 123-45-67", IsActive: true},
+		{ClinicID: clinic1.ID, Name: "Дмитрий Орлов", Position: "Координатор лечения", IsActive: true},
+		{ClinicID: clinic2.ID, Name: "Анна Белова", Position: "Администратор", IsActive: true},
+	}
+	if err := db.Create(&staff).Error; err != nil {
+		return fmt.Errorf("failed to create clinic staff: %w", err)
+	}
+
+	var workingHours []models.ClinicWorkingHours
+	for weekday := int(time.Monday); weekday <= int(time.Friday); weekday++ {
+		workingHours = append(workingHours,
+			models.ClinicWorkingHours{ClinicID: clinic1.ID, Weekday: weekday, OpensAt: "09:00", ClosesAt: "21:00"},
+			models.ClinicWorkingHours{ClinicID: clinic2.ID, Weekday: weekday, OpensAt: "09:00", ClosesAt: "20:00"},
+		)
+	}
+	workingHours = append(workingHours,
+		models.ClinicWorkingHours{ClinicID: clinic1.ID, Weekday: int(time.Saturday), OpensAt: "10:00", ClosesAt: "18:00"},
+		models.ClinicWorkingHours{ClinicID: clinic2.ID, Weekday: int(time.Saturday), OpensAt: "10:00", ClosesAt: "16:00"},
+		// The surgeon only operates on Tuesdays and Thursdays
+		models.ClinicWorkingHours{ClinicID: clinic1.ID, ResourceID: &resources[2].ID, Weekday: int(time.Tuesday), OpensAt: "10:00", ClosesAt: "18:00"},
+		models.ClinicWorkingHours{ClinicID: clinic1.ID, ResourceID: &resources[2].ID, Weekday: int(time.Thursday), OpensAt: "10:00", ClosesAt: "18:00"},
+	)
+	if err := db.Create(&workingHours).Error; err != nil {
+		return fmt.Errorf("failed to create working hours: %w", err)
+	}
+
 	// 5. CREATE CT SCANS FOR PATIENT
 	scan1 := &models.CTScan{
 		PatientID:   patient.ID,
@@ -234,10 +287,13 @@ func SeedSampleData(db *gorm.DB) error {
 	}
 
 	// 6. CREATE TREATMENT PLAN FOR SCAN 2 (most recent)
+	offersRequestedAt := time.Date(2024, 12, 11, 9, 0, 0, 0, time.UTC)
 	treatmentPlan := &models.TreatmentPlan{
 		PatientID:            patient.ID,
 		CTScanID:             scan2.ID,
-		Status:               models.PlanStatusGenerated,
+		Status:               models.PlanStatusOffersReceived,
+		TargetCity:           "Москва",
+		OffersRequestedAt:    &offersRequestedAt,
 		RequiresTherapy:      true,
 		RequiresOrthopedics:  true,
 		RequiresSurgery:      true,
@@ -280,10 +336,12 @@ func SeedSampleData(db *gorm.DB) error {
 	}
 
 	// 8. CREATE CLINIC OFFERS
+	offersValidUntil := time.Now().AddDate(0, 0, 14)
 	offer1 := &models.ClinicOffer{
 		TreatmentPlanID:   treatmentPlan.ID,
 		ClinicID:          clinic1.ID,
 		Status:            models.OfferStatusSent,
+		ValidUntil:        &offersValidUntil,
 		TherapyCost:       32500,
 		OrthopedicsCost:   90000,
 		SurgeryCost:       95000,
@@ -294,6 +352,10 @@ func SeedSampleData(db *gorm.DB) error {
 		InstallmentMonths: 12,
 		WarrantyDetails:   "10 лет на имплант, 5 лет на коронки, 1-2 года на пломбы",
 		Notes:             "Премиальные материалы, опытные хирурги, индивидуальный подход",
+		WaitDays:          waitDays(3),
+		Items: sampleOfferItems(treatmentItems,
+			[]int{9500, 15000, 8000, 45000, 45000, 95000, 5000},
+			[]int{1, 2, 1, 5, 5, 10, 0}),
 	}
 	if err := db.Create(offer1).Error; err != nil {
 		return fmt.Errorf("failed to create offer1: %w", err)
@@ -303,6 +365,7 @@ func SeedSampleData(db *gorm.DB) error {
 		TreatmentPlanID:   treatmentPlan.ID,
 		ClinicID:          clinic2.ID,
 		Status:            models.OfferStatusSent,
+		ValidUntil:        &offersValidUntil,
 		TherapyCost:       26500,
 		OrthopedicsCost:   76000,
 		SurgeryCost:       85000,
@@ -313,6 +376,10 @@ func SeedSampleData(db *gorm.DB) error {
 		InstallmentMonths: 12,
 		WarrantyDetails:   "10 лет на имплант, 4 года на коронки, 1-2 года на пломбы",
 		Notes:             "Хорошее соотношение цена-качество, принимаем страховки, гибкий график",
+		WaitDays:          waitDays(7),
+		Items: sampleOfferItems(treatmentItems,
+			[]int{8000, 12500, 6000, 38000, 38000, 85000, 4000},
+			[]int{1, 2, 1, 4, 4, 10, 0}),
 	}
 	if err := db.Create(offer2).Error; err != nil {
 		return fmt.Errorf("failed to create offer2: %w", err)
@@ -398,3 +465,22 @@ func SeedSampleData(db *gorm.DB) error {
 
 	return nil
 }
+
+func waitDays(n int) *int {
+	return &n
+}
+
+// sampleOfferItems prices treatment items in order
+func sampleOfferItems(treatmentItems []models.TreatmentItem, prices, warrantyYears []int) []models.OfferItem {
+	items := make([]models.OfferItem, len(treatmentItems))
+	for i, item := range treatmentItems {
+		items[i] = models.OfferItem{
+			TreatmentItemID: item.ID,
+			Specialization:  item.Specialization,
+			ListPrice:       prices[i],
+			Price:           prices[i],
+			WarrantyYears:   warrantyYears[i],
+		}
+	}
+	return items
+}
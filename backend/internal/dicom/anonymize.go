@@ -0,0 +1,97 @@
+package dicom
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Deidentify writes a de-identified copy of a DICOM file or ZIP archive of
+// DICOM files. Archive entries that are not DICOM images, including
+// DICOMDIR indexes, are dropped and the remaining entries are renamed,
+// since file names often carry the patient's name. Pixel data is copied
+// unchanged; burned-in annotations are not detected.
+func Deidentify(r io.Reader, w io.Writer, profile *Profile) error {
+	anonymizer, err := NewAnonymizer(profile)
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	if string(magic) == "PK\x03\x04" {
+		return deidentifyArchive(br, w, anonymizer)
+	}
+
+	file, err := ReadHeader(br)
+	if err != nil {
+		return err
+	}
+	if isDirectory(file) {
+		return newError(ReasonNoImageInstances, "file is a DICOMDIR index without images")
+	}
+
+	anonymizer.Apply(file)
+	return Write(w, file)
+}
+
+func deidentifyArchive(r io.Reader, w io.Writer, anonymizer *Anonymizer) error {
+	archive, cleanup, err := spoolArchive(r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	out := zip.NewWriter(w)
+	written := 0
+	for _, entry := range archive.File {
+		if skipArchiveEntry(entry) {
+			continue
+		}
+
+		ok, err := deidentifyEntry(entry, out, anonymizer, written+1)
+		if err != nil {
+			return err
+		}
+		if ok {
+			written++
+		}
+	}
+
+	if written == 0 {
+		return newError(ReasonNoImageInstances, "archive contains no DICOM images")
+	}
+	return out.Close()
+}
+
+// deidentifyEntry copies one archive entry if it is a DICOM image
+func deidentifyEntry(entry *zip.File, out *zip.Writer, anonymizer *Anonymizer, index int) (bool, error) {
+	f, err := entry.Open()
+	if err != nil {
+		return false, newError(ReasonMalformed, "cannot open %s: %v", entry.Name, err)
+	}
+	defer f.Close()
+
+	file, err := ReadHeader(f)
+	if err != nil {
+		if _, ok := AsError(err); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	if isDirectory(file) {
+		return false, nil
+	}
+
+	anonymizer.Apply(file)
+
+	dst, err := out.Create(fmt.Sprintf("IMG%05d.dcm", index))
+	if err != nil {
+		return false, err
+	}
+	if err := Write(dst, file); err != nil {
+		return false, err
+	}
+	return true, nil
+}
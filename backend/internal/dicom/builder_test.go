@@ -0,0 +1,207 @@
+package dicom
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// builder hand-encodes DICOM elements so that tests do not depend on the
+// package's own encoder
+type builder struct {
+	buf      bytes.Buffer
+	order    binary.ByteOrder
+	explicit bool
+}
+
+func newBuilder(syntax string) *builder {
+	b := &builder{order: binary.LittleEndian, explicit: true}
+	switch syntax {
+	case ImplicitVRLittleEndian:
+		b.explicit = false
+	case ExplicitVRBigEndian:
+		b.order = binary.BigEndian
+	}
+	return b
+}
+
+func (b *builder) tag(tag Tag) {
+	binary.Write(&b.buf, b.order, tag.Group)
+	binary.Write(&b.buf, b.order, tag.Element)
+}
+
+func (b *builder) header(tag Tag, vr string, length uint32) *builder {
+	b.tag(tag)
+	switch {
+	case !b.explicit:
+		binary.Write(&b.buf, b.order, length)
+	case longVRs[vr]:
+		b.buf.WriteString(vr)
+		b.buf.Write([]byte{0, 0})
+		binary.Write(&b.buf, b.order, length)
+	default:
+		b.buf.WriteString(vr)
+		binary.Write(&b.buf, b.order, uint16(length))
+	}
+	return b
+}
+
+// element writes value as is; callers pass even-length values
+func (b *builder) element(tag Tag, vr, value string) *builder {
+	b.header(tag, vr, uint32(len(value)))
+	b.buf.WriteString(value)
+	return b
+}
+
+func (b *builder) uint16(tag Tag, value uint16) *builder {
+	b.header(tag, "US", 2)
+	binary.Write(&b.buf, b.order, value)
+	return b
+}
+
+// sequence writes an undefined-length sequence, one item per callback
+func (b *builder) sequence(tag Tag, items ...func(*builder)) *builder {
+	b.header(tag, "SQ", undefinedLength)
+	for _, item := range items {
+		b.tag(tagItem)
+		binary.Write(&b.buf, b.order, uint32(undefinedLength))
+		item(b)
+		b.tag(tagItemDelimitation)
+		binary.Write(&b.buf, b.order, uint32(0))
+	}
+	b.tag(tagSequenceDelimiter)
+	binary.Write(&b.buf, b.order, uint32(0))
+	return b
+}
+
+func (b *builder) raw(data []byte) *builder {
+	b.buf.Write(data)
+	return b
+}
+
+func (b *builder) bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// part10 wraps a data set encoded in syntax with a zero preamble and file
+// meta information
+func part10(t *testing.T, syntax, sopClassUID, sopInstanceUID string, dataSet []byte) []byte {
+	t.Helper()
+
+	meta := newBuilder(ExplicitVRLittleEndian).
+		element(TagMediaStorageSOPClassUID, "UI", evenUID(sopClassUID)).
+		element(TagMediaStorageSOPInstanceUID, "UI", evenUID(sopInstanceUID)).
+		element(TagTransferSyntaxUID, "UI", evenUID(syntax)).
+		bytes()
+
+	var out bytes.Buffer
+	out.Write(make([]byte, preambleLength))
+	out.WriteString("DICM")
+	length := newBuilder(ExplicitVRLittleEndian)
+	length.header(TagFileMetaGroupLength, "UL", 4)
+	binary.Write(&length.buf, binary.LittleEndian, uint32(len(meta)))
+	out.Write(length.bytes())
+	out.Write(meta)
+
+	if syntax == DeflatedExplicitVRLE {
+		w, err := flate.NewWriter(&out, flate.DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(dataSet)
+		w.Close()
+	} else {
+		out.Write(dataSet)
+	}
+	return out.Bytes()
+}
+
+// evenUID pads a UID with NUL the way the standard requires
+func evenUID(uid string) string {
+	if len(uid)%2 == 1 {
+		return uid + "\x00"
+	}
+	return uid
+}
+
+const (
+	ctImageStorage = "1.2.840.10008.5.1.4.1.1.2"
+	testStudyUID   = "1.2.826.0.1.3680043.2.1125.1.1"
+	testSeriesUID  = "1.2.826.0.1.3680043.2.1125.1.2"
+	testFrameUID   = "1.2.826.0.1.3680043.2.1125.1.3"
+)
+
+var testPixelData = []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+// testInstance encodes a CT image with patient data, a private tag, a
+// sequence referencing another instance and pixel data
+func testInstance(t *testing.T, syntax, sopInstanceUID, referencedUID string) []byte {
+	t.Helper()
+
+	b := newBuilder(syntax).
+		element(TagSOPClassUID, "UI", evenUID(ctImageStorage)).
+		element(TagSOPInstanceUID, "UI", evenUID(sopInstanceUID)).
+		element(TagStudyDate, "DA", "20240115").
+		element(TagModality, "CS", "CT").
+		element(Tag{0x0008, 0x0080}, "LO", "City Dental Clinic").
+		element(TagManufacturer, "LO", "Planmeca").
+		sequence(Tag{0x0008, 0x1140}, func(b *builder) {
+			b.element(Tag{0x0008, 0x1150}, "UI", evenUID(ctImageStorage)).
+				element(Tag{0x0008, 0x1155}, "UI", evenUID(referencedUID)).
+				element(Tag{0x0008, 0x0080}, "LO", "Nested Clinic ")
+		}).
+		element(Tag{0x0009, 0x0010}, "LO", "VENDOR").
+		element(Tag{0x0009, 0x1001}, "LO", "Ivanov I.I. ").
+		element(TagPatientName, "PN", "Ivanov^Ivan ").
+		element(TagPatientID, "LO", "MRN-000123").
+		element(TagPatientBirthDate, "DA", "19800101").
+		element(Tag{0x0010, 0x1040}, "LO", "Moscow, Lenina 1").
+		element(TagSliceThickness, "DS", "0.2 ").
+		element(TagStudyInstanceUID, "UI", evenUID(testStudyUID)).
+		element(TagSeriesInstanceUID, "UI", evenUID(testSeriesUID)).
+		element(Tag{0x0020, 0x0052}, "UI", evenUID(testFrameUID)).
+		uint16(TagRows, 512).
+		uint16(TagColumns, 512).
+		element(TagPixelSpacing, "DS", "0.2\\0.25").
+		header(TagPixelData, "OW", uint32(len(testPixelData))).
+		raw(testPixelData)
+
+	return part10(t, syntax, ctImageStorage, sopInstanceUID, b.bytes())
+}
+
+// testDirectory encodes a DICOMDIR index without images
+func testDirectory(t *testing.T) []byte {
+	t.Helper()
+	b := newBuilder(ExplicitVRLittleEndian).
+		element(Tag{0x0004, 0x1130}, "CS", "PATIENT ").
+		element(TagSOPClassUID, "UI", evenUID(mediaStorageDirectory))
+	return part10(t, ExplicitVRLittleEndian, mediaStorageDirectory, "1.2.3.4", b.bytes())
+}
+
+// zipOf builds an archive; entries are written in name order and names
+// ending in a slash become directories
+func zipOf(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(entries[name])
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
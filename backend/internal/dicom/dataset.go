@@ -0,0 +1,101 @@
+package dicom
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// Element is a single data element. Sequence elements carry their items
+// instead of a raw value.
+type Element struct {
+	Tag   Tag
+	VR    string
+	Value []byte
+	Items []*DataSet
+}
+
+// IsSequence reports whether the element holds sequence items
+func (e *Element) IsSequence() bool {
+	return e.Items != nil || e.VR == "SQ"
+}
+
+// DataSet is an ordered list of data elements
+type DataSet struct {
+	Elements []*Element
+	order    binary.ByteOrder
+}
+
+// Find returns the element with the given tag, or nil
+func (d *DataSet) Find(tag Tag) *Element {
+	if d == nil {
+		return nil
+	}
+	for _, e := range d.Elements {
+		if e.Tag == tag {
+			return e
+		}
+	}
+	return nil
+}
+
+// String returns the value of a text element with padding removed
+func (d *DataSet) String(tag Tag) string {
+	e := d.Find(tag)
+	if e == nil || e.IsSequence() {
+		return ""
+	}
+	return strings.TrimRight(string(e.Value), " \x00")
+}
+
+// Strings splits a multi-valued text element on the backslash delimiter
+func (d *DataSet) Strings(tag Tag) []string {
+	value := d.String(tag)
+	if value == "" {
+		return nil
+	}
+	parts := strings.Split(value, "\\")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// Float parses the first value of a decimal string (DS) element
+func (d *DataSet) Float(tag Tag) float64 {
+	values := d.Strings(tag)
+	if len(values) == 0 {
+		return 0
+	}
+	return parseFloat(values[0])
+}
+
+// Int parses an integer string (IS) or unsigned short (US) element
+func (d *DataSet) Int(tag Tag) int {
+	e := d.Find(tag)
+	if e == nil || e.IsSequence() {
+		return 0
+	}
+
+	if e.VR == "US" {
+		if len(e.Value) < 2 {
+			return 0
+		}
+		return int(d.byteOrder().Uint16(e.Value))
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSpace(d.String(tag)))
+	return n
+}
+
+func (d *DataSet) byteOrder() binary.ByteOrder {
+	if d.order == nil {
+		return binary.LittleEndian
+	}
+	return d.order
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
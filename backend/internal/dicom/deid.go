@@ -0,0 +1,333 @@
+package dicom
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Action is a de-identification action code from DICOM PS3.15 Annex E
+type Action string
+
+const (
+	ActionRemove  Action = "X" // remove the attribute
+	ActionEmpty   Action = "Z" // keep the attribute with an empty value
+	ActionDummy   Action = "D" // replace with a dummy value of the same VR
+	ActionReplace Action = "U" // replace a UID with a consistent new UID
+	ActionKeep    Action = "K" // keep the attribute unchanged
+)
+
+// Profile lists the action applied to each attribute. Attributes that are
+// not listed are kept.
+type Profile struct {
+	Name              string
+	RemovePrivateTags bool
+	Actions           map[Tag]Action
+}
+
+// profileFile is the JSON form of a profile, keyed by "gggg,eeee" tags
+type profileFile struct {
+	Name              string            `json:"name"`
+	RemovePrivateTags *bool             `json:"remove_private_tags"`
+	Actions           map[string]Action `json:"actions"`
+}
+
+// BasicProfile returns the attributes of the PS3.15 Basic Application
+// Level Confidentiality Profile that occur in dental CT studies
+func BasicProfile() *Profile {
+	return &Profile{
+		Name:              "DICOM PS3.15 Basic Profile",
+		RemovePrivateTags: true,
+		Actions: map[Tag]Action{
+			TagMediaStorageSOPInstanceUID: ActionReplace,
+
+			// Instance and study identification
+			{0x0008, 0x0014}:     ActionReplace, // Instance Creator UID
+			TagSOPInstanceUID:    ActionReplace,
+			{0x0008, 0x0020}:     ActionEmpty,   // Study Date
+			{0x0008, 0x0021}:     ActionRemove,  // Series Date
+			{0x0008, 0x0022}:     ActionRemove,  // Acquisition Date
+			{0x0008, 0x0023}:     ActionEmpty,   // Content Date
+			{0x0008, 0x002A}:     ActionRemove,  // Acquisition DateTime
+			{0x0008, 0x0030}:     ActionEmpty,   // Study Time
+			{0x0008, 0x0031}:     ActionRemove,  // Series Time
+			{0x0008, 0x0032}:     ActionRemove,  // Acquisition Time
+			{0x0008, 0x0033}:     ActionEmpty,   // Content Time
+			{0x0008, 0x0050}:     ActionEmpty,   // Accession Number
+			{0x0008, 0x0080}:     ActionRemove,  // Institution Name
+			{0x0008, 0x0081}:     ActionRemove,  // Institution Address
+			{0x0008, 0x0090}:     ActionEmpty,   // Referring Physician's Name
+			{0x0008, 0x0092}:     ActionRemove,  // Referring Physician's Address
+			{0x0008, 0x0094}:     ActionRemove,  // Referring Physician's Telephone Numbers
+			{0x0008, 0x1010}:     ActionRemove,  // Station Name
+			{0x0008, 0x1030}:     ActionRemove,  // Study Description
+			{0x0008, 0x103E}:     ActionRemove,  // Series Description
+			{0x0008, 0x1040}:     ActionRemove,  // Institutional Department Name
+			{0x0008, 0x1048}:     ActionRemove,  // Physician(s) of Record
+			{0x0008, 0x1050}:     ActionRemove,  // Performing Physician's Name
+			{0x0008, 0x1060}:     ActionRemove,  // Name of Physician(s) Reading Study
+			{0x0008, 0x1070}:     ActionRemove,  // Operators' Name
+			{0x0008, 0x1080}:     ActionRemove,  // Admitting Diagnoses Description
+			{0x0008, 0x1120}:     ActionRemove,  // Referenced Patient Sequence
+			{0x0008, 0x1155}:     ActionReplace, // Referenced SOP Instance UID
+			{0x0008, 0x2111}:     ActionRemove,  // Derivation Description
+			TagStudyInstanceUID:  ActionReplace,
+			TagSeriesInstanceUID: ActionReplace,
+			{0x0020, 0x0010}:     ActionEmpty,   // Study ID
+			{0x0020, 0x0052}:     ActionReplace, // Frame of Reference UID
+			{0x0020, 0x0200}:     ActionReplace, // Synchronization Frame of Reference UID
+			{0x0020, 0x4000}:     ActionRemove,  // Image Comments
+			{0x0040, 0xA124}:     ActionReplace, // UID
+			{0x0088, 0x0140}:     ActionReplace, // Storage Media File-set UID
+			{0x3006, 0x0024}:     ActionReplace, // Referenced Frame of Reference UID
+
+			// Patient
+			TagPatientName:      ActionEmpty,
+			TagPatientID:        ActionEmpty,
+			TagPatientBirthDate: ActionEmpty,
+			{0x0010, 0x0032}:    ActionRemove, // Patient's Birth Time
+			{0x0010, 0x0040}:    ActionEmpty,  // Patient's Sex
+			{0x0010, 0x1000}:    ActionRemove, // Other Patient IDs
+			{0x0010, 0x1001}:    ActionRemove, // Other Patient Names
+			{0x0010, 0x1002}:    ActionRemove, // Other Patient IDs Sequence
+			{0x0010, 0x1010}:    ActionRemove, // Patient's Age
+			{0x0010, 0x1020}:    ActionRemove, // Patient's Size
+			{0x0010, 0x1030}:    ActionRemove, // Patient's Weight
+			{0x0010, 0x1040}:    ActionRemove, // Patient's Address
+			{0x0010, 0x1060}:    ActionRemove, // Patient's Mother's Birth Name
+			{0x0010, 0x2154}:    ActionRemove, // Patient's Telephone Numbers
+			{0x0010, 0x2160}:    ActionRemove, // Ethnic Group
+			{0x0010, 0x2180}:    ActionRemove, // Occupation
+			{0x0010, 0x21B0}:    ActionRemove, // Additional Patient History
+			{0x0010, 0x4000}:    ActionRemove, // Patient Comments
+
+			// Equipment and procedure
+			{0x0018, 0x1000}: ActionRemove, // Device Serial Number
+			{0x0018, 0x1030}: ActionRemove, // Protocol Name
+			{0x0032, 0x1032}: ActionRemove, // Requesting Physician
+			{0x0032, 0x1060}: ActionRemove, // Requested Procedure Description
+			{0x0040, 0x0244}: ActionRemove, // Performed Procedure Step Start Date
+			{0x0040, 0x0245}: ActionRemove, // Performed Procedure Step Start Time
+			{0x0040, 0x0253}: ActionRemove, // Performed Procedure Step ID
+			{0x0040, 0x0254}: ActionRemove, // Performed Procedure Step Description
+			{0x0040, 0x0275}: ActionRemove, // Request Attributes Sequence
+		},
+	}
+}
+
+// LoadProfile reads a JSON profile and merges it over the basic profile.
+// Listing a tag with "K" keeps an attribute the basic profile would clean.
+//
+//	{"name": "...", "remove_private_tags": true, "actions": {"0010,0010": "Z"}}
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read de-identification profile: %w", err)
+	}
+
+	var file profileFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid de-identification profile: %w", err)
+	}
+
+	profile := BasicProfile()
+	if file.Name != "" {
+		profile.Name = file.Name
+	}
+	if file.RemovePrivateTags != nil {
+		profile.RemovePrivateTags = *file.RemovePrivateTags
+	}
+
+	for key, action := range file.Actions {
+		tag, err := parseTag(key)
+		if err != nil {
+			return nil, err
+		}
+		switch action {
+		case ActionRemove, ActionEmpty, ActionDummy, ActionReplace, ActionKeep:
+		default:
+			return nil, fmt.Errorf("invalid de-identification action %q for %s", action, key)
+		}
+		profile.Actions[tag] = action
+	}
+
+	return profile, nil
+}
+
+// parseTag parses "gggg,eeee" with optional parentheses
+func parseTag(s string) (Tag, error) {
+	parts := strings.Split(strings.Trim(s, "() "), ",")
+	if len(parts) != 2 {
+		return Tag{}, fmt.Errorf("invalid tag %q", s)
+	}
+	group, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 16, 16)
+	if err != nil {
+		return Tag{}, fmt.Errorf("invalid tag %q", s)
+	}
+	element, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 16, 16)
+	if err != nil {
+		return Tag{}, fmt.Errorf("invalid tag %q", s)
+	}
+	return Tag{Group: uint16(group), Element: uint16(element)}, nil
+}
+
+// Anonymizer applies a profile to the instances of one study. UIDs are
+// replaced consistently so that series and cross-references stay intact.
+type Anonymizer struct {
+	profile *Profile
+	salt    []byte
+	uids    map[string]string
+}
+
+func NewAnonymizer(profile *Profile) (*Anonymizer, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &Anonymizer{
+		profile: profile,
+		salt:    salt,
+		uids:    map[string]string{},
+	}, nil
+}
+
+// Apply de-identifies the file meta information and data set in place
+func (a *Anonymizer) Apply(f *File) {
+	if f.Meta != nil {
+		a.apply(f.Meta)
+	}
+	a.apply(f.DataSet)
+
+	f.DataSet.set(&Element{Tag: TagPatientIdentityRemoved, VR: "CS", Value: []byte("YES")})
+	f.DataSet.set(&Element{Tag: TagDeidentificationMethod, VR: "LO", Value: []byte(a.profile.Name)})
+
+	// The preamble is application defined and may carry identifying data
+	if f.Preamble != nil {
+		f.Preamble = make([]byte, preambleLength)
+	}
+}
+
+func (a *Anonymizer) apply(ds *DataSet) {
+	kept := ds.Elements[:0]
+	for _, e := range ds.Elements {
+		action, listed := a.profile.Actions[e.Tag]
+		if !listed && a.profile.RemovePrivateTags && e.Tag.Group%2 == 1 {
+			action = ActionRemove
+		}
+
+		switch action {
+		case ActionRemove:
+			continue
+		case ActionEmpty:
+			e.Value, e.Items = []byte{}, nil
+			if e.VR == "SQ" {
+				e.Items = []*DataSet{}
+			}
+		case ActionDummy:
+			if e.IsSequence() {
+				e.Items = []*DataSet{}
+			} else {
+				e.Value = dummyValue(e.VR)
+			}
+		case ActionReplace:
+			if !e.IsSequence() {
+				e.Value = []byte(a.replaceUIDs(string(e.Value)))
+			}
+		}
+
+		if e.IsSequence() {
+			for _, item := range e.Items {
+				a.apply(item)
+			}
+		}
+		kept = append(kept, e)
+	}
+	ds.Elements = kept
+}
+
+// replaceUIDs maps each UID of a multi-valued element to a new UID
+func (a *Anonymizer) replaceUIDs(value string) string {
+	uids := strings.Split(strings.TrimRight(value, " \x00"), "\\")
+	for i, uid := range uids {
+		uid = strings.TrimSpace(uid)
+		if uid == "" {
+			continue
+		}
+		uids[i] = a.uid(uid)
+	}
+	return strings.Join(uids, "\\")
+}
+
+// uid derives a UUID-based UID (PS3.5 B.2) from a keyed hash of the original
+func (a *Anonymizer) uid(original string) string {
+	if uid, ok := a.uids[original]; ok {
+		return uid
+	}
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write([]byte(original))
+	sum := mac.Sum(nil)[:16]
+	// Mark the value as a version 4 variant 1 UUID
+	sum[6] = sum[6]&0x0F | 0x40
+	sum[8] = sum[8]&0x3F | 0x80
+
+	uid := "2.25." + new(big.Int).SetBytes(sum).String()
+	a.uids[original] = uid
+	return uid
+}
+
+// dummyValue returns a neutral value of the given VR
+func dummyValue(vr string) []byte {
+	switch vr {
+	case "DA":
+		return []byte("19000101")
+	case "TM":
+		return []byte("000000")
+	case "DT":
+		return []byte("19000101000000")
+	case "PN":
+		return []byte("ANONYMOUS")
+	case "DS", "IS":
+		return []byte("0")
+	case "AS":
+		return []byte("000Y")
+	case "US", "SS":
+		return []byte{0, 0}
+	case "UL", "SL", "FL":
+		return []byte{0, 0, 0, 0}
+	case "FD":
+		return make([]byte, 8)
+	case "UI":
+		return []byte("2.25.0")
+	}
+	return []byte("ANONYMIZED")
+}
+
+// set replaces the element with the same tag or inserts it in tag order
+func (d *DataSet) set(e *Element) {
+	for i, existing := range d.Elements {
+		if existing.Tag == e.Tag {
+			d.Elements[i] = e
+			return
+		}
+	}
+	i := sort.Search(len(d.Elements), func(i int) bool {
+		return tagLess(e.Tag, d.Elements[i].Tag)
+	})
+	d.Elements = append(d.Elements, nil)
+	copy(d.Elements[i+1:], d.Elements[i:])
+	d.Elements[i] = e
+}
+
+func tagLess(a, b Tag) bool {
+	if a.Group != b.Group {
+		return a.Group < b.Group
+	}
+	return a.Element < b.Element
+}
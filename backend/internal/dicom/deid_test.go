@@ -0,0 +1,350 @@
+package dicom
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizerApplyBasicProfile(t *testing.T) {
+	file, err := ReadHeader(bytes.NewReader(testInstance(t, ExplicitVRLittleEndian, "1.2.3.100", "1.2.3.99")))
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	file.Preamble[0] = 'X'
+
+	anonymizer, err := NewAnonymizer(BasicProfile())
+	if err != nil {
+		t.Fatalf("NewAnonymizer: %v", err)
+	}
+	anonymizer.Apply(file)
+	ds := file.DataSet
+
+	removed := []Tag{
+		{0x0008, 0x0080}, // Institution Name
+		{0x0010, 0x1040}, // Patient's Address
+		{0x0009, 0x0010}, // private creator
+		{0x0009, 0x1001}, // private element
+	}
+	for _, tag := range removed {
+		if ds.Find(tag) != nil {
+			t.Errorf("%s was not removed", tag)
+		}
+	}
+
+	emptied := []Tag{TagPatientName, TagPatientID, TagPatientBirthDate, TagStudyDate}
+	for _, tag := range emptied {
+		if e := ds.Find(tag); e == nil || len(e.Value) != 0 {
+			t.Errorf("%s = %+v, want present and empty", tag, e)
+		}
+	}
+
+	kept := map[Tag]string{
+		TagModality:       "CT",
+		TagManufacturer:   "Planmeca",
+		TagSOPClassUID:    ctImageStorage,
+		TagSliceThickness: "0.2",
+	}
+	for tag, want := range kept {
+		if got := ds.String(tag); got != want {
+			t.Errorf("%s = %q, want %q", tag, got, want)
+		}
+	}
+
+	replaced := map[Tag]string{
+		TagSOPInstanceUID:    "1.2.3.100",
+		TagStudyInstanceUID:  testStudyUID,
+		TagSeriesInstanceUID: testSeriesUID,
+		{0x0020, 0x0052}:     testFrameUID,
+	}
+	for tag, original := range replaced {
+		got := ds.String(tag)
+		if got == original || !strings.HasPrefix(got, "2.25.") || len(got) > 64 {
+			t.Errorf("%s = %q, want a new 2.25 UID", tag, got)
+		}
+	}
+
+	if got := ds.String(TagPatientIdentityRemoved); got != "YES" {
+		t.Errorf("PatientIdentityRemoved = %q", got)
+	}
+	if got := ds.String(TagDeidentificationMethod); got != BasicProfile().Name {
+		t.Errorf("DeidentificationMethod = %q", got)
+	}
+	if !bytes.Equal(file.Preamble, make([]byte, preambleLength)) {
+		t.Error("preamble was not cleared")
+	}
+
+	// Actions apply inside sequence items too
+	item := ds.Find(Tag{0x0008, 0x1140}).Items[0]
+	if item.Find(Tag{0x0008, 0x0080}) != nil {
+		t.Error("Institution Name inside a sequence item was not removed")
+	}
+	if got := item.String(Tag{0x0008, 0x1150}); got != ctImageStorage {
+		t.Errorf("Referenced SOP Class UID = %q, want it kept", got)
+	}
+}
+
+func TestAnonymizerUIDsStayConsistent(t *testing.T) {
+	anonymizer, err := NewAnonymizer(BasicProfile())
+	if err != nil {
+		t.Fatalf("NewAnonymizer: %v", err)
+	}
+
+	// The second instance references the first
+	first := readAnonymized(t, anonymizer, testInstance(t, ImplicitVRLittleEndian, "1.2.3.100", "1.2.3.50"))
+	second := readAnonymized(t, anonymizer, testInstance(t, ExplicitVRLittleEndian, "1.2.3.101", "1.2.3.100"))
+
+	for _, f := range []*File{first, second} {
+		meta := f.Meta.String(TagMediaStorageSOPInstanceUID)
+		if meta != f.DataSet.String(TagSOPInstanceUID) {
+			t.Errorf("meta SOP Instance UID %q does not match the data set's %q", meta, f.DataSet.String(TagSOPInstanceUID))
+		}
+		if got := f.Meta.String(TagMediaStorageSOPClassUID); got != ctImageStorage {
+			t.Errorf("meta SOP Class UID = %q, want it kept", got)
+		}
+	}
+
+	if first.DataSet.String(TagSOPInstanceUID) == second.DataSet.String(TagSOPInstanceUID) {
+		t.Error("two instances were given the same SOP Instance UID")
+	}
+	for _, tag := range []Tag{TagStudyInstanceUID, TagSeriesInstanceUID, {0x0020, 0x0052}} {
+		if first.DataSet.String(tag) != second.DataSet.String(tag) {
+			t.Errorf("%s differs between instances of one study", tag)
+		}
+	}
+
+	referenced := second.DataSet.Find(Tag{0x0008, 0x1140}).Items[0].String(Tag{0x0008, 0x1155})
+	if referenced != first.DataSet.String(TagSOPInstanceUID) {
+		t.Errorf("Referenced SOP Instance UID = %q, want the first instance's new UID %q", referenced, first.DataSet.String(TagSOPInstanceUID))
+	}
+
+	// A new anonymizer uses a new salt, so studies cannot be linked
+	other, err := NewAnonymizer(BasicProfile())
+	if err != nil {
+		t.Fatalf("NewAnonymizer: %v", err)
+	}
+	third := readAnonymized(t, other, testInstance(t, ExplicitVRLittleEndian, "1.2.3.100", "1.2.3.50"))
+	if third.DataSet.String(TagStudyInstanceUID) == first.DataSet.String(TagStudyInstanceUID) {
+		t.Error("separate anonymizers produced the same Study Instance UID")
+	}
+}
+
+func TestAnonymizerReplacesMultiValuedUIDs(t *testing.T) {
+	anonymizer, err := NewAnonymizer(BasicProfile())
+	if err != nil {
+		t.Fatalf("NewAnonymizer: %v", err)
+	}
+
+	got := strings.Split(anonymizer.replaceUIDs("1.2.3\\1.2.4\\1.2.3\x00"), "\\")
+	if len(got) != 3 {
+		t.Fatalf("replaceUIDs returned %d values, want 3", len(got))
+	}
+	if got[0] != anonymizer.uid("1.2.3") || got[1] != anonymizer.uid("1.2.4") || got[2] != got[0] {
+		t.Errorf("replaceUIDs = %q", got)
+	}
+}
+
+func TestAnonymizerActions(t *testing.T) {
+	tests := []struct {
+		action Action
+		vr     string
+		want   string
+	}{
+		{ActionEmpty, "PN", ""},
+		{ActionDummy, "PN", "ANONYMOUS"},
+		{ActionDummy, "DA", "19000101"},
+		{ActionDummy, "LO", "ANONYMIZED"},
+		{ActionKeep, "PN", "Ivanov^Ivan"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.action)+"/"+tt.vr, func(t *testing.T) {
+			tag := Tag{0x0010, 0x0010}
+			profile := &Profile{Name: "test", Actions: map[Tag]Action{tag: tt.action}}
+			anonymizer, err := NewAnonymizer(profile)
+			if err != nil {
+				t.Fatalf("NewAnonymizer: %v", err)
+			}
+
+			file := &File{DataSet: &DataSet{Elements: []*Element{
+				{Tag: tag, VR: tt.vr, Value: []byte("Ivanov^Ivan")},
+				{Tag: Tag{0x0009, 0x1001}, VR: "LO", Value: []byte("private")},
+			}}}
+			anonymizer.Apply(file)
+
+			if got := file.DataSet.String(tag); got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+			// Private tags are only removed when the profile asks for it
+			if file.DataSet.Find(Tag{0x0009, 0x1001}) == nil {
+				t.Error("private tag removed although RemovePrivateTags is off")
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	profile, err := LoadProfile(write("clinic.json", `{
+		"name": "Clinic profile",
+		"remove_private_tags": false,
+		"actions": {"0010,0040": "K", "(0008,0070)": "X", "0010,0010": "D"}
+	}`))
+	if err != nil {
+		t.Fatalf("LoadProfile: %v", err)
+	}
+	if profile.Name != "Clinic profile" || profile.RemovePrivateTags {
+		t.Errorf("profile = %q, remove private %v", profile.Name, profile.RemovePrivateTags)
+	}
+	want := map[Tag]Action{
+		{0x0010, 0x0040}:    ActionKeep,
+		TagManufacturer:     ActionRemove,
+		TagPatientName:      ActionDummy,
+		TagStudyInstanceUID: ActionReplace, // inherited from the basic profile
+	}
+	for tag, action := range want {
+		if got := profile.Actions[tag]; got != action {
+			t.Errorf("action for %s = %q, want %q", tag, got, action)
+		}
+	}
+
+	invalid := map[string]string{
+		"bad action": `{"actions": {"0010,0010": "Q"}}`,
+		"bad tag":    `{"actions": {"0010": "X"}}`,
+		"bad hex":    `{"actions": {"00G0,0010": "X"}}`,
+		"bad json":   `{"actions": `,
+	}
+	for name, content := range invalid {
+		if _, err := LoadProfile(write(name+".json", content)); err == nil {
+			t.Errorf("LoadProfile accepted %s", name)
+		}
+	}
+	if _, err := LoadProfile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadProfile accepted a missing file")
+	}
+}
+
+func TestDeidentifyFile(t *testing.T) {
+	var out bytes.Buffer
+	input := testInstance(t, ExplicitVRBigEndian, "1.2.3.100", "1.2.3.99")
+	if err := Deidentify(bytes.NewReader(input), &out, BasicProfile()); err != nil {
+		t.Fatalf("Deidentify: %v", err)
+	}
+
+	file, err := ReadHeader(&out)
+	if err != nil {
+		t.Fatalf("ReadHeader of output: %v", err)
+	}
+	if file.TransferSyntaxUID != ExplicitVRBigEndian {
+		t.Errorf("TransferSyntaxUID = %q, want it kept", file.TransferSyntaxUID)
+	}
+	if got := file.DataSet.String(TagPatientName); got != "" {
+		t.Errorf("PatientName = %q", got)
+	}
+	rest, _ := io.ReadAll(file.Rest)
+	if !bytes.HasSuffix(rest, testPixelData) {
+		t.Error("pixel data was not copied through")
+	}
+}
+
+func TestDeidentifyRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"DICOMDIR", testDirectory(t), ReasonNoImageInstances},
+		{"not DICOM", []byte("just some text that is long enough"), ReasonNotDICOM},
+		{"archive without images", zipOf(t, map[string][]byte{
+			"DICOMDIR":   testDirectory(t),
+			"readme.txt": []byte("viewer instructions"),
+		}), ReasonNoImageInstances},
+		{"corrupt archive", append([]byte("PK\x03\x04"), make([]byte, 64)...), ReasonMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Deidentify(bytes.NewReader(tt.data), &out, BasicProfile())
+			dicomErr, ok := AsError(err)
+			if !ok || dicomErr.Reason != tt.reason {
+				t.Errorf("Deidentify error = %v, want reason %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestDeidentifyArchive(t *testing.T) {
+	input := zipOf(t, map[string][]byte{
+		"Ivanov Ivan/DICOMDIR":      testDirectory(t),
+		"Ivanov Ivan/readme.txt":    []byte("viewer instructions"),
+		"Ivanov Ivan/viewer.exe":    bytes.Repeat([]byte{0x4D, 0x5A}, 100),
+		"Ivanov Ivan/broken.dcm":    testInstance(t, ExplicitVRLittleEndian, "1.2.3.102", "1.2.3.99")[:200],
+		"Ivanov Ivan/CT/IM0001.dcm": testInstance(t, ExplicitVRLittleEndian, "1.2.3.100", "1.2.3.99"),
+		"Ivanov Ivan/CT/IM0002.dcm": testInstance(t, ImplicitVRLittleEndian, "1.2.3.101", "1.2.3.100"),
+		"__MACOSX/Ivanov Ivan/._x":  testInstance(t, ExplicitVRLittleEndian, "1.2.3.103", "1.2.3.99"),
+		"Ivanov Ivan/CT/":           nil,
+	})
+
+	var out bytes.Buffer
+	if err := Deidentify(bytes.NewReader(input), &out, BasicProfile()); err != nil {
+		t.Fatalf("Deidentify: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("output is not a ZIP archive: %v", err)
+	}
+
+	var names []string
+	var study string
+	for _, entry := range archive.File {
+		names = append(names, entry.Name)
+
+		f, err := entry.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", entry.Name, err)
+		}
+		file, err := ReadHeader(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("ReadHeader %s: %v", entry.Name, err)
+		}
+
+		if got := file.DataSet.String(TagPatientName); got != "" {
+			t.Errorf("%s: PatientName = %q", entry.Name, got)
+		}
+		uid := file.DataSet.String(TagStudyInstanceUID)
+		if uid == testStudyUID {
+			t.Errorf("%s: Study Instance UID was not replaced", entry.Name)
+		}
+		if study != "" && uid != study {
+			t.Errorf("%s: Study Instance UID %q differs from %q", entry.Name, uid, study)
+		}
+		study = uid
+	}
+
+	if want := "IMG00001.dcm,IMG00002.dcm"; strings.Join(names, ",") != want {
+		t.Errorf("archive entries = %v, want %s", names, want)
+	}
+}
+
+func readAnonymized(t *testing.T, anonymizer *Anonymizer, data []byte) *File {
+	t.Helper()
+	file, err := ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	anonymizer.Apply(file)
+	return file
+}
@@ -0,0 +1,36 @@
+package dicom
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Machine-readable reasons a file could not be read as DICOM
+const (
+	ReasonNotDICOM          = "not_dicom"
+	ReasonTruncated         = "truncated"
+	ReasonMalformed         = "malformed"
+	ReasonNoImageInstances  = "no_image_instances"
+	ReasonUnsupportedSyntax = "unsupported_transfer_syntax"
+)
+
+// Error describes why input is not a valid DICOM file
+type Error struct {
+	Reason string
+	Detail string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("dicom: %s: %s", e.Reason, e.Detail)
+}
+
+func newError(reason, format string, args ...interface{}) *Error {
+	return &Error{Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
+
+// AsError extracts a *Error from err, if any
+func AsError(err error) (*Error, bool) {
+	var dicomErr *Error
+	ok := errors.As(err, &dicomErr)
+	return dicomErr, ok
+}
@@ -0,0 +1,182 @@
+package dicom
+
+import (
+	"archive/zip"
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Summary holds the study attributes indexed for an uploaded scan. A scan
+// is either a single DICOM file or a ZIP archive of DICOM instances.
+type Summary struct {
+	StudyInstanceUID  string
+	StudyDate         *time.Time
+	Modality          string
+	Manufacturer      string
+	ManufacturerModel string
+	TransferSyntaxUID string
+
+	SliceThickness float64
+	VoxelSizeX     float64
+	VoxelSizeY     float64
+	VoxelSizeZ     float64
+	Rows           int
+	Columns        int
+
+	SeriesCount   int
+	InstanceCount int
+	FrameCount    int
+}
+
+// Inspect reads a DICOM file or ZIP archive of DICOM files and summarizes
+// the study. Non-DICOM input yields an *Error.
+func Inspect(r io.Reader) (*Summary, error) {
+	br := bufio.NewReader(r)
+
+	magic, _ := br.Peek(4)
+	if string(magic) == "PK\x03\x04" {
+		return inspectArchive(br)
+	}
+
+	file, err := ReadHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+	series := map[string]bool{}
+	summary.add(file, series)
+	if summary.InstanceCount == 0 {
+		return nil, newError(ReasonNoImageInstances, "file is a DICOMDIR index without images")
+	}
+	return summary, nil
+}
+
+// inspectArchive spools a ZIP archive to disk and summarizes every DICOM
+// instance inside it; entries that are not DICOM are skipped
+func inspectArchive(r io.Reader) (*Summary, error) {
+	archive, cleanup, err := spoolArchive(r)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	summary := &Summary{}
+	series := map[string]bool{}
+	for _, entry := range archive.File {
+		if skipArchiveEntry(entry) {
+			continue
+		}
+
+		f, err := entry.Open()
+		if err != nil {
+			return nil, newError(ReasonMalformed, "cannot open %s: %v", entry.Name, err)
+		}
+		file, err := ReadHeader(f)
+		f.Close()
+		if err != nil {
+			if _, ok := AsError(err); ok {
+				continue
+			}
+			return nil, err
+		}
+
+		summary.add(file, series)
+	}
+
+	if summary.InstanceCount == 0 {
+		return nil, newError(ReasonNoImageInstances, "archive contains no DICOM images")
+	}
+	return summary, nil
+}
+
+// spoolArchive copies a ZIP archive to a temporary file so its central
+// directory can be read; cleanup removes the file
+func spoolArchive(r io.Reader) (*zip.Reader, func(), error) {
+	tmp, err := os.CreateTemp("", "dicom-archive-*.zip")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	archive, err := zip.NewReader(tmp, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, newError(ReasonMalformed, "invalid zip archive: %v", err)
+	}
+	return archive, cleanup, nil
+}
+
+// skipArchiveEntry ignores directories and macOS resource forks
+func skipArchiveEntry(entry *zip.File) bool {
+	return entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/")
+}
+
+// isDirectory reports whether the file is a DICOMDIR index
+func isDirectory(file *File) bool {
+	return file.Meta.String(TagMediaStorageSOPClassUID) == mediaStorageDirectory ||
+		file.DataSet.String(TagSOPClassUID) == mediaStorageDirectory
+}
+
+// add merges one instance into the summary; study-level attributes are
+// taken from the first image instance
+func (s *Summary) add(file *File, series map[string]bool) {
+	if isDirectory(file) {
+		return
+	}
+
+	ds := file.DataSet
+	s.InstanceCount++
+
+	frames := ds.Int(TagNumberOfFrames)
+	if frames < 1 {
+		frames = 1
+	}
+	s.FrameCount += frames
+
+	seriesUID := ds.String(TagSeriesInstanceUID)
+	if !series[seriesUID] {
+		series[seriesUID] = true
+		s.SeriesCount++
+	}
+
+	if s.InstanceCount > 1 {
+		return
+	}
+
+	s.StudyInstanceUID = ds.String(TagStudyInstanceUID)
+	s.Modality = ds.String(TagModality)
+	s.Manufacturer = ds.String(TagManufacturer)
+	s.ManufacturerModel = ds.String(TagManufacturerModelName)
+	s.TransferSyntaxUID = file.TransferSyntaxUID
+	s.Rows = ds.Int(TagRows)
+	s.Columns = ds.Int(TagColumns)
+	s.SliceThickness = ds.Float(TagSliceThickness)
+
+	if studyDate, err := time.Parse("20060102", ds.String(TagStudyDate)); err == nil {
+		s.StudyDate = &studyDate
+	}
+
+	// Pixel Spacing is row spacing \ column spacing, i.e. Y then X
+	if spacing := ds.Strings(TagPixelSpacing); len(spacing) == 2 {
+		s.VoxelSizeY = ds.Float(TagPixelSpacing)
+		s.VoxelSizeX = parseFloat(spacing[1])
+	}
+
+	s.VoxelSizeZ = ds.Float(TagSpacingBetweenSlices)
+	if s.VoxelSizeZ == 0 {
+		s.VoxelSizeZ = s.SliceThickness
+	}
+}
@@ -0,0 +1,89 @@
+package dicom
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestInspectFile(t *testing.T) {
+	for _, syntax := range transferSyntaxes {
+		t.Run(syntax, func(t *testing.T) {
+			summary, err := Inspect(bytes.NewReader(testInstance(t, syntax, "1.2.3.100", "1.2.3.99")))
+			if err != nil {
+				t.Fatalf("Inspect: %v", err)
+			}
+
+			want := Summary{
+				StudyInstanceUID:  testStudyUID,
+				Modality:          "CT",
+				Manufacturer:      "Planmeca",
+				TransferSyntaxUID: syntax,
+				SliceThickness:    0.2,
+				VoxelSizeX:        0.25,
+				VoxelSizeY:        0.2,
+				VoxelSizeZ:        0.2,
+				Rows:              512,
+				Columns:           512,
+				SeriesCount:       1,
+				InstanceCount:     1,
+				FrameCount:        1,
+			}
+			got := *summary
+			got.StudyDate = nil
+			if got != want {
+				t.Errorf("Inspect =\n%+v\nwant\n%+v", got, want)
+			}
+			if summary.StudyDate == nil || !summary.StudyDate.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("StudyDate = %v", summary.StudyDate)
+			}
+		})
+	}
+}
+
+func TestInspectArchive(t *testing.T) {
+	input := zipOf(t, map[string][]byte{
+		"DICOMDIR":             testDirectory(t),
+		"README.TXT":           []byte("open with the bundled viewer"),
+		"broken.dcm":           []byte("DICM but not really"),
+		"CT/":                  nil,
+		"CT/IM0001":            testInstance(t, ExplicitVRLittleEndian, "1.2.3.100", "1.2.3.99"),
+		"CT/IM0002":            testInstance(t, ImplicitVRLittleEndian, "1.2.3.101", "1.2.3.100"),
+		"__MACOSX/CT/._IM0001": testInstance(t, ExplicitVRLittleEndian, "1.2.3.102", "1.2.3.99"),
+	})
+
+	summary, err := Inspect(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if summary.InstanceCount != 2 || summary.SeriesCount != 1 || summary.FrameCount != 2 {
+		t.Errorf("counts = %d instances, %d series, %d frames; want 2, 1, 2",
+			summary.InstanceCount, summary.SeriesCount, summary.FrameCount)
+	}
+	if summary.StudyInstanceUID != testStudyUID {
+		t.Errorf("StudyInstanceUID = %q", summary.StudyInstanceUID)
+	}
+}
+
+func TestInspectRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"DICOMDIR", testDirectory(t), ReasonNoImageInstances},
+		{"archive of a DICOMDIR", zipOf(t, map[string][]byte{"DICOMDIR": testDirectory(t)}), ReasonNoImageInstances},
+		{"archive of documents", zipOf(t, map[string][]byte{"report.pdf": []byte("%PDF-1.7")}), ReasonNoImageInstances},
+		{"corrupt archive", append([]byte("PK\x03\x04"), bytes.Repeat([]byte{0xFF}, 64)...), ReasonMalformed},
+		{"jpeg", append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, make([]byte, 200)...), ReasonNotDICOM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Inspect(bytes.NewReader(tt.data))
+			dicomErr, ok := AsError(err)
+			if !ok || dicomErr.Reason != tt.reason {
+				t.Errorf("Inspect error = %v, want reason %q", err, tt.reason)
+			}
+		})
+	}
+}
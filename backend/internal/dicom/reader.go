@@ -0,0 +1,351 @@
+package dicom
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	preambleLength  = 128
+	undefinedLength = 0xFFFFFFFF
+	// maxValueLength guards against allocating huge buffers for corrupt lengths
+	maxValueLength = 256 << 20
+)
+
+var validVRs = map[string]bool{
+	"AE": true, "AS": true, "AT": true, "CS": true, "DA": true, "DS": true, "DT": true,
+	"FL": true, "FD": true, "IS": true, "LO": true, "LT": true, "OB": true, "OD": true,
+	"OF": true, "OL": true, "OV": true, "OW": true, "PN": true, "SH": true, "SL": true,
+	"SQ": true, "SS": true, "ST": true, "SV": true, "TM": true, "UC": true, "UI": true,
+	"UL": true, "UN": true, "UR": true, "US": true, "UT": true, "UV": true,
+}
+
+// File is a parsed DICOM file header. Parsing stops at the pixel data
+// element, which is left unread in Rest.
+type File struct {
+	Preamble          []byte // nil when the input had no Part 10 preamble
+	Meta              *DataSet
+	DataSet           *DataSet
+	TransferSyntaxUID string
+
+	// Rest is positioned at the pixel data element (or trailing padding)
+	// and yields the remainder of the encoded data set
+	Rest io.Reader
+
+	order    binary.ByteOrder
+	explicit bool
+}
+
+// stream tracks the read position shared by nested decoders
+type stream struct {
+	r   *bufio.Reader
+	pos int64
+}
+
+// decoder reads elements in one transfer syntax
+type decoder struct {
+	*stream
+	order    binary.ByteOrder
+	explicit bool
+}
+
+// ReadHeader parses the file meta information and all data elements that
+// precede the pixel data
+func ReadHeader(r io.Reader) (*File, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	file := &File{}
+
+	head, _ := br.Peek(preambleLength + 4)
+	switch {
+	case len(head) == preambleLength+4 && string(head[preambleLength:]) == "DICM":
+		file.Preamble = append([]byte(nil), head[:preambleLength]...)
+		br.Discard(preambleLength + 4)
+
+		meta := &decoder{stream: &stream{r: br}, order: binary.LittleEndian, explicit: true}
+		ds, err := meta.readMeta()
+		if err != nil {
+			return nil, err
+		}
+		file.Meta = ds
+		file.TransferSyntaxUID = ds.String(TagTransferSyntaxUID)
+		if file.TransferSyntaxUID == "" {
+			return nil, newError(ReasonMalformed, "missing transfer syntax")
+		}
+
+	case looksLikeRawDataSet(head):
+		// Some modalities export bare data sets without the Part 10 header
+		file.TransferSyntaxUID = ImplicitVRLittleEndian
+		if validVRs[string(head[4:6])] {
+			file.TransferSyntaxUID = ExplicitVRLittleEndian
+		}
+
+	default:
+		return nil, newError(ReasonNotDICOM, "missing DICM prefix")
+	}
+
+	file.order = binary.LittleEndian
+	file.explicit = true
+	switch file.TransferSyntaxUID {
+	case ImplicitVRLittleEndian:
+		file.explicit = false
+	case ExplicitVRBigEndian:
+		file.order = binary.BigEndian
+	case DeflatedExplicitVRLE:
+		br = bufio.NewReaderSize(flate.NewReader(br), 64<<10)
+	}
+
+	dec := &decoder{stream: &stream{r: br}, order: file.order, explicit: file.explicit}
+	ds, err := dec.readTopLevel()
+	if err != nil {
+		return nil, err
+	}
+	file.DataSet = ds
+	file.Rest = br
+
+	return file, nil
+}
+
+// looksLikeRawDataSet checks for a data set starting with group 0x0008
+func looksLikeRawDataSet(head []byte) bool {
+	return len(head) >= 8 && head[0] == 0x08 && head[1] == 0x00
+}
+
+// readMeta reads group 0x0002 elements
+func (d *decoder) readMeta() (*DataSet, error) {
+	ds := &DataSet{order: d.order}
+	for {
+		tag, err := d.peekTag()
+		if err == io.EOF {
+			return nil, newError(ReasonTruncated, "no data set after file meta information")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tag.Group != 0x0002 {
+			return ds, nil
+		}
+
+		e, err := d.readElement()
+		if err != nil {
+			return nil, err
+		}
+		ds.Elements = append(ds.Elements, e)
+	}
+}
+
+// readTopLevel reads elements until pixel data, trailing padding or EOF
+func (d *decoder) readTopLevel() (*DataSet, error) {
+	ds := &DataSet{order: d.order}
+	for {
+		tag, err := d.peekTag()
+		if err == io.EOF {
+			return ds, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tag == TagPixelData || (tag.Group == 0xFFFC && tag.Element == 0xFFFC) {
+			return ds, nil
+		}
+
+		e, err := d.readElement()
+		if err != nil {
+			return nil, err
+		}
+		ds.Elements = append(ds.Elements, e)
+	}
+}
+
+// readItem reads the elements of a sequence item. A negative end means
+// the item is terminated by an item delimitation tag.
+func (d *decoder) readItem(end int64) (*DataSet, error) {
+	ds := &DataSet{order: d.order}
+	for {
+		if end >= 0 {
+			if d.pos == end {
+				return ds, nil
+			}
+			if d.pos > end {
+				return nil, newError(ReasonMalformed, "item overruns its length")
+			}
+		}
+
+		tag, err := d.peekTag()
+		if err == io.EOF {
+			return nil, newError(ReasonTruncated, "unterminated sequence item")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if end < 0 && tag == tagItemDelimitation {
+			if _, err := d.read(8); err != nil {
+				return nil, err
+			}
+			return ds, nil
+		}
+
+		e, err := d.readElement()
+		if err != nil {
+			return nil, err
+		}
+		ds.Elements = append(ds.Elements, e)
+	}
+}
+
+// readSequence reads the items of a sequence value
+func (d *decoder) readSequence(length uint32) ([]*DataSet, error) {
+	items := []*DataSet{}
+	end := d.pos + int64(length)
+
+	for {
+		if length != undefinedLength && d.pos >= end {
+			return items, nil
+		}
+
+		tag, err := d.readTag()
+		if err != nil {
+			return nil, err
+		}
+		itemLength, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		if tag == tagSequenceDelimiter {
+			return items, nil
+		}
+		if tag != tagItem {
+			return nil, newError(ReasonMalformed, "unexpected tag %s in sequence", tag)
+		}
+
+		itemEnd := int64(-1)
+		if itemLength != undefinedLength {
+			itemEnd = d.pos + int64(itemLength)
+		}
+		item, err := d.readItem(itemEnd)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// readElement reads one data element, descending into sequences
+func (d *decoder) readElement() (*Element, error) {
+	tag, err := d.readTag()
+	if err != nil {
+		return nil, err
+	}
+	if tag.Group == 0xFFFE {
+		return nil, newError(ReasonMalformed, "unexpected delimiter %s", tag)
+	}
+
+	var vr string
+	var length uint32
+	if d.explicit {
+		b, err := d.read(2)
+		if err != nil {
+			return nil, err
+		}
+		vr = string(b)
+		if !validVRs[vr] {
+			return nil, newError(ReasonMalformed, "invalid VR %q for %s", vr, tag)
+		}
+
+		if longVRs[vr] {
+			if _, err := d.read(2); err != nil {
+				return nil, err
+			}
+			length, err = d.readUint32()
+		} else {
+			var short uint16
+			short, err = d.readUint16()
+			length = uint32(short)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		vr = implicitVRs[tag]
+		if vr == "" {
+			vr = "UN"
+		}
+		if length, err = d.readUint32(); err != nil {
+			return nil, err
+		}
+	}
+
+	if vr == "SQ" || length == undefinedLength {
+		seq := d
+		if vr == "UN" && d.explicit {
+			// Sequences of unknown VR are always encoded implicit little endian
+			seq = &decoder{stream: d.stream, order: binary.LittleEndian, explicit: false}
+		}
+		items, err := seq.readSequence(length)
+		if err != nil {
+			return nil, err
+		}
+		return &Element{Tag: tag, VR: "SQ", Items: items}, nil
+	}
+
+	if length > maxValueLength {
+		return nil, newError(ReasonMalformed, "value length %d of %s is too large", length, tag)
+	}
+	value, err := d.read(int(length))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Element{Tag: tag, VR: vr, Value: value}, nil
+}
+
+func (d *decoder) peekTag() (Tag, error) {
+	b, err := d.r.Peek(4)
+	if len(b) == 0 && errors.Is(err, io.EOF) {
+		return Tag{}, io.EOF
+	}
+	if len(b) < 4 {
+		return Tag{}, newError(ReasonTruncated, "unexpected end of data")
+	}
+	return Tag{Group: d.order.Uint16(b[0:2]), Element: d.order.Uint16(b[2:4])}, nil
+}
+
+func (d *decoder) readTag() (Tag, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return Tag{}, err
+	}
+	return Tag{Group: d.order.Uint16(b[0:2]), Element: d.order.Uint16(b[2:4])}, nil
+}
+
+func (d *decoder) readUint16() (uint16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint16(b), nil
+}
+
+func (d *decoder) readUint32() (uint32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint32(b), nil
+}
+
+func (s *stream) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := io.ReadFull(s.r, buf)
+	s.pos += int64(read)
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, newError(ReasonTruncated, "unexpected end of data")
+		}
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,189 @@
+package dicom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+var transferSyntaxes = []string{
+	ImplicitVRLittleEndian,
+	ExplicitVRLittleEndian,
+	ExplicitVRBigEndian,
+	DeflatedExplicitVRLE,
+}
+
+func TestReadHeaderTransferSyntaxes(t *testing.T) {
+	for _, syntax := range transferSyntaxes {
+		t.Run(syntax, func(t *testing.T) {
+			file, err := ReadHeader(bytes.NewReader(testInstance(t, syntax, "1.2.3.100", "1.2.3.99")))
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+
+			if file.TransferSyntaxUID != syntax {
+				t.Errorf("TransferSyntaxUID = %q, want %q", file.TransferSyntaxUID, syntax)
+			}
+			if got := file.Meta.String(TagMediaStorageSOPInstanceUID); got != "1.2.3.100" {
+				t.Errorf("meta SOP Instance UID = %q", got)
+			}
+
+			ds := file.DataSet
+			if got := ds.String(TagPatientName); got != "Ivanov^Ivan" {
+				t.Errorf("PatientName = %q", got)
+			}
+			if got := ds.String(TagStudyInstanceUID); got != testStudyUID {
+				t.Errorf("StudyInstanceUID = %q", got)
+			}
+			if got := ds.Float(TagSliceThickness); got != 0.2 {
+				t.Errorf("SliceThickness = %v", got)
+			}
+			if got := ds.Strings(TagPixelSpacing); len(got) != 2 || got[1] != "0.25" {
+				t.Errorf("PixelSpacing = %q", got)
+			}
+			if ds.Find(TagPixelData) != nil {
+				t.Error("pixel data was parsed into the data set")
+			}
+
+			// Implicit VR files only know the VRs listed in implicitVRs
+			if got := ds.Int(TagRows); got != 512 {
+				t.Errorf("Rows = %d, want 512", got)
+			}
+
+			seq := ds.Find(Tag{0x0008, 0x1140})
+			if seq == nil || !seq.IsSequence() || len(seq.Items) != 1 {
+				t.Fatalf("Referenced Image Sequence = %+v", seq)
+			}
+			if got := seq.Items[0].String(Tag{0x0008, 0x1155}); got != "1.2.3.99" {
+				t.Errorf("Referenced SOP Instance UID = %q", got)
+			}
+
+			rest, err := io.ReadAll(file.Rest)
+			if err != nil {
+				t.Fatalf("read Rest: %v", err)
+			}
+			if !bytes.HasSuffix(rest, testPixelData) {
+				t.Errorf("Rest does not end with the pixel data: % x", rest)
+			}
+		})
+	}
+}
+
+func TestReadHeaderRawDataSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		syntax string
+	}{
+		{"explicit", ExplicitVRLittleEndian},
+		{"implicit", ImplicitVRLittleEndian},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newBuilder(tt.syntax).
+				element(TagSOPClassUID, "UI", evenUID(ctImageStorage)).
+				element(TagModality, "CS", "CT").
+				bytes()
+
+			file, err := ReadHeader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+			if file.Meta != nil || file.Preamble != nil {
+				t.Error("raw data set reported file meta information")
+			}
+			if file.TransferSyntaxUID != tt.syntax {
+				t.Errorf("TransferSyntaxUID = %q, want %q", file.TransferSyntaxUID, tt.syntax)
+			}
+			if got := file.DataSet.String(TagModality); got != "CT" {
+				t.Errorf("Modality = %q", got)
+			}
+		})
+	}
+}
+
+func TestReadHeaderErrors(t *testing.T) {
+	explicit := func(build func(*builder)) []byte {
+		b := newBuilder(ExplicitVRLittleEndian)
+		build(b)
+		return part10(t, ExplicitVRLittleEndian, ctImageStorage, "1.2.3", b.bytes())
+	}
+	le32 := func(v uint32) []byte {
+		return binary.LittleEndian.AppendUint32(nil, v)
+	}
+	valid := testInstance(t, ExplicitVRLittleEndian, "1.2.3.100", "1.2.3.99")
+
+	noSyntax := newBuilder(ExplicitVRLittleEndian).
+		element(TagMediaStorageSOPClassUID, "UI", evenUID(ctImageStorage)).
+		bytes()
+	missingSyntax := append(append(make([]byte, preambleLength), "DICM"...), noSyntax...)
+	missingSyntax = append(missingSyntax, newBuilder(ExplicitVRLittleEndian).element(TagModality, "CS", "CT").bytes()...)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"empty", nil, ReasonNotDICOM},
+		{"text", []byte("patient notes, not an image"), ReasonNotDICOM},
+		{"pdf", append([]byte("%PDF-1.7\n"), make([]byte, 200)...), ReasonNotDICOM},
+		{"preamble only", append(make([]byte, preambleLength), "DICM"...), ReasonTruncated},
+		{"missing transfer syntax", missingSyntax, ReasonMalformed},
+		{"invalid VR", explicit(func(b *builder) {
+			b.element(TagModality, "ZZ", "CT")
+		}), ReasonMalformed},
+		{"oversized value length", explicit(func(b *builder) {
+			b.header(TagModality, "UN", maxValueLength+2)
+		}), ReasonMalformed},
+		{"stray delimiter", explicit(func(b *builder) {
+			b.tag(tagItemDelimitation)
+			b.raw(le32(0))
+		}), ReasonMalformed},
+		{"non-item tag in sequence", explicit(func(b *builder) {
+			b.header(Tag{0x0008, 0x1140}, "SQ", undefinedLength)
+			b.element(TagModality, "CS", "CT")
+		}), ReasonMalformed},
+		{"item overruns its length", explicit(func(b *builder) {
+			b.header(Tag{0x0008, 0x1140}, "SQ", undefinedLength)
+			b.tag(tagItem)
+			b.raw(le32(4))
+			b.element(TagModality, "CS", "CT")
+		}), ReasonMalformed},
+		{"unterminated item", explicit(func(b *builder) {
+			b.header(Tag{0x0008, 0x1140}, "SQ", undefinedLength)
+			b.tag(tagItem)
+			b.raw(le32(undefinedLength))
+			b.element(TagModality, "CS", "CT")
+		}), ReasonTruncated},
+		{"truncated value", valid[:len(valid)-len(testPixelData)-200], ReasonTruncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadHeader(bytes.NewReader(tt.data))
+			dicomErr, ok := AsError(err)
+			if !ok {
+				t.Fatalf("ReadHeader error = %v, want a *dicom.Error", err)
+			}
+			if dicomErr.Reason != tt.reason {
+				t.Errorf("reason = %q (%v), want %q", dicomErr.Reason, dicomErr, tt.reason)
+			}
+		})
+	}
+}
+
+// Cutting a file at any offset must yield either a usable prefix or a
+// *dicom.Error, never a panic or an unclassified error
+func TestReadHeaderTruncatedInput(t *testing.T) {
+	for _, syntax := range transferSyntaxes {
+		data := testInstance(t, syntax, "1.2.3.100", "1.2.3.99")
+		for n := 0; n < len(data); n++ {
+			_, err := ReadHeader(bytes.NewReader(data[:n]))
+			if err == nil {
+				continue
+			}
+			if _, ok := AsError(err); !ok {
+				t.Errorf("%s cut at %d: error = %v, want a *dicom.Error", syntax, n, err)
+			}
+		}
+	}
+}
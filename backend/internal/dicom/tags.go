@@ -0,0 +1,112 @@
+package dicom
+
+import "fmt"
+
+// Tag identifies a DICOM data element by group and element number
+type Tag struct {
+	Group   uint16
+	Element uint16
+}
+
+func (t Tag) String() string {
+	return fmt.Sprintf("(%04X,%04X)", t.Group, t.Element)
+}
+
+// File meta information
+var (
+	TagFileMetaGroupLength        = Tag{0x0002, 0x0000}
+	TagMediaStorageSOPClassUID    = Tag{0x0002, 0x0002}
+	TagMediaStorageSOPInstanceUID = Tag{0x0002, 0x0003}
+	TagTransferSyntaxUID          = Tag{0x0002, 0x0010}
+)
+
+// Study, series and equipment attributes
+var (
+	TagSOPClassUID           = Tag{0x0008, 0x0016}
+	TagStudyDate             = Tag{0x0008, 0x0020}
+	TagModality              = Tag{0x0008, 0x0060}
+	TagManufacturer          = Tag{0x0008, 0x0070}
+	TagManufacturerModelName = Tag{0x0008, 0x1090}
+	TagSliceThickness        = Tag{0x0018, 0x0050}
+	TagSpacingBetweenSlices  = Tag{0x0018, 0x0088}
+	TagStudyInstanceUID      = Tag{0x0020, 0x000D}
+	TagSeriesInstanceUID     = Tag{0x0020, 0x000E}
+	TagNumberOfFrames        = Tag{0x0028, 0x0008}
+	TagRows                  = Tag{0x0028, 0x0010}
+	TagColumns               = Tag{0x0028, 0x0011}
+	TagPixelSpacing          = Tag{0x0028, 0x0030}
+	TagPixelData             = Tag{0x7FE0, 0x0010}
+)
+
+// Patient identification and de-identification attributes
+var (
+	TagSOPInstanceUID         = Tag{0x0008, 0x0018}
+	TagPatientName            = Tag{0x0010, 0x0010}
+	TagPatientID              = Tag{0x0010, 0x0020}
+	TagPatientBirthDate       = Tag{0x0010, 0x0030}
+	TagPatientIdentityRemoved = Tag{0x0012, 0x0062}
+	TagDeidentificationMethod = Tag{0x0012, 0x0063}
+)
+
+// Sequence item delimitation
+var (
+	tagItem              = Tag{0xFFFE, 0xE000}
+	tagItemDelimitation  = Tag{0xFFFE, 0xE00D}
+	tagSequenceDelimiter = Tag{0xFFFE, 0xE0DD}
+)
+
+// Transfer syntaxes that change how the data set is encoded
+const (
+	ImplicitVRLittleEndian = "1.2.840.10008.1.2"
+	ExplicitVRLittleEndian = "1.2.840.10008.1.2.1"
+	DeflatedExplicitVRLE   = "1.2.840.10008.1.2.1.99"
+	ExplicitVRBigEndian    = "1.2.840.10008.1.2.2"
+)
+
+// mediaStorageDirectory is the SOP class of DICOMDIR index files
+const mediaStorageDirectory = "1.2.840.10008.1.3.10"
+
+// implicitVRs maps tags to their VR for implicit VR transfer syntaxes.
+// Only attributes this package interprets and sequences that may hold
+// identifying attributes need to be listed; other elements are carried
+// through as UN.
+var implicitVRs = map[Tag]string{
+	TagMediaStorageSOPInstanceUID: "UI",
+	TagSOPClassUID:                "UI",
+	TagSOPInstanceUID:             "UI",
+	TagPatientName:                "PN",
+	TagPatientID:                  "LO",
+	TagPatientBirthDate:           "DA",
+	TagPatientIdentityRemoved:     "CS",
+	TagDeidentificationMethod:     "LO",
+	TagStudyDate:                  "DA",
+	TagModality:                   "CS",
+	TagManufacturer:               "LO",
+	TagManufacturerModelName:      "LO",
+	TagSliceThickness:             "DS",
+	TagSpacingBetweenSlices:       "DS",
+	TagStudyInstanceUID:           "UI",
+	TagSeriesInstanceUID:          "UI",
+	TagNumberOfFrames:             "IS",
+	TagRows:                       "US",
+	TagColumns:                    "US",
+	TagPixelSpacing:               "DS",
+
+	{0x0008, 0x1032}: "SQ", // Procedure Code Sequence
+	{0x0008, 0x1110}: "SQ", // Referenced Study Sequence
+	{0x0008, 0x1111}: "SQ", // Referenced Performed Procedure Step Sequence
+	{0x0008, 0x1115}: "SQ", // Referenced Series Sequence
+	{0x0008, 0x1120}: "SQ", // Referenced Patient Sequence
+	{0x0008, 0x1140}: "SQ", // Referenced Image Sequence
+	{0x0008, 0x2112}: "SQ", // Source Image Sequence
+	{0x0010, 0x1002}: "SQ", // Other Patient IDs Sequence
+	{0x0040, 0x0260}: "SQ", // Performed Protocol Code Sequence
+	{0x0040, 0x0275}: "SQ", // Request Attributes Sequence
+	{0x0040, 0xA730}: "SQ", // Content Sequence
+}
+
+// longVRs use a 4-byte value length in explicit VR encoding
+var longVRs = map[string]bool{
+	"OB": true, "OD": true, "OF": true, "OL": true, "OV": true, "OW": true,
+	"SQ": true, "SV": true, "UC": true, "UN": true, "UR": true, "UT": true, "UV": true,
+}
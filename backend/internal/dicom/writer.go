@@ -0,0 +1,190 @@
+package dicom
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+)
+
+// Write encodes a file read by ReadHeader: the preamble, file meta
+// information and data set are re-encoded and the unread remainder in
+// Rest (pixel data and anything after it) is copied through unchanged.
+// Sequences are always written with undefined length so that edited
+// items never need their lengths recomputed.
+func Write(w io.Writer, f *File) error {
+	bw := bufio.NewWriterSize(w, 64<<10)
+
+	if f.Meta != nil {
+		preamble := f.Preamble
+		if len(preamble) != preambleLength {
+			preamble = make([]byte, preambleLength)
+		}
+		bw.Write(preamble)
+		bw.WriteString("DICM")
+
+		if err := writeMeta(bw, f.Meta); err != nil {
+			return err
+		}
+	}
+
+	var body io.Writer = bw
+	var deflater *flate.Writer
+	if f.TransferSyntaxUID == DeflatedExplicitVRLE {
+		var err error
+		if deflater, err = flate.NewWriter(bw, flate.DefaultCompression); err != nil {
+			return err
+		}
+		body = deflater
+	}
+
+	enc := &encoder{w: body, order: f.order, explicit: f.explicit}
+	if enc.order == nil {
+		enc.order = binary.LittleEndian
+	}
+	for _, e := range f.DataSet.Elements {
+		// Group lengths are retired outside the meta group and would be stale
+		if e.Tag.Element == 0x0000 {
+			continue
+		}
+		if err := enc.writeElement(e); err != nil {
+			return err
+		}
+	}
+
+	if f.Rest != nil {
+		if _, err := io.Copy(body, f.Rest); err != nil {
+			return err
+		}
+	}
+
+	if deflater != nil {
+		if err := deflater.Close(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeMeta encodes group 0x0002 in explicit VR little endian with a
+// recomputed group length
+func writeMeta(w io.Writer, meta *DataSet) error {
+	var buf bytes.Buffer
+	enc := &encoder{w: &buf, order: binary.LittleEndian, explicit: true}
+	for _, e := range meta.Elements {
+		if e.Tag == TagFileMetaGroupLength {
+			continue
+		}
+		if err := enc.writeElement(e); err != nil {
+			return err
+		}
+	}
+
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(buf.Len()))
+	header := &encoder{w: w, order: binary.LittleEndian, explicit: true}
+	if err := header.writeElement(&Element{Tag: TagFileMetaGroupLength, VR: "UL", Value: length}); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encoder writes elements in one transfer syntax
+type encoder struct {
+	w        io.Writer
+	order    binary.ByteOrder
+	explicit bool
+	scratch  [12]byte
+}
+
+func (e *encoder) writeElement(el *Element) error {
+	if el.IsSequence() {
+		return e.writeSequence(el)
+	}
+
+	value := padValue(el.VR, el.Value)
+	if !e.explicit {
+		return e.writeHeader(el.Tag, "", uint32(len(value)), value)
+	}
+
+	vr := el.VR
+	if vr == "" || (!longVRs[vr] && len(value) > 0xFFFF) {
+		// Values too long for a short VR can only be carried as UN
+		vr = "UN"
+	}
+	return e.writeHeader(el.Tag, vr, uint32(len(value)), value)
+}
+
+func (e *encoder) writeSequence(el *Element) error {
+	if err := e.writeHeader(el.Tag, "SQ", undefinedLength, nil); err != nil {
+		return err
+	}
+	for _, item := range el.Items {
+		if err := e.writeTag(tagItem, undefinedLength); err != nil {
+			return err
+		}
+		for _, child := range item.Elements {
+			if err := e.writeElement(child); err != nil {
+				return err
+			}
+		}
+		if err := e.writeTag(tagItemDelimitation, 0); err != nil {
+			return err
+		}
+	}
+	return e.writeTag(tagSequenceDelimiter, 0)
+}
+
+// writeHeader writes the tag, VR (explicit syntaxes only), length and value
+func (e *encoder) writeHeader(tag Tag, vr string, length uint32, value []byte) error {
+	b := e.scratch[:4]
+	e.order.PutUint16(b[0:2], tag.Group)
+	e.order.PutUint16(b[2:4], tag.Element)
+
+	switch {
+	case !e.explicit:
+		b = b[:8]
+		e.order.PutUint32(b[4:8], length)
+	case longVRs[vr]:
+		b = b[:12]
+		copy(b[4:6], vr)
+		b[6], b[7] = 0, 0
+		e.order.PutUint32(b[8:12], length)
+	default:
+		b = b[:8]
+		copy(b[4:6], vr)
+		e.order.PutUint16(b[6:8], uint16(length))
+	}
+
+	if _, err := e.w.Write(b); err != nil {
+		return err
+	}
+	_, err := e.w.Write(value)
+	return err
+}
+
+// writeTag writes an item or delimitation tag, which never carries a VR
+func (e *encoder) writeTag(tag Tag, length uint32) error {
+	b := e.scratch[:8]
+	e.order.PutUint16(b[0:2], tag.Group)
+	e.order.PutUint16(b[2:4], tag.Element)
+	e.order.PutUint32(b[4:8], length)
+	_, err := e.w.Write(b)
+	return err
+}
+
+// padValue pads values to the even length the standard requires: UIDs and
+// binary values with NUL, text with a space
+func padValue(vr string, value []byte) []byte {
+	if len(value)%2 == 0 {
+		return value
+	}
+	pad := byte(' ')
+	switch vr {
+	case "UI", "OB", "UN", "":
+		pad = 0x00
+	}
+	return append(value[:len(value):len(value)], pad)
+}
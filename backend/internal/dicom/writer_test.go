@@ -0,0 +1,199 @@
+package dicom
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	for _, syntax := range transferSyntaxes {
+		t.Run(syntax, func(t *testing.T) {
+			data := testInstance(t, syntax, "1.2.3.100", "1.2.3.99")
+			file, err := ReadHeader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+
+			var out bytes.Buffer
+			if err := Write(&out, file); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			// The fixture is already in the canonical form Write produces
+			if syntax != DeflatedExplicitVRLE && !bytes.Equal(out.Bytes(), data) {
+				t.Fatalf("Write output differs from input\n got % x\nwant % x", out.Bytes(), data)
+			}
+
+			again, err := ReadHeader(&out)
+			if err != nil {
+				t.Fatalf("ReadHeader of written file: %v", err)
+			}
+			assertSameDataSet(t, again.DataSet, readDataSet(t, data))
+			rest, _ := io.ReadAll(again.Rest)
+			if !bytes.HasSuffix(rest, testPixelData) {
+				t.Errorf("pixel data not copied through: % x", rest)
+			}
+		})
+	}
+}
+
+func TestWriteRecomputesMetaGroupLength(t *testing.T) {
+	file, err := ReadHeader(bytes.NewReader(testInstance(t, ExplicitVRLittleEndian, "1.2.3.100", "1.2.3.99")))
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	file.Meta.set(&Element{Tag: TagMediaStorageSOPInstanceUID, VR: "UI", Value: []byte("2.25.123456789012345678901234567890")})
+
+	var out bytes.Buffer
+	if err := Write(&out, file); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	again, err := ReadHeader(&out)
+	if err != nil {
+		t.Fatalf("ReadHeader of written file: %v", err)
+	}
+
+	length := again.Meta.Find(TagFileMetaGroupLength)
+	encoded := 0
+	for _, e := range again.Meta.Elements[1:] {
+		encoded += 8 + len(e.Value)
+	}
+	if got := int(again.Meta.byteOrder().Uint32(length.Value)); got != encoded {
+		t.Errorf("group length = %d, want %d", got, encoded)
+	}
+	if again.DataSet.String(TagModality) != "CT" {
+		t.Error("data set was not found after the rewritten meta group")
+	}
+}
+
+func TestWriteDropsDataSetGroupLengths(t *testing.T) {
+	data := newBuilder(ExplicitVRLittleEndian).
+		element(Tag{0x0008, 0x0000}, "UL", "\x10\x00\x00\x00").
+		element(TagModality, "CS", "CT").
+		bytes()
+	file, err := ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, file); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	want := newBuilder(ExplicitVRLittleEndian).element(TagModality, "CS", "CT").bytes()
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("Write = % x, want % x", out.Bytes(), want)
+	}
+}
+
+func TestWritePadsOddValues(t *testing.T) {
+	file := &File{
+		TransferSyntaxUID: ExplicitVRLittleEndian,
+		explicit:          true,
+		DataSet: &DataSet{Elements: []*Element{
+			{Tag: TagSOPInstanceUID, VR: "UI", Value: []byte("1.2.3")},
+			{Tag: TagModality, VR: "CS", Value: []byte("CT")},
+			{Tag: TagPatientName, VR: "PN", Value: []byte("Doe^J")},
+		}},
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, file); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	want := newBuilder(ExplicitVRLittleEndian).
+		element(TagSOPInstanceUID, "UI", "1.2.3\x00").
+		element(TagModality, "CS", "CT").
+		element(TagPatientName, "PN", "Doe^J ").
+		bytes()
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("Write = % x, want % x", out.Bytes(), want)
+	}
+}
+
+func TestWriteLongValueFallsBackToUN(t *testing.T) {
+	long := strings.Repeat("A", 0x10000)
+	file := &File{
+		TransferSyntaxUID: ExplicitVRLittleEndian,
+		explicit:          true,
+		DataSet: &DataSet{Elements: []*Element{
+			{Tag: Tag{0x0008, 0x1030}, VR: "LO", Value: []byte(long)},
+		}},
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, file); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	again, err := ReadHeader(&out)
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	e := again.DataSet.Find(Tag{0x0008, 0x1030})
+	if e == nil || e.VR != "UN" || len(e.Value) != len(long) {
+		t.Errorf("element = VR %q, %d bytes; want UN with %d bytes", e.VR, len(e.Value), len(long))
+	}
+}
+
+func TestPadValue(t *testing.T) {
+	tests := []struct {
+		vr    string
+		value string
+		want  string
+	}{
+		{"UI", "1.2.3", "1.2.3\x00"},
+		{"UI", "1.2.34", "1.2.34"},
+		{"OB", "\x01", "\x01\x00"},
+		{"UN", "\x01\x02\x03", "\x01\x02\x03\x00"},
+		{"", "abc", "abc\x00"},
+		{"PN", "Doe^J", "Doe^J "},
+		{"LO", "abc", "abc "},
+		{"CS", "YES", "YES "},
+		{"DA", "", ""},
+	}
+	for _, tt := range tests {
+		value := []byte(tt.value)
+		got := padValue(tt.vr, value)
+		if string(got) != tt.want {
+			t.Errorf("padValue(%q, %q) = %q, want %q", tt.vr, tt.value, got, tt.want)
+		}
+		if string(value) != tt.value {
+			t.Errorf("padValue(%q, %q) modified its input", tt.vr, tt.value)
+		}
+	}
+
+	// Padding must not write into spare capacity shared with the caller
+	backing := []byte("1.2.3XYZ")
+	padValue("UI", backing[:5])
+	if string(backing) != "1.2.3XYZ" {
+		t.Errorf("padValue overwrote the backing array: %q", backing)
+	}
+}
+
+func readDataSet(t *testing.T, data []byte) *DataSet {
+	t.Helper()
+	file, err := ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadHeader: %v", err)
+	}
+	return file.DataSet
+}
+
+func assertSameDataSet(t *testing.T, got, want *DataSet) {
+	t.Helper()
+	if len(got.Elements) != len(want.Elements) {
+		t.Fatalf("data set has %d elements, want %d", len(got.Elements), len(want.Elements))
+	}
+	for i, w := range want.Elements {
+		g := got.Elements[i]
+		if g.Tag != w.Tag || g.VR != w.VR || !bytes.Equal(g.Value, w.Value) || len(g.Items) != len(w.Items) {
+			t.Errorf("element %d = %s %s %q, want %s %s %q", i, g.Tag, g.VR, g.Value, w.Tag, w.VR, w.Value)
+			continue
+		}
+		for j := range w.Items {
+			assertSameDataSet(t, g.Items[j], w.Items[j])
+		}
+	}
+}
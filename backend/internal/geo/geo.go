@@ -0,0 +1,37 @@
+package geo
+
+import "math"
+
+// earthRadiusKm is the mean Earth radius
+const earthRadiusKm = 6371.0
+
+// Point is a WGS 84 coordinate in degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// NewPoint returns the point for nullable coordinates, or false if either
+// is missing
+func NewPoint(lat, lng *float64) (Point, bool) {
+	if lat == nil || lng == nil {
+		return Point{}, false
+	}
+	return Point{Lat: *lat, Lng: *lng}, true
+}
+
+// DistanceKm returns the haversine distance between two points in kilometres
+func DistanceKm(a, b Point) float64 {
+	lat1 := radians(a.Lat)
+	lat2 := radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/attachments"
+	"dental-marketplace/backend/internal/authz"
+	"dental-marketplace/backend/internal/config"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentHandler lets patients attach photos, invoices and X-rays to their
+// complaints and reviews. Files are downloaded through short-lived signed
+// URLs, issued only to the patient, the clinic concerned and regulators.
+type AttachmentHandler struct {
+	repo          *repository.Repository
+	store         storage.Storage
+	uploader      *attachments.Uploader
+	storageCfg    config.StorageConfig
+	attachmentCfg config.AttachmentConfig
+}
+
+func NewAttachmentHandler(repo *repository.Repository, store storage.Storage, uploader *attachments.Uploader, storageCfg config.StorageConfig, attachmentCfg config.AttachmentConfig) *AttachmentHandler {
+	return &AttachmentHandler{
+		repo:          repo,
+		store:         store,
+		uploader:      uploader,
+		storageCfg:    storageCfg,
+		attachmentCfg: attachmentCfg,
+	}
+}
+
+// UploadComplaintAttachment attaches a file to a complaint
+// @Summary Attach file to complaint
+// @Description Attach a photo (JPEG, PNG, WebP, HEIC), PDF or DICOM file to one of the patient's open
+// @Description complaints. The type is detected from the content and every file is virus-scanned.
+// @Tags patient
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Complaint ID"
+// @Param file formData file true "File"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /api/patient/complaints/{id}/attachments [post]
+func (h *AttachmentHandler) UploadComplaintAttachment(c *gin.Context) {
+	patient, complaintID, ok := h.patientParent(c, "Invalid complaint ID")
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.storeUpload(c, fmt.Sprintf("attachments/complaints/%d", complaintID))
+	if !ok {
+		return
+	}
+
+	err := h.repo.CreateComplaintAttachment(patient.ID, complaintID, attachment, h.attachmentCfg.MaxPerItem, requestActor(c))
+	if err != nil {
+		h.discard(c, attachment)
+		attachmentFailed(c, err, "Complaint not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// UploadReviewAttachment attaches a file to a review
+// @Summary Attach file to review
+// @Description Attach a photo (JPEG, PNG, WebP, HEIC), PDF or DICOM file to one of the patient's reviews.
+// @Description Review attachments are shown to the reviewed clinic and regulators, not published.
+// @Tags patient
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param file formData file true "File"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /api/patient/reviews/{id}/attachments [post]
+func (h *AttachmentHandler) UploadReviewAttachment(c *gin.Context) {
+	patient, reviewID, ok := h.patientParent(c, "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.storeUpload(c, fmt.Sprintf("attachments/reviews/%d", reviewID))
+	if !ok {
+		return
+	}
+
+	if err := h.repo.CreateReviewAttachment(patient.ID, reviewID, attachment, h.attachmentCfg.MaxPerItem); err != nil {
+		h.discard(c, attachment)
+		attachmentFailed(c, err, "Review not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// Download returns a short-lived signed URL for an attachment
+// @Summary Get attachment download URL
+// @Description Get a short-lived signed download URL for an attachment. Patients can download their own
+// @Description attachments, clinics those on complaints sent to them and on their reviews, regulators all.
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attachment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/attachments/{id}/download [get]
+// @Router /api/clinic/attachments/{id}/download [get]
+// @Router /api/regulator/attachments/{id}/download [get]
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+
+	attachmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid attachment ID",
+		})
+		return
+	}
+
+	attachment, err := h.repo.GetAttachment(uint(attachmentID))
+	if err == nil && !h.canView(userID.(uint), role, attachment) {
+		err = repository.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Attachment not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve attachment",
+		})
+		return
+	}
+
+	url, err := h.store.SignedURL(c.Request.Context(), attachment.StorageKey, h.storageCfg.SignedURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate download URL",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":          url,
+		"file_name":    attachment.FileName,
+		"content_type": attachment.ContentType,
+		"expires_at":   time.Now().Add(h.storageCfg.SignedURLTTL),
+	})
+}
+
+// canView applies the attachment policy for the caller's role
+func (h *AttachmentHandler) canView(userID uint, role any, attachment *models.Attachment) bool {
+	switch role {
+	case models.RolePatient:
+		patient, err := h.repo.GetPatientByUserID(userID)
+		return err == nil && authz.PatientCanViewAttachment(patient, attachment)
+	case models.RoleClinic:
+		clinic, err := h.repo.GetClinicByUserID(userID)
+		return err == nil && authz.ClinicCanViewAttachment(clinic, attachment)
+	case models.RoleRegulator:
+		return true
+	default:
+		return false
+	}
+}
+
+// patientParent loads the calling patient and parses the id path parameter.
+// It writes the error response when it fails.
+func (h *AttachmentHandler) patientParent(c *gin.Context, invalidID string) (*models.Patient, uint, bool) {
+	userID, _ := c.Get("userID")
+
+	parentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": invalidID,
+		})
+		return nil, 0, false
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return nil, 0, false
+	}
+
+	return patient, uint(parentID), true
+}
+
+// storeUpload validates, scans and stores the uploaded file. It writes the
+// error response when it fails.
+func (h *AttachmentHandler) storeUpload(c *gin.Context, prefix string) (*models.Attachment, bool) {
+	userID, _ := c.Get("userID")
+
+	// Leave room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.uploader.MaxSize()+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "File is too large",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "File is required",
+		})
+		return nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read uploaded file",
+		})
+		return nil, false
+	}
+	defer file.Close()
+
+	attachment, err := h.uploader.Store(c.Request.Context(), prefix, fileHeader.Filename, file, fileHeader.Size)
+	switch {
+	case err == nil:
+		attachment.UploadedByUserID = userID.(uint)
+		return attachment, true
+	case errors.Is(err, attachments.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "File is too large",
+		})
+	case errors.Is(err, attachments.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Only JPEG, PNG, WebP, HEIC, PDF and DICOM files can be attached",
+		})
+	case errors.Is(err, attachments.ErrInfected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "File was rejected by the virus scan",
+		})
+	default:
+		log.Printf("failed to store attachment under %s: %v", prefix, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store attachment",
+		})
+	}
+	return nil, false
+}
+
+// discard removes a stored file whose attachment could not be saved
+func (h *AttachmentHandler) discard(c *gin.Context, attachment *models.Attachment) {
+	if err := h.store.Delete(c.Request.Context(), attachment.StorageKey); err != nil {
+		log.Printf("failed to delete orphaned attachment %s: %v", attachment.StorageKey, err)
+	}
+}
+
+// attachmentFailed maps a failed attachment operation to a response
+func attachmentFailed(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": notFound,
+		})
+	case errors.Is(err, repository.ErrComplaintNotActive),
+		errors.Is(err, repository.ErrTooManyAttachments):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save attachment",
+		})
+	}
+}
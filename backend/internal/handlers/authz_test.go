@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"dental-marketplace/backend/internal/config"
+	"dental-marketplace/backend/internal/jobs"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/pricing"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"dental-marketplace/backend/internal/testdb"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// testRouter mounts the routes under test at the same paths as the server.
+// Requests authenticate as the user named by the X-Test-User and X-Test-Role
+// headers instead of a JWT.
+func testRouter(t *testing.T, db *gorm.DB) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/files/", "test-secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	repo := repository.NewRepository(db)
+	patientHandler := NewPatientHandler(repo, store, nil, config.StorageConfig{}, config.ReviewConfig{}, config.ComplaintConfig{})
+	clinicHandler := NewClinicHandler(repo, store, jobs.NewEstimateRefresher(pricing.NewEstimator(repo)), config.StorageConfig{}, config.OfferConfig{Validity: 24 * time.Hour})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseUint(c.GetHeader("X-Test-User"), 10, 32)
+		c.Set("userID", uint(userID))
+		c.Set("role", c.GetHeader("X-Test-Role"))
+	})
+
+	patient := router.Group("/api/patient")
+	patient.GET("/scans/:id", patientHandler.GetScanByID)
+	patient.GET("/scans/:id/plan", patientHandler.GetTreatmentPlan)
+	patient.GET("/plans/:plan_id/offers", patientHandler.GetOffers)
+	patient.POST("/select-offer", patientHandler.SelectOffer)
+
+	clinic := router.Group("/api/clinic")
+	clinic.GET("/plans/:plan_id/scan", clinicHandler.GetPlanScan)
+	clinic.POST("/plans/:plan_id/quote", clinicHandler.QuotePlan)
+	clinic.POST("/offers", clinicHandler.CreateOffer)
+	clinic.PUT("/offers/:id", clinicHandler.UpdateOffer)
+	clinic.DELETE("/offers/:id", clinicHandler.WithdrawOffer)
+	clinic.POST("/offers/:id/send", clinicHandler.SendOffer)
+	clinic.PUT("/appointments/:id", clinicHandler.UpdateAppointment)
+
+	return router
+}
+
+// serve sends a request as the user and returns the response status
+func serve(t *testing.T, router *gin.Engine, userID uint, role, method, path string, body interface{}) int {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", strconv.FormatUint(uint64(userID), 10))
+	req.Header.Set("X-Test-Role", role)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+type authzCase struct {
+	name   string
+	method string
+	path   string
+	body   interface{}
+	want   int
+}
+
+func TestPatientCannotReachAnotherPatientsResources(t *testing.T) {
+	db := testdb.Open(t)
+	router := testRouter(t, db)
+
+	owner := testdb.Patient(t, db)
+	intruder := testdb.Patient(t, db)
+	scan := testdb.Scan(t, db, owner)
+	plan := testdb.Plan(t, db, scan, models.PlanStatusOffersReceived)
+	offer := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusSent)
+	draft := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusPending)
+
+	selectOffer := func(offer *models.ClinicOffer) gin.H {
+		return gin.H{"offer_id": offer.ID, "offer_version": offer.Version}
+	}
+
+	for _, tc := range []authzCase{
+		{"scan", http.MethodGet, fmt.Sprintf("/api/patient/scans/%d", scan.ID), nil, http.StatusNotFound},
+		{"scan plan", http.MethodGet, fmt.Sprintf("/api/patient/scans/%d/plan", scan.ID), nil, http.StatusNotFound},
+		{"plan offers", http.MethodGet, fmt.Sprintf("/api/patient/plans/%d/offers", plan.ID), nil, http.StatusNotFound},
+		{"select offer", http.MethodPost, "/api/patient/select-offer", selectOffer(offer), http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := serve(t, router, intruder.UserID, models.RolePatient, tc.method, tc.path, tc.body); got != tc.want {
+				t.Errorf("%s %s as another patient = %d, want %d", tc.method, tc.path, got, tc.want)
+			}
+		})
+	}
+
+	// The owner reaches the same resources, so the 404s above come from the
+	// policies rather than missing rows
+	for _, tc := range []authzCase{
+		{"own scan", http.MethodGet, fmt.Sprintf("/api/patient/scans/%d", scan.ID), nil, http.StatusOK},
+		{"own scan plan", http.MethodGet, fmt.Sprintf("/api/patient/scans/%d/plan", scan.ID), nil, http.StatusOK},
+		{"own plan offers", http.MethodGet, fmt.Sprintf("/api/patient/plans/%d/offers", plan.ID), nil, http.StatusOK},
+		{"own pending draft", http.MethodPost, "/api/patient/select-offer", selectOffer(draft), http.StatusNotFound},
+		{"own offer", http.MethodPost, "/api/patient/select-offer", selectOffer(offer), http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := serve(t, router, owner.UserID, models.RolePatient, tc.method, tc.path, tc.body); got != tc.want {
+				t.Errorf("%s %s as the owner = %d, want %d", tc.method, tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClinicCannotReachOtherTenantsResources(t *testing.T) {
+	db := testdb.Open(t)
+	router := testRouter(t, db)
+
+	clinic := testdb.Clinic(t, db)
+	other := testdb.Clinic(t, db)
+	for _, c := range []*models.Clinic{clinic, other} {
+		if err := db.Model(c).Update("has_therapy", true).Error; err != nil {
+			t.Fatalf("failed to enable therapy: %v", err)
+		}
+	}
+
+	patient := testdb.Patient(t, db)
+	requested := time.Now()
+
+	// Published to another city, so never targeted at the clinic
+	untargeted := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersRequested)
+	if err := db.Model(untargeted).Updates(map[string]interface{}{
+		"offers_requested_at": requested,
+		"target_city":         "Казань",
+	}).Error; err != nil {
+		t.Fatalf("failed to publish plan: %v", err)
+	}
+	// Never published at all
+	unpublished := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusGenerated)
+
+	targeted := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersReceived)
+	if err := db.Model(targeted).Update("offers_requested_at", requested).Error; err != nil {
+		t.Fatalf("failed to publish plan: %v", err)
+	}
+	foreignOffer := testdb.Offer(t, db, targeted, other, models.OfferStatusSent)
+	foreignDraft := testdb.Offer(t, db, targeted, other, models.OfferStatusPending)
+	foreignAppointment := testdb.Appointment(t, db, patient, other)
+
+	offerBody := func(plan *models.TreatmentPlan) gin.H {
+		return gin.H{
+			"treatment_plan_id": plan.ID,
+			"items":             []gin.H{{"treatment_item_id": plan.Items[0].ID, "price": 5000}},
+		}
+	}
+
+	var cases []authzCase
+	for name, plan := range map[string]*models.TreatmentPlan{"untargeted": untargeted, "unpublished": unpublished} {
+		cases = append(cases,
+			authzCase{name + " plan scan", http.MethodGet, fmt.Sprintf("/api/clinic/plans/%d/scan", plan.ID), nil, http.StatusNotFound},
+			authzCase{name + " plan quote", http.MethodPost, fmt.Sprintf("/api/clinic/plans/%d/quote", plan.ID), nil, http.StatusNotFound},
+			authzCase{name + " plan offer", http.MethodPost, "/api/clinic/offers", offerBody(plan), http.StatusNotFound},
+		)
+	}
+	for name, offer := range map[string]*models.ClinicOffer{"sent": foreignOffer, "draft": foreignDraft} {
+		path := fmt.Sprintf("/api/clinic/offers/%d", offer.ID)
+		cases = append(cases,
+			authzCase{"update another clinic's " + name + " offer", http.MethodPut, path, offerBody(targeted), http.StatusNotFound},
+			authzCase{"send another clinic's " + name + " offer", http.MethodPost, path + "/send", nil, http.StatusNotFound},
+			authzCase{"withdraw another clinic's " + name + " offer", http.MethodDelete, path, nil, http.StatusNotFound},
+		)
+	}
+	// A targeted plan passes the policy and only fails for the missing
+	// anonymized copy
+	cases = append(cases, authzCase{
+		"targeted plan scan", http.MethodGet,
+		fmt.Sprintf("/api/clinic/plans/%d/scan", targeted.ID), nil, http.StatusConflict,
+	})
+	cases = append(cases, authzCase{
+		"another clinic's appointment", http.MethodPut,
+		fmt.Sprintf("/api/clinic/appointments/%d", foreignAppointment.ID),
+		gin.H{"status": models.AppointmentStatusConfirmed}, http.StatusNotFound,
+	})
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := serve(t, router, clinic.UserID, models.RoleClinic, tc.method, tc.path, tc.body); got != tc.want {
+				t.Errorf("%s %s = %d, want %d", tc.method, tc.path, got, tc.want)
+			}
+		})
+	}
+
+	// The owning clinic still manages its own offer and appointment
+	for _, tc := range []authzCase{
+		{"own appointment", http.MethodPut, fmt.Sprintf("/api/clinic/appointments/%d", foreignAppointment.ID), gin.H{"status": models.AppointmentStatusConfirmed}, http.StatusOK},
+		{"own offer", http.MethodDelete, fmt.Sprintf("/api/clinic/offers/%d", foreignOffer.ID), nil, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := serve(t, router, other.UserID, models.RoleClinic, tc.method, tc.path, tc.body); got != tc.want {
+				t.Errorf("%s %s as the owner = %d, want %d", tc.method, tc.path, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"dental-marketplace/backend/internal/ical"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarHandler serves read-only iCalendar appointment feeds. Calendar
+// clients cannot send a bearer token, so feeds are authorized by a token in
+// the URL that the user issues and revokes from the authenticated API.
+type CalendarHandler struct {
+	repo      *repository.Repository
+	publicURL string
+}
+
+func NewCalendarHandler(repo *repository.Repository, publicURL string) *CalendarHandler {
+	return &CalendarHandler{repo: repo, publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// CalendarTokenResponse is a newly issued feed URL. The token is shown only
+// once.
+type CalendarTokenResponse struct {
+	Token   string `json:"token"`
+	FeedURL string `json:"feed_url"`
+}
+
+// IssueToken issues a calendar feed token
+// @Summary Issue calendar feed token
+// @Description Issue a token for the caller's read-only iCalendar appointment feed. Any earlier token
+// @Description stops working. The token is returned only once.
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} CalendarTokenResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/calendar-token [post]
+// @Router /api/patient/calendar-token [post]
+func (h *CalendarHandler) IssueToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue calendar token",
+		})
+		return
+	}
+	token := hex.EncodeToString(secret)
+
+	if _, err := h.repo.IssueCalendarToken(userID.(uint), hashCalendarToken(token)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue calendar token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CalendarTokenResponse{
+		Token:   token,
+		FeedURL: fmt.Sprintf("%s/api/%s/appointments.ics?token=%s", h.publicURL, role, token),
+	})
+}
+
+// RevokeToken revokes the caller's calendar feed token
+// @Summary Revoke calendar feed token
+// @Description Revoke the caller's calendar feed token; the feed URL stops working
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/calendar-token [delete]
+// @Router /api/patient/calendar-token [delete]
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.repo.RevokeCalendarTokens(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke calendar token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Calendar token revoked successfully",
+	})
+}
+
+// ClinicFeed serves a clinic's appointments as an iCalendar feed
+// @Summary Clinic appointment feed
+// @Description Read-only iCalendar (RFC 5545) feed of the clinic's appointments, authorized by a calendar
+// @Description feed token. Cancelled appointments stay in the feed with STATUS:CANCELLED.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token query string true "Calendar feed token"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/appointments.ics [get]
+func (h *CalendarHandler) ClinicFeed(c *gin.Context) {
+	user, ok := h.feedUser(c, models.RoleClinic)
+	if !ok {
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendar not found",
+		})
+		return
+	}
+
+	appointments, err := h.repo.GetClinicAppointments(clinic.ID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve appointments",
+		})
+		return
+	}
+
+	calendar := &ical.Calendar{Name: clinic.Name}
+	for i := range appointments {
+		a := &appointments[i]
+		event := appointmentEvent(a)
+		event.Summary = strings.TrimSpace(fmt.Sprintf("%s: %s %s", a.Specialization, a.Patient.FirstName, a.Patient.LastName))
+		event.Location = clinic.Address
+		calendar.Events = append(calendar.Events, event)
+	}
+
+	writeCalendar(c, calendar)
+}
+
+// PatientFeed serves a patient's appointments as an iCalendar feed
+// @Summary Patient appointment feed
+// @Description Read-only iCalendar (RFC 5545) feed of the patient's appointments, authorized by a calendar
+// @Description feed token. Cancelled appointments stay in the feed with STATUS:CANCELLED.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token query string true "Calendar feed token"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/appointments.ics [get]
+func (h *CalendarHandler) PatientFeed(c *gin.Context) {
+	user, ok := h.feedUser(c, models.RolePatient)
+	if !ok {
+		return
+	}
+
+	patient, err := h.repo.GetPatientByUserID(user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendar not found",
+		})
+		return
+	}
+
+	appointments, err := h.repo.GetPatientAppointments(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve appointments",
+		})
+		return
+	}
+
+	calendar := &ical.Calendar{Name: "Стоматология"}
+	for i := range appointments {
+		a := &appointments[i]
+		event := appointmentEvent(a)
+		event.Summary = strings.TrimSpace(fmt.Sprintf("%s: %s", a.Clinic.Name, a.Specialization))
+		event.Location = a.Clinic.Address
+		calendar.Events = append(calendar.Events, event)
+	}
+
+	writeCalendar(c, calendar)
+}
+
+// feedUser resolves the token query parameter to a user with the given
+// role. Unknown, revoked and foreign tokens all get the same 404. It writes
+// the error response when it fails.
+func (h *CalendarHandler) feedUser(c *gin.Context, role string) (*models.User, bool) {
+	token := c.Query("token")
+	if token != "" {
+		user, err := h.repo.GetUserByCalendarToken(hashCalendarToken(token))
+		if err == nil && user.Role == role {
+			return user, true
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{
+		"error": "Calendar not found",
+	})
+	return nil, false
+}
+
+// appointmentEvent maps an appointment to an event. The UID is derived from
+// the appointment ID so it never changes; every recorded change, such as a
+// reschedule or cancellation, bumps the sequence so clients pick it up.
+func appointmentEvent(a *models.Appointment) ical.Event {
+	duration := a.DurationMinutes
+	if duration <= 0 {
+		duration = 60
+	}
+
+	event := ical.Event{
+		UID:          fmt.Sprintf("appointment-%d@dental-marketplace", a.ID),
+		Sequence:     len(a.History),
+		Start:        a.AppointmentDate,
+		End:          a.AppointmentDate.Add(time.Duration(duration) * time.Minute),
+		LastModified: a.UpdatedAt,
+		Description:  a.Notes,
+		Status:       ical.StatusConfirmed,
+	}
+	switch a.Status {
+	case models.AppointmentStatusScheduled:
+		event.Status = ical.StatusTentative
+	case models.AppointmentStatusCancelled:
+		event.Status = ical.StatusCancelled
+	}
+	if a.Resource != nil {
+		event.Description = strings.TrimSpace(a.Resource.Name + "\n" + event.Description)
+	}
+	return event
+}
+
+func writeCalendar(c *gin.Context, calendar *ical.Calendar) {
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	calendar.Write(c.Writer, time.Now())
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
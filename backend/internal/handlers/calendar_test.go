@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/ical"
+	"dental-marketplace/backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestAppointmentEvent(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	appointment := &models.Appointment{
+		ID:              7,
+		AppointmentDate: start,
+		Status:          models.AppointmentStatusScheduled,
+		Notes:           "принести снимки",
+		Resource:        &models.ClinicResource{Name: "Кресло 1"},
+	}
+
+	event := appointmentEvent(appointment)
+	if event.UID != "appointment-7@dental-marketplace" || event.Sequence != 0 {
+		t.Errorf("new appointment = UID %q, sequence %d", event.UID, event.Sequence)
+	}
+	if event.Status != ical.StatusTentative || !event.End.Equal(start.Add(time.Hour)) {
+		t.Errorf("new appointment = status %s, ends %s", event.Status, event.End)
+	}
+	if event.Description != "Кресло 1\nпринести снимки" {
+		t.Errorf("description = %q", event.Description)
+	}
+
+	// Rescheduling and cancelling keep the UID and bump the sequence
+	appointment.AppointmentDate = start.Add(24 * time.Hour)
+	appointment.DurationMinutes = 30
+	appointment.Status = models.AppointmentStatusCancelled
+	appointment.History = make([]models.AppointmentChange, 2)
+
+	event = appointmentEvent(appointment)
+	if event.UID != "appointment-7@dental-marketplace" || event.Sequence != 2 {
+		t.Errorf("changed appointment = UID %q, sequence %d", event.UID, event.Sequence)
+	}
+	if event.Status != ical.StatusCancelled || !event.End.Equal(appointment.AppointmentDate.Add(30*time.Minute)) {
+		t.Errorf("changed appointment = status %s, ends %s", event.Status, event.End)
+	}
+}
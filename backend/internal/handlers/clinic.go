@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"dental-marketplace/backend/internal/authz"
+	"dental-marketplace/backend/internal/config"
+	"dental-marketplace/backend/internal/jobs"
+	"dental-marketplace/backend/internal/matching"
 	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/offers"
 	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,11 +18,21 @@ import (
 )
 
 type ClinicHandler struct {
-	repo *repository.Repository
+	repo       *repository.Repository
+	store      storage.Storage
+	estimates  *jobs.EstimateRefresher
+	storageCfg config.StorageConfig
+	offerCfg   config.OfferConfig
 }
 
-func NewClinicHandler(repo *repository.Repository) *ClinicHandler {
-	return &ClinicHandler{repo: repo}
+func NewClinicHandler(repo *repository.Repository, store storage.Storage, estimates *jobs.EstimateRefresher, storageCfg config.StorageConfig, offerCfg config.OfferConfig) *ClinicHandler {
+	return &ClinicHandler{
+		repo:       repo,
+		store:      store,
+		estimates:  estimates,
+		storageCfg: storageCfg,
+		offerCfg:   offerCfg,
+	}
 }
 
 // GetDashboard retrieves dashboard metrics for clinic
@@ -65,17 +82,39 @@ func (h *ClinicHandler) GetDashboard(c *gin.Context) {
 	c.JSON(http.StatusOK, metrics)
 }
 
+// IncomingPlan is a treatment plan ranked for the requesting clinic
+type IncomingPlan struct {
+	models.TreatmentPlan
+	RelevanceScore float64  `json:"relevance_score"`
+	DistanceKm     *float64 `json:"distance_km,omitempty"`
+}
+
+// maxRankedIncomingPlans caps how many of the most recently published plans
+// are loaded and ranked per request. Older plans have all but lost their
+// freshness score.
+const maxRankedIncomingPlans = 500
+
 // GetIncomingPlans retrieves treatment plans for clinic to review
 // @Summary Get incoming treatment plans
-// @Description Get treatment plans that clinic can bid on
+// @Description Get treatment plans that clinic can bid on, most relevant first.
+// @Description Relevance combines item urgency, distance to the patient's district and plan age.
+// @Description Only the 500 most recently published plans are ranked.
+// @Description The total number of ranked plans is returned in the X-Total-Count header.
 // @Tags clinic
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} models.TreatmentPlan
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Plans per page" default(20)
+// @Success 200 {array} IncomingPlan
 // @Failure 500 {object} ErrorResponse
 // @Router /api/clinic/incoming-plans [get]
 func (h *ClinicHandler) GetIncomingPlans(c *gin.Context) {
 	userID, _ := c.Get("userID")
+
+	page, ok := bindPage(c)
+	if !ok {
+		return
+	}
 	
 	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
 	if err != nil {
@@ -85,7 +124,7 @@ func (h *ClinicHandler) GetIncomingPlans(c *gin.Context) {
 		return
 	}
 
-	plans, err := h.repo.GetIncomingTreatmentPlans(clinic.ID, "")
+	plans, err := h.repo.GetIncomingTreatmentPlans(clinic, "", maxRankedIncomingPlans)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve treatment plans",
@@ -93,26 +132,128 @@ func (h *ClinicHandler) GetIncomingPlans(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, plans)
+	districts, err := h.repo.GetDistrictLocations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve treatment plans",
+		})
+		return
+	}
+
+	matches := matching.Rank(plans, clinic, matching.NewLocations(districts), time.Now())
+
+	start := min(page.Offset(), len(matches))
+	end := min(start+page.PerPage, len(matches))
+	result := make([]IncomingPlan, 0, end-start)
+	for _, match := range matches[start:end] {
+		result = append(result, IncomingPlan{
+			TreatmentPlan:  match.Plan,
+			RelevanceScore: match.Score,
+			DistanceKm:     match.DistanceKm,
+		})
+	}
+
+	c.Header("X-Total-Count", strconv.Itoa(len(matches)))
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPlanScan returns a signed URL for the de-identified scan of a plan
+// @Summary Get plan scan download URL
+// @Description Get a short-lived signed URL for the anonymized CT scan behind a treatment plan.
+// @Description Clinics never receive the original file.
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path int true "Treatment plan ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/clinic/plans/{plan_id}/scan [get]
+func (h *ClinicHandler) GetPlanScan(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	planID, err := strconv.ParseUint(c.Param("plan_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid plan ID",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	// Clinics only see plans targeted at them or ones they already bid on
+	plan, err := h.repo.GetTreatmentPlanByID(uint(planID))
+	if err != nil || !authz.ClinicCanViewPlan(clinic, plan) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Treatment plan not found",
+		})
+		return
+	}
+
+	scan, err := h.repo.GetCTScanByID(plan.CTScanID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Scan not found",
+		})
+		return
+	}
+
+	// Only the de-identified copy is ever signed for clinics
+	if scan.AnonymizedKey == "" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Anonymized scan is not available",
+		})
+		return
+	}
+
+	url, err := h.store.SignedURL(c.Request.Context(), scan.AnonymizedKey, h.storageCfg.SignedURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate download URL",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":        url,
+		"checksum":   scan.AnonymizedChecksum,
+		"expires_at": time.Now().Add(h.storageCfg.SignedURLTTL),
+	})
 }
 
 // CreateOffer creates a clinic offer for a treatment plan
 type CreateOfferRequest struct {
-	TreatmentPlanID   uint   `json:"treatment_plan_id" binding:"required"`
-	TherapyCost       int    `json:"therapy_cost"`
-	OrthopedicsCost   int    `json:"orthopedics_cost"`
-	SurgeryCost       int    `json:"surgery_cost"`
-	HygieneCost       int    `json:"hygiene_cost"`
-	PeriodonticsCost  int    `json:"periodontics_cost"`
-	TotalCost         int    `json:"total_cost" binding:"required"`
-	EstimatedDuration string `json:"estimated_duration"`
-	InstallmentMonths int    `json:"installment_months"`
-	WarrantyDetails   string `json:"warranty_details"`
-	Notes             string `json:"notes"`
+	TreatmentPlanID   uint               `json:"treatment_plan_id" binding:"required"`
+	Items             []OfferItemRequest `json:"items" binding:"required,dive"`
+	EstimatedDuration string             `json:"estimated_duration"`
+	InstallmentMonths int                `json:"installment_months" binding:"min=0,max=60"` // longest installment term offered; 0 for none
+	WarrantyDetails   string             `json:"warranty_details"`
+	Notes             string             `json:"notes"`
+	WaitDays          *int               `json:"wait_days" binding:"omitempty,min=0"` // days until treatment can start
+	ValidUntil        *time.Time         `json:"valid_until"`                         // defaults to the configured offer validity
+}
+
+// OfferItemRequest prices or excludes one treatment plan item
+type OfferItemRequest struct {
+	TreatmentItemID uint   `json:"treatment_item_id" binding:"required"`
+	PriceListID     *uint  `json:"price_list_id"`
+	Excluded        bool   `json:"excluded"`
+	Price           int    `json:"price"`
+	WarrantyYears   int    `json:"warranty_years"`
+	Notes           string `json:"notes"`
 }
 
 // @Summary Create clinic offer
-// @Description Create an offer for a treatment plan
+// @Description Create an offer for a treatment plan. Every plan item must be priced or excluded;
+// @Description per-specialization and total costs are computed from the items.
+// @Description The offer expires at valid_until, which defaults to the configured offer validity.
 // @Tags clinic
 // @Accept json
 // @Produce json
@@ -120,6 +261,8 @@ type CreateOfferRequest struct {
 // @Param request body CreateOfferRequest true "Offer details"
 // @Success 201 {object} models.ClinicOffer
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /api/clinic/offers [post]
 func (h *ClinicHandler) CreateOffer(c *gin.Context) {
 	userID, _ := c.Get("userID")
@@ -140,65 +283,69 @@ func (h *ClinicHandler) CreateOffer(c *gin.Context) {
 		return
 	}
 
+	plan, err := h.repo.GetTreatmentPlanByID(req.TreatmentPlanID)
+	if err != nil || !authz.ClinicCanViewPlan(clinic, plan) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Treatment plan not found",
+		})
+		return
+	}
+
+	priceList, err := h.repo.GetClinicPriceList(clinic.ID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve price list",
+		})
+		return
+	}
+
+	validUntil, ok := h.offerValidUntil(c, req.ValidUntil)
+	if !ok {
+		return
+	}
+
 	offer := &models.ClinicOffer{
 		TreatmentPlanID:   req.TreatmentPlanID,
 		ClinicID:          clinic.ID,
 		Status:            models.OfferStatusSent,
-		TherapyCost:       req.TherapyCost,
-		OrthopedicsCost:   req.OrthopedicsCost,
-		SurgeryCost:       req.SurgeryCost,
-		HygieneCost:       req.HygieneCost,
-		PeriodonticsCost:  req.PeriodonticsCost,
-		TotalCost:         req.TotalCost,
+		ValidUntil:        &validUntil,
 		EstimatedDuration: req.EstimatedDuration,
 		InstallmentMonths: req.InstallmentMonths,
 		WarrantyDetails:   req.WarrantyDetails,
 		Notes:             req.Notes,
+		WaitDays:          req.WaitDays,
 	}
 
-	err = h.repo.CreateClinicOffer(offer)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create offer",
-		})
+	if err := offers.Build(offer, plan, priceList, offerItemInputs(req.Items)); err != nil {
+		offerFailed(c, err)
 		return
 	}
-
-	// Update treatment plan status
-	h.repo.UpdateTreatmentPlanStatus(req.TreatmentPlanID, models.PlanStatusOffersReceived)
-
-	c.JSON(http.StatusCreated, offer)
-}
-
-// GetLeads retrieves accepted offers (leads) for clinic
-// @Summary Get clinic leads
-// @Description Get all accepted offers (leads) for the clinic
-// @Tags clinic
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {array} models.ClinicOffer
-// @Failure 500 {object} ErrorResponse
-// @Router /api/clinic/leads [get]
-func (h *ClinicHandler) GetLeads(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	
-	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Clinic profile not found",
-		})
+	if err := offers.Complete(offer, plan); err != nil {
+		offerFailed(c, err)
 		return
 	}
 
-	leads, err := h.repo.GetClinicLeads(clinic.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve leads",
-		})
+	if err := h.repo.CreateClinicOffer(offer, requestActor(c)); err != nil {
+		offerFailed(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, leads)
+	c.JSON(http.StatusCreated, offer)
+}
+
+func offerItemInputs(items []OfferItemRequest) []offers.ItemInput {
+	inputs := make([]offers.ItemInput, len(items))
+	for i, item := range items {
+		inputs[i] = offers.ItemInput{
+			TreatmentItemID: item.TreatmentItemID,
+			PriceListID:     item.PriceListID,
+			Excluded:        item.Excluded,
+			Price:           item.Price,
+			WarrantyYears:   item.WarrantyYears,
+			Notes:           item.Notes,
+		}
+	}
+	return inputs
 }
 
 // GetAppointments retrieves appointments for clinic
@@ -241,17 +388,23 @@ type UpdateAppointmentRequest struct {
 }
 
 // @Summary Update appointment
-// @Description Update appointment status and notes
+// @Description Move an appointment through its lifecycle: scheduled → confirmed → completed or no_show;
+// @Description scheduled and confirmed appointments may be cancelled. Completing an appointment starts
+// @Description treatment on its plan and completes the plan once all its treatment is done.
 // @Tags clinic
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Appointment ID"
 // @Param request body UpdateAppointmentRequest true "Update details"
-// @Success 200 {object} SuccessResponse
+// @Success 200 {object} models.Appointment
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /api/clinic/appointments/{id} [put]
 func (h *ClinicHandler) UpdateAppointment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
 	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -267,18 +420,49 @@ func (h *ClinicHandler) UpdateAppointment(c *gin.Context) {
 		})
 		return
 	}
+	if !repository.IsAppointmentStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown appointment status: " + req.Status,
+		})
+		return
+	}
 
-	err = h.repo.UpdateAppointmentStatus(uint(appointmentID), req.Status, req.Notes)
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update appointment",
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Appointment updated successfully",
-	})
+	appointment, err := h.repo.GetAppointmentByID(uint(appointmentID))
+	if err != nil || !authz.ClinicCanManageAppointment(clinic, appointment) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Appointment not found",
+		})
+		return
+	}
+
+	appointment, err = h.repo.TransitionAppointment(appointment.ID, clinic.ID, req.Status, req.Notes, requestActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Appointment not found",
+			})
+		case errors.Is(err, repository.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update appointment",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, appointment)
 }
 
 // GetPriceList retrieves clinic price list
@@ -324,6 +508,7 @@ func (h *ClinicHandler) GetPriceList(c *gin.Context) {
 // @Param request body []models.PriceList true "Price list items"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/clinic/price-list [put]
 func (h *ClinicHandler) UpdatePriceList(c *gin.Context) {
 	userID, _ := c.Get("userID")
@@ -344,12 +529,73 @@ func (h *ClinicHandler) UpdatePriceList(c *gin.Context) {
 		return
 	}
 
+	// Existing rows may only be edited by the clinic that owns them
+	current, err := h.repo.GetClinicPriceList(clinic.ID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve price list",
+		})
+		return
+	}
+	if !authz.ClinicCanManagePriceList(clinic, current, items) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Price list item not found",
+		})
+		return
+	}
+
 	// Set clinic ID for all items
 	for i := range items {
 		items[i].ClinicID = clinic.ID
 	}
 
+	// Catalog codes must exist and belong to the row's specialization
+	var codes []string
+	for _, item := range items {
+		if item.ProcedureCode != "" {
+			codes = append(codes, item.ProcedureCode)
+		}
+	}
+	if len(codes) > 0 {
+		procedures, err := h.repo.GetProceduresByCodes(codes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to validate procedure codes",
+			})
+			return
+		}
+
+		specializations := make(map[string]string, len(procedures))
+		for _, p := range procedures {
+			specializations[p.Code] = p.Specialization
+		}
+		for _, item := range items {
+			if item.ProcedureCode == "" {
+				continue
+			}
+			spec, ok := specializations[item.ProcedureCode]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Unknown procedure code: " + item.ProcedureCode,
+				})
+				return
+			}
+			if spec != item.Specialization {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Procedure " + item.ProcedureCode + " does not belong to specialization " + item.Specialization,
+				})
+				return
+			}
+		}
+	}
+
 	err = h.repo.UpdatePriceList(items)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Price list item not found",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update price list",
@@ -357,6 +603,10 @@ func (h *ClinicHandler) UpdatePriceList(c *gin.Context) {
 		return
 	}
 
+	// Plan estimates in the clinic's city follow the new prices on the
+	// refresher's next run
+	h.estimates.Schedule(clinic.City)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Price list updated successfully",
 	})
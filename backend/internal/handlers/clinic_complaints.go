@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetComplaints retrieves complaints awaiting the clinic's response
+// @Summary Get clinic complaints
+// @Description Get the complaints regulators have asked the clinic to answer, with response deadlines
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Complaint
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/complaints [get]
+func (h *ClinicHandler) GetComplaints(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	complaints, err := h.repo.GetClinicComplaints(clinic.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve complaints",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, complaints)
+}
+
+// GetComplaint retrieves one complaint
+// @Summary Get clinic complaint
+// @Description Get a complaint the clinic has been asked to answer, with the regulator's requests and
+// @Description the clinic's earlier answers. Regulators' internal notes are not included.
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Complaint ID"
+// @Success 200 {object} models.Complaint
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/complaints/{id} [get]
+func (h *ClinicHandler) GetComplaint(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	complaint, err := h.repo.GetClinicComplaint(clinic.ID, complaintID)
+	if err != nil {
+		complaintFailed(c, err, "Failed to retrieve complaint")
+		return
+	}
+
+	c.JSON(http.StatusOK, complaint)
+}
+
+// ComplaintResponseRequest is the clinic's answer to a complaint
+type ComplaintResponseRequest struct {
+	Response string `json:"response" binding:"required"`
+}
+
+// RespondToComplaint answers a complaint
+// @Summary Respond to complaint
+// @Description Answer a complaint a regulator has asked the clinic to respond to. The assigned
+// @Description regulator is notified. Each request takes one answer.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Complaint ID"
+// @Param request body ComplaintResponseRequest true "Response"
+// @Success 200 {object} models.Complaint
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/clinic/complaints/{id}/response [post]
+func (h *ClinicHandler) RespondToComplaint(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ComplaintResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	if err := h.repo.RespondToComplaint(clinic.ID, complaintID, req.Response, requestActor(c)); err != nil {
+		complaintFailed(c, err, "Failed to save response")
+		return
+	}
+
+	complaint, err := h.repo.GetClinicComplaint(clinic.ID, complaintID)
+	if err != nil {
+		complaintFailed(c, err, "Failed to retrieve complaint")
+		return
+	}
+
+	c.JSON(http.StatusOK, complaint)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InstallmentProductRequest is one product in the clinic's installment list
+type InstallmentProductRequest struct {
+	Name               string  `json:"name" binding:"required"`
+	TermMonths         int     `json:"term_months" binding:"required,min=1,max=60"`
+	AnnualInterestRate float64 `json:"annual_interest_rate" binding:"min=0,max=100"`
+	MarkupPercent      float64 `json:"markup_percent" binding:"min=0,max=100"`
+	DownPaymentPercent float64 `json:"down_payment_percent" binding:"min=0,lt=100"`
+	IsActive           *bool   `json:"is_active"` // defaults to true
+}
+
+// GetInstallmentProducts retrieves the clinic's installment products
+// @Summary Get installment products
+// @Description Get the installment plans the clinic offers
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.InstallmentProduct
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/installment-products [get]
+func (h *ClinicHandler) GetInstallmentProducts(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	products, err := h.repo.GetInstallmentProducts(clinic.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve installment products",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// UpdateInstallmentProducts replaces the clinic's installment products
+// @Summary Update installment products
+// @Description Replace the installment plans the clinic offers. An offer lists a schedule for every
+// @Description active product whose term fits the offer's installment_months.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body []InstallmentProductRequest true "Installment products"
+// @Success 200 {array} models.InstallmentProduct
+// @Failure 400 {object} ErrorResponse
+// @Router /api/clinic/installment-products [put]
+func (h *ClinicHandler) UpdateInstallmentProducts(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req []InstallmentProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	products := make([]models.InstallmentProduct, len(req))
+	for i, p := range req {
+		products[i] = models.InstallmentProduct{
+			Name:               p.Name,
+			TermMonths:         p.TermMonths,
+			AnnualInterestRate: p.AnnualInterestRate,
+			MarkupPercent:      p.MarkupPercent,
+			DownPaymentPercent: p.DownPaymentPercent,
+			IsActive:           p.IsActive == nil || *p.IsActive,
+		}
+	}
+
+	if err := h.repo.ReplaceInstallmentProducts(clinic.ID, products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update installment products",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Leads are opened when a patient accepts the clinic's offer. The clinic
+// works them through contact, consultation and treatment; booking and
+// completing appointments moves them forward automatically.
+
+// LeadsQuery filters the lead list
+type LeadsQuery struct {
+	Status          string `form:"status"`
+	AssignedStaffID *uint  `form:"assigned_staff_id"`
+}
+
+// GetLeads retrieves the clinic's leads
+// @Summary Get clinic leads
+// @Description Get the clinic's leads with their offer, patient, assignee and open tasks
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by lead status"
+// @Param assigned_staff_id query int false "Filter by assigned staff member"
+// @Success 200 {array} models.Lead
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/leads [get]
+func (h *ClinicHandler) GetLeads(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var query LeadsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Status != "" && !repository.IsLeadStatus(query.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown lead status: " + query.Status,
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	leads, err := h.repo.GetClinicLeads(clinic.ID, query.Status, query.AssignedStaffID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve leads",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, leads)
+}
+
+// GetLead retrieves one lead
+// @Summary Get lead
+// @Description Get a lead with its tasks, contact log and status history
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lead ID"
+// @Success 200 {object} models.Lead
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/leads/{id} [get]
+func (h *ClinicHandler) GetLead(c *gin.Context) {
+	clinic, leadID, ok := h.clinicLeadID(c)
+	if !ok {
+		return
+	}
+
+	lead, err := h.repo.GetClinicLead(clinic.ID, leadID)
+	if err != nil {
+		leadFailed(c, err, "Failed to retrieve lead")
+		return
+	}
+
+	c.JSON(http.StatusOK, lead)
+}
+
+// UpdateLeadRequest moves a lead through the pipeline or reassigns it
+type UpdateLeadRequest struct {
+	Status          string `json:"status"`            // new status; omit to keep
+	Reason          string `json:"reason"`            // required when rejecting
+	AssignedStaffID *uint  `json:"assigned_staff_id"` // 0 unassigns; omit to keep
+}
+
+// UpdateLead updates a lead
+// @Summary Update lead
+// @Description Move a lead through the pipeline and/or assign it to a staff member.
+// @Description new → contacted → consultation_scheduled → treatment_started → treatment_completed;
+// @Description open leads may be rejected with a reason. Completed and rejected leads are final.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lead ID"
+// @Param request body UpdateLeadRequest true "Lead update"
+// @Success 200 {object} models.Lead
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/clinic/leads/{id} [put]
+func (h *ClinicHandler) UpdateLead(c *gin.Context) {
+	var req UpdateLeadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	if req.Status == "" && req.AssignedStaffID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nothing to update",
+		})
+		return
+	}
+	if req.Status != "" && !repository.IsLeadStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown lead status: " + req.Status,
+		})
+		return
+	}
+	if req.Status == models.LeadStatusRejected && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A reason is required to reject a lead",
+		})
+		return
+	}
+
+	clinic, leadID, ok := h.clinicLeadID(c)
+	if !ok {
+		return
+	}
+
+	update := repository.LeadUpdate{
+		Status:          req.Status,
+		Reason:          req.Reason,
+		AssignedStaffID: req.AssignedStaffID,
+	}
+	if err := h.repo.UpdateLead(clinic.ID, leadID, update, requestActor(c)); err != nil {
+		leadFailed(c, err, "Failed to update lead")
+		return
+	}
+
+	lead, err := h.repo.GetClinicLead(clinic.ID, leadID)
+	if err != nil {
+		leadFailed(c, err, "Failed to retrieve lead")
+		return
+	}
+
+	c.JSON(http.StatusOK, lead)
+}
+
+// LeadTaskRequest describes a follow-up task
+type LeadTaskRequest struct {
+	Title           string    `json:"title" binding:"required"`
+	Notes           string    `json:"notes"`
+	DueAt           time.Time `json:"due_at" binding:"required"`
+	AssignedStaffID *uint     `json:"assigned_staff_id"`
+	Completed       bool      `json:"completed"`
+}
+
+// CreateLeadTask adds a follow-up task to a lead
+// @Summary Create lead task
+// @Description Add a follow-up task with a due date to a lead
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lead ID"
+// @Param request body LeadTaskRequest true "Task"
+// @Success 201 {object} models.LeadTask
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/leads/{id}/tasks [post]
+func (h *ClinicHandler) CreateLeadTask(c *gin.Context) {
+	var req LeadTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, leadID, ok := h.clinicLeadID(c)
+	if !ok {
+		return
+	}
+
+	task := leadTask(leadID, &req)
+	if err := h.repo.CreateLeadTask(clinic.ID, task); err != nil {
+		leadFailed(c, err, "Failed to create task")
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+// UpdateLeadTask updates a lead's task
+// @Summary Update lead task
+// @Description Update a follow-up task, or mark it completed
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lead ID"
+// @Param task_id path int true "Task ID"
+// @Param request body LeadTaskRequest true "Task"
+// @Success 200 {object} models.LeadTask
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/leads/{id}/tasks/{task_id} [put]
+func (h *ClinicHandler) UpdateLeadTask(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("task_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid task ID",
+		})
+		return
+	}
+
+	var req LeadTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, leadID, ok := h.clinicLeadID(c)
+	if !ok {
+		return
+	}
+
+	task := leadTask(leadID, &req)
+	task.ID = uint(taskID)
+	if err := h.repo.UpdateLeadTask(clinic.ID, task); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Task not found",
+			})
+			return
+		}
+		leadFailed(c, err, "Failed to update task")
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// LeadContactRequest logs a contact with a lead
+type LeadContactRequest struct {
+	Channel     string     `json:"channel" binding:"required,oneof=phone email messenger visit"`
+	Summary     string     `json:"summary" binding:"required"`
+	StaffID     *uint      `json:"staff_id"`
+	ContactedAt *time.Time `json:"contacted_at"` // defaults to now
+}
+
+// CreateLeadContact logs a contact with a lead
+// @Summary Log lead contact
+// @Description Add an entry to a lead's contact log. The first contact with a new lead moves it to contacted.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lead ID"
+// @Param request body LeadContactRequest true "Contact"
+// @Success 201 {object} models.LeadContact
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/leads/{id}/contacts [post]
+func (h *ClinicHandler) CreateLeadContact(c *gin.Context) {
+	var req LeadContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, leadID, ok := h.clinicLeadID(c)
+	if !ok {
+		return
+	}
+
+	contact := &models.LeadContact{
+		LeadID:      leadID,
+		StaffID:     req.StaffID,
+		Channel:     req.Channel,
+		Summary:     req.Summary,
+		ContactedAt: time.Now(),
+	}
+	if req.ContactedAt != nil {
+		contact.ContactedAt = *req.ContactedAt
+	}
+	if err := h.repo.AddLeadContact(clinic.ID, contact, requestActor(c)); err != nil {
+		leadFailed(c, err, "Failed to log contact")
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact)
+}
+
+// clinicLeadID loads the calling clinic and parses the id path parameter.
+// It writes the error response when it fails.
+func (h *ClinicHandler) clinicLeadID(c *gin.Context) (*models.Clinic, uint, bool) {
+	userID, _ := c.Get("userID")
+
+	leadID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid lead ID",
+		})
+		return nil, 0, false
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return nil, 0, false
+	}
+
+	return clinic, uint(leadID), true
+}
+
+// leadTask builds a task from a request
+func leadTask(leadID uint, req *LeadTaskRequest) *models.LeadTask {
+	task := &models.LeadTask{
+		LeadID:          leadID,
+		AssignedStaffID: req.AssignedStaffID,
+		Title:           req.Title,
+		Notes:           req.Notes,
+		DueAt:           req.DueAt,
+	}
+	if req.Completed {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+	return task
+}
+
+// leadFailed maps a failed lead operation to a response
+func leadFailed(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Lead not found",
+		})
+	case errors.Is(err, repository.ErrStaffNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown staff member",
+		})
+	case errors.Is(err, repository.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message,
+		})
+	}
+}
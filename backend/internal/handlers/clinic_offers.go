@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/authz"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/offers"
+	"dental-marketplace/backend/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Clinics can draft offers instead of sending them at once: a quote is
+// priced from the clinic's own price list and discounts and stays pending,
+// invisible to the patient, until staff review it and send it. A sent offer
+// stays open until its validity passes, the clinic withdraws it or the
+// patient decides; revising it keeps the previous version as a revision.
+
+// QuoteResponse is a drafted offer and the plan items it could not price
+type QuoteResponse struct {
+	Offer            *models.ClinicOffer `json:"offer"`
+	UnmatchedItemIDs []uint              `json:"unmatched_item_ids"`
+}
+
+// QuotePlan drafts an offer from the clinic's price list
+// @Summary Auto-quote treatment plan
+// @Description Draft a pending offer by matching plan items to the clinic's price list and applying its discounts.
+// @Description Items without a matching price list entry are left unpriced and listed in unmatched_item_ids.
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path int true "Treatment plan ID"
+// @Success 201 {object} QuoteResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/clinic/plans/{plan_id}/quote [post]
+func (h *ClinicHandler) QuotePlan(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	planID, err := strconv.ParseUint(c.Param("plan_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid plan ID",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	plan, err := h.repo.GetTreatmentPlanByID(uint(planID))
+	if err != nil || !authz.ClinicCanViewPlan(clinic, plan) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Treatment plan not found",
+		})
+		return
+	}
+
+	priceList, err := h.repo.GetClinicPriceList(clinic.ID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve price list",
+		})
+		return
+	}
+
+	discounts, err := h.repo.GetClinicDiscounts(clinic.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve discounts",
+		})
+		return
+	}
+
+	offer, unmatched := offers.Quote(plan, clinic.ID, priceList, discounts)
+	if err := h.repo.CreateClinicOffer(offer, requestActor(c)); err != nil {
+		offerFailed(c, err)
+		return
+	}
+
+	if unmatched == nil {
+		unmatched = []uint{}
+	}
+	c.JSON(http.StatusCreated, QuoteResponse{
+		Offer:            offer,
+		UnmatchedItemIDs: unmatched,
+	})
+}
+
+// GetOffers retrieves the clinic's offers
+// @Summary Get clinic offers
+// @Description Get offers made by the clinic, optionally filtered by status (e.g. pending drafts)
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Offer status"
+// @Success 200 {array} models.ClinicOffer
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/offers [get]
+func (h *ClinicHandler) GetOffers(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	clinicOffers, err := h.repo.GetClinicOffers(clinic.ID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve offers",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, clinicOffers)
+}
+
+// UpdateOfferRequest replaces the terms and items of a draft or sent offer
+type UpdateOfferRequest struct {
+	Items             []OfferItemRequest `json:"items" binding:"required,dive"`
+	EstimatedDuration string             `json:"estimated_duration"`
+	InstallmentMonths int                `json:"installment_months" binding:"min=0,max=60"` // longest installment term offered; 0 for none
+	WarrantyDetails   string             `json:"warranty_details"`
+	Notes             string             `json:"notes"`
+	WaitDays          *int               `json:"wait_days" binding:"omitempty,min=0"` // days until treatment can start
+	ValidUntil        *time.Time         `json:"valid_until"`                         // sent offers only; defaults to the configured offer validity
+}
+
+// UpdateOffer edits a draft offer or revises a sent one
+// @Summary Update or revise offer
+// @Description Replace the items and terms of a pending offer. Items may be left unpriced until the offer is sent.
+// @Description A sent offer is revised instead: every item must be priced or excluded, the previous version
+// @Description is kept as a revision, the version is bumped and validity restarts from valid_until.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Offer ID"
+// @Param request body UpdateOfferRequest true "Offer details"
+// @Success 200 {object} models.ClinicOffer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/clinic/offers/{id} [put]
+func (h *ClinicHandler) UpdateOffer(c *gin.Context) {
+	var req UpdateOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, offer, ok := h.clinicOffer(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.repo.GetTreatmentPlanByID(offer.TreatmentPlanID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Treatment plan not found",
+		})
+		return
+	}
+
+	priceList, err := h.repo.GetClinicPriceList(clinic.ID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve price list",
+		})
+		return
+	}
+
+	offer.EstimatedDuration = req.EstimatedDuration
+	offer.InstallmentMonths = req.InstallmentMonths
+	offer.WarrantyDetails = req.WarrantyDetails
+	offer.Notes = req.Notes
+	offer.WaitDays = req.WaitDays
+	if err := offers.Build(offer, plan, priceList, offerItemInputs(req.Items)); err != nil {
+		offerFailed(c, err)
+		return
+	}
+
+	if offer.Status == models.OfferStatusSent {
+		if err := offers.Complete(offer, plan); err != nil {
+			offerFailed(c, err)
+			return
+		}
+		validUntil, ok := h.offerValidUntil(c, req.ValidUntil)
+		if !ok {
+			return
+		}
+		offer.ValidUntil = &validUntil
+	}
+
+	if err := h.repo.UpdateClinicOffer(offer); err != nil {
+		offerFailed(c, err)
+		return
+	}
+
+	updated, err := h.repo.GetClinicOfferByID(offer.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve offer",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// SendOfferRequest sets how long a sent offer stays open
+type SendOfferRequest struct {
+	ValidUntil *time.Time `json:"valid_until"` // defaults to the configured offer validity
+}
+
+// SendOffer sends a draft offer to the patient
+// @Summary Send draft offer
+// @Description Send a pending offer once every plan item is priced or excluded
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Offer ID"
+// @Param request body SendOfferRequest false "Offer validity"
+// @Success 200 {object} models.ClinicOffer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/clinic/offers/{id}/send [post]
+func (h *ClinicHandler) SendOffer(c *gin.Context) {
+	var req SendOfferRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request format",
+			})
+			return
+		}
+	}
+
+	_, offer, ok := h.clinicOffer(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.repo.GetTreatmentPlanByID(offer.TreatmentPlanID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Treatment plan not found",
+		})
+		return
+	}
+
+	if err := offers.Complete(offer, plan); err != nil {
+		offerFailed(c, err)
+		return
+	}
+
+	validUntil, ok := h.offerValidUntil(c, req.ValidUntil)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.SendClinicOffer(offer.ID, validUntil, requestActor(c)); err != nil {
+		offerFailed(c, err)
+		return
+	}
+
+	offer.Status = models.OfferStatusSent
+	offer.ValidUntil = &validUntil
+	c.JSON(http.StatusOK, offer)
+}
+
+// WithdrawOffer withdraws a draft or sent offer
+// @Summary Withdraw offer
+// @Description Withdraw a sent offer; the patient still sees it, marked as withdrawn. A pending draft is discarded.
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Offer ID"
+// @Success 200 {object} models.ClinicOffer
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/clinic/offers/{id} [delete]
+func (h *ClinicHandler) WithdrawOffer(c *gin.Context) {
+	_, offer, ok := h.clinicOffer(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if err := h.repo.WithdrawClinicOffer(offer.ID, now); err != nil {
+		offerFailed(c, err)
+		return
+	}
+
+	offer.Status = models.OfferStatusWithdrawn
+	offer.WithdrawnAt = &now
+	c.JSON(http.StatusOK, offer)
+}
+
+// offerValidUntil resolves the requested validity of an offer, defaulting
+// to the configured validity. It writes the error response when it fails.
+func (h *ClinicHandler) offerValidUntil(c *gin.Context, requested *time.Time) (time.Time, bool) {
+	if requested == nil {
+		return time.Now().Add(h.offerCfg.Validity), true
+	}
+	if !requested.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "valid_until must be in the future",
+		})
+		return time.Time{}, false
+	}
+	return *requested, true
+}
+
+// clinicOffer loads the offer in the id path parameter if it belongs to the
+// authenticated clinic. It writes the error response when it fails.
+func (h *ClinicHandler) clinicOffer(c *gin.Context) (*models.Clinic, *models.ClinicOffer, bool) {
+	userID, _ := c.Get("userID")
+
+	offerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid offer ID",
+		})
+		return nil, nil, false
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return nil, nil, false
+	}
+
+	offer, err := h.repo.GetClinicOfferByID(uint(offerID))
+	if err != nil || !authz.ClinicCanManageOffer(clinic, offer) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Offer not found",
+		})
+		return nil, nil, false
+	}
+
+	return clinic, offer, true
+}
+
+// offerFailed maps offer validation and repository errors to a response
+func offerFailed(c *gin.Context, err error) {
+	var validationErr *offers.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Invalid offer items",
+			"problems": validationErr.Problems,
+		})
+	case errors.Is(err, repository.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Treatment plan not found",
+		})
+	case errors.Is(err, repository.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, repository.ErrOfferDeadlinePassed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Offer deadline has passed",
+		})
+	case errors.Is(err, repository.ErrOfferExpired):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Offer has expired",
+		})
+	case errors.Is(err, repository.ErrOfferExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Clinic already has an open offer for this plan",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save offer",
+		})
+	}
+}
+
+// ClinicDiscountRequest is one discount in the clinic's discount list
+type ClinicDiscountRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Specialization string `json:"specialization"`
+	Percent        int    `json:"percent" binding:"required,min=1,max=99"`
+	MinPlanTotal   int    `json:"min_plan_total" binding:"min=0"`
+	IsActive       *bool  `json:"is_active"` // defaults to true
+}
+
+// GetDiscounts retrieves the clinic's discounts
+// @Summary Get clinic discounts
+// @Description Get discounts applied to auto-quoted offers
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.ClinicDiscount
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/discounts [get]
+func (h *ClinicHandler) GetDiscounts(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	discounts, err := h.repo.GetClinicDiscounts(clinic.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve discounts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, discounts)
+}
+
+// UpdateDiscounts replaces the clinic's discounts
+// @Summary Update clinic discounts
+// @Description Replace the discounts applied to auto-quoted offers. The largest applicable discount wins; discounts do not stack.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body []ClinicDiscountRequest true "Discounts"
+// @Success 200 {array} models.ClinicDiscount
+// @Failure 400 {object} ErrorResponse
+// @Router /api/clinic/discounts [put]
+func (h *ClinicHandler) UpdateDiscounts(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req []ClinicDiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	discounts := make([]models.ClinicDiscount, len(req))
+	for i, d := range req {
+		if d.Specialization != "" && !isSpecialization(d.Specialization) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Unknown specialization: " + d.Specialization,
+			})
+			return
+		}
+		discounts[i] = models.ClinicDiscount{
+			Name:           d.Name,
+			Specialization: d.Specialization,
+			Percent:        d.Percent,
+			MinPlanTotal:   d.MinPlanTotal,
+			IsActive:       d.IsActive == nil || *d.IsActive,
+		}
+	}
+
+	if err := h.repo.ReplaceClinicDiscounts(clinic.ID, discounts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update discounts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, discounts)
+}
+
+func isSpecialization(code string) bool {
+	switch code {
+	case models.SpecTherapy, models.SpecOrthopedics, models.SpecSurgery, models.SpecHygiene, models.SpecPeriodontics:
+		return true
+	}
+	return false
+}
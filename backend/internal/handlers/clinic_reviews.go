@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/moderation"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReviews retrieves the clinic's published reviews
+// @Summary Get clinic reviews
+// @Description Get the clinic's approved reviews with its replies and the patients' attachments, newest first.
+// @Description The total is returned in the X-Total-Count header.
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Reviews per page" default(20)
+// @Success 200 {array} PublicReview
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/reviews [get]
+func (h *ClinicHandler) GetReviews(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	page, ok := bindPage(c)
+	if !ok {
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	reviews, total, err := h.repo.GetPublishedClinicReviews(clinic.ID, page.Offset(), page.PerPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve reviews",
+		})
+		return
+	}
+
+	result := make([]PublicReview, 0, len(reviews))
+	for i := range reviews {
+		review := publicReview(&reviews[i])
+		review.Attachments = reviews[i].Attachments
+		result = append(result, review)
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, result)
+}
+
+// ReviewReplyRequest is the clinic's answer to a review
+type ReviewReplyRequest struct {
+	Text string `json:"text" binding:"required,max=2000"`
+}
+
+// SaveReviewReply replies to a review
+// @Summary Reply to review
+// @Description Publish the clinic's reply to one of its approved reviews, replacing an earlier reply.
+// @Description Replies are shown without moderation, so they must not contain profanity or personal data.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body ReviewReplyRequest true "Reply"
+// @Success 200 {object} models.ReviewReply
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/reviews/{id}/reply [put]
+func (h *ClinicHandler) SaveReviewReply(c *gin.Context) {
+	var req ReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reply text is required",
+		})
+		return
+	}
+	if flags := moderation.Scan(req.Text); len(flags) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reply must not contain: " + strings.Join(flags, ", "),
+		})
+		return
+	}
+
+	clinic, reviewID, ok := h.clinicReviewID(c)
+	if !ok {
+		return
+	}
+
+	reply, err := h.repo.SaveReviewReply(clinic.ID, reviewID, req.Text)
+	if err != nil {
+		reviewFailed(c, err, "Review not found", "Failed to save reply")
+		return
+	}
+
+	c.JSON(http.StatusOK, reply)
+}
+
+// DeleteReviewReply removes a reply
+// @Summary Delete review reply
+// @Description Remove the clinic's reply to a review
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/reviews/{id}/reply [delete]
+func (h *ClinicHandler) DeleteReviewReply(c *gin.Context) {
+	clinic, reviewID, ok := h.clinicReviewID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteReviewReply(clinic.ID, reviewID); err != nil {
+		reviewFailed(c, err, "Reply not found", "Failed to delete reply")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reply deleted successfully",
+	})
+}
+
+// clinicReviewID loads the calling clinic and parses the id path parameter.
+// It writes the error response when it fails.
+func (h *ClinicHandler) clinicReviewID(c *gin.Context) (*models.Clinic, uint, bool) {
+	userID, _ := c.Get("userID")
+
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return nil, 0, false
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return nil, 0, false
+	}
+
+	return clinic, uint(reviewID), true
+}
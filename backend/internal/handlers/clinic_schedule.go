@@ -0,0 +1,494 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/scheduling"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Clinics publish when they can see patients: weekly working hours, the
+// chairs and doctors appointments are booked on, and days they are closed.
+// Patients book free slots generated from this calendar.
+
+// ScheduleResponse is a clinic's booking calendar
+type ScheduleResponse struct {
+	TimeZone string `json:"time_zone"`
+	// Patients cannot cancel or reschedule appointments starting within this many hours
+	CancellationWindowHours int                         `json:"cancellation_window_hours"`
+	Resources               []models.ClinicResource     `json:"resources"`
+	WorkingHours            []models.ClinicWorkingHours `json:"working_hours"`
+	BlockedDates            []models.ClinicBlockedDate  `json:"blocked_dates"` // today and later
+}
+
+// GetSchedule retrieves the clinic's booking calendar
+// @Summary Get clinic schedule
+// @Description Get the clinic's resources, weekly working hours and upcoming blocked dates
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} ScheduleResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/schedule [get]
+func (h *ClinicHandler) GetSchedule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	resources, err := h.repo.GetClinicResources(clinic.ID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve schedule",
+		})
+		return
+	}
+
+	hours, err := h.repo.GetClinicWorkingHours(clinic.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve schedule",
+		})
+		return
+	}
+
+	today := time.Now().In(scheduling.Location(clinic))
+	blocked, err := h.repo.GetClinicBlockedDates(clinic.ID, today, today.AddDate(10, 0, 0))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve schedule",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ScheduleResponse{
+		TimeZone:                clinic.TimeZone,
+		CancellationWindowHours: clinic.CancellationWindowHours,
+		Resources:               resources,
+		WorkingHours:            hours,
+		BlockedDates:            blocked,
+	})
+}
+
+// WorkingHoursRequest replaces the clinic's weekly working hours
+type WorkingHoursRequest struct {
+	TimeZone string                 `json:"time_zone"` // IANA zone, e.g. Europe/Moscow; unchanged if empty
+	Hours    []WorkingHoursInterval `json:"hours" binding:"dive"`
+}
+
+// WorkingHoursInterval is one opening interval on a weekday
+type WorkingHoursInterval struct {
+	ResourceID *uint  `json:"resource_id"`                   // the resource's own hours; omit for clinic hours
+	Weekday    int    `json:"weekday" binding:"min=0,max=6"` // 0 = Sunday
+	OpensAt    string `json:"opens_at" binding:"required"`   // HH:MM
+	ClosesAt   string `json:"closes_at" binding:"required"`  // HH:MM
+}
+
+// UpdateWorkingHours replaces the clinic's weekly working hours
+// @Summary Update working hours
+// @Description Replace the clinic's weekly working hours. Intervals with a resource_id are that resource's
+// @Description own hours; resources without their own hours follow the clinic's.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body WorkingHoursRequest true "Working hours"
+// @Success 200 {array} models.ClinicWorkingHours
+// @Failure 400 {object} ErrorResponse
+// @Router /api/clinic/schedule/working-hours [put]
+func (h *ClinicHandler) UpdateWorkingHours(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req WorkingHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	if req.TimeZone != "" {
+		if _, err := time.LoadLocation(req.TimeZone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Unknown time zone: " + req.TimeZone,
+			})
+			return
+		}
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	resources, err := h.repo.GetClinicResources(clinic.ID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update working hours",
+		})
+		return
+	}
+	owned := make(map[uint]bool, len(resources))
+	for _, r := range resources {
+		owned[r.ID] = true
+	}
+
+	hours := make([]models.ClinicWorkingHours, len(req.Hours))
+	for i, interval := range req.Hours {
+		opens, err := scheduling.ParseClock(interval.OpensAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		closes, err := scheduling.ParseClock(interval.ClosesAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if opens >= closes {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Opening time must be before closing time",
+			})
+			return
+		}
+		if interval.ResourceID != nil && !owned[*interval.ResourceID] {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Resource not found",
+			})
+			return
+		}
+
+		hours[i] = models.ClinicWorkingHours{
+			ResourceID: interval.ResourceID,
+			Weekday:    interval.Weekday,
+			OpensAt:    interval.OpensAt,
+			ClosesAt:   interval.ClosesAt,
+		}
+	}
+
+	if err := h.repo.ReplaceClinicWorkingHours(clinic.ID, req.TimeZone, hours); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update working hours",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+// CancellationWindowRequest sets the clinic's cancellation window
+type CancellationWindowRequest struct {
+	Hours *int `json:"hours" binding:"required,min=0,max=168"`
+}
+
+// UpdateCancellationWindow sets the clinic's cancellation window
+// @Summary Update cancellation window
+// @Description Set how many hours before an appointment patients may still cancel or reschedule it
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CancellationWindowRequest true "Cancellation window"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/clinic/schedule/cancellation-window [put]
+func (h *ClinicHandler) UpdateCancellationWindow(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CancellationWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	if err := h.repo.UpdateClinicCancellationWindow(clinic.ID, *req.Hours); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update cancellation window",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cancellation window updated successfully",
+	})
+}
+
+// ClinicResourceRequest describes a chair or doctor
+type ClinicResourceRequest struct {
+	Kind           string `json:"kind" binding:"required,oneof=chair doctor"`
+	Name           string `json:"name" binding:"required"`
+	Specialization string `json:"specialization"` // empty serves every specialization
+	IsActive       *bool  `json:"is_active"`      // defaults to true
+}
+
+// CreateResource adds a chair or doctor
+// @Summary Create clinic resource
+// @Description Add a chair or doctor that appointments can be booked on
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ClinicResourceRequest true "Resource"
+// @Success 201 {object} models.ClinicResource
+// @Failure 400 {object} ErrorResponse
+// @Router /api/clinic/schedule/resources [post]
+func (h *ClinicHandler) CreateResource(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	resource, ok := bindClinicResource(c)
+	if !ok {
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	resource.ClinicID = clinic.ID
+	if err := h.repo.CreateClinicResource(resource); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create resource",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resource)
+}
+
+// UpdateResource updates a chair or doctor
+// @Summary Update clinic resource
+// @Description Update a chair or doctor. Deactivated resources get no new bookings; existing ones stay.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Resource ID"
+// @Param request body ClinicResourceRequest true "Resource"
+// @Success 200 {object} models.ClinicResource
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/schedule/resources/{id} [put]
+func (h *ClinicHandler) UpdateResource(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid resource ID",
+		})
+		return
+	}
+
+	resource, ok := bindClinicResource(c)
+	if !ok {
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	resource.ID = uint(resourceID)
+	resource.ClinicID = clinic.ID
+	if err := h.repo.UpdateClinicResource(resource); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Resource not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update resource",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resource)
+}
+
+// bindClinicResource parses a resource request. It writes the error
+// response when it fails.
+func bindClinicResource(c *gin.Context) (*models.ClinicResource, bool) {
+	var req ClinicResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return nil, false
+	}
+	if req.Specialization != "" && !isSpecialization(req.Specialization) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown specialization: " + req.Specialization,
+		})
+		return nil, false
+	}
+
+	return &models.ClinicResource{
+		Kind:           req.Kind,
+		Name:           req.Name,
+		Specialization: req.Specialization,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}, true
+}
+
+// BlockedDateRequest closes the clinic or one resource for a day
+type BlockedDateRequest struct {
+	Date       string `json:"date" binding:"required"` // YYYY-MM-DD
+	ResourceID *uint  `json:"resource_id"`             // omit to close the whole clinic
+	Reason     string `json:"reason"`
+}
+
+// CreateBlockedDate blocks a day
+// @Summary Block date
+// @Description Close the whole clinic, or one resource, for a day. Existing appointments are kept.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BlockedDateRequest true "Blocked date"
+// @Success 201 {object} models.ClinicBlockedDate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/schedule/blocked-dates [post]
+func (h *ClinicHandler) CreateBlockedDate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req BlockedDateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	date, err := time.Parse(time.DateOnly, req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Date must be in YYYY-MM-DD format",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	if req.ResourceID != nil {
+		resources, err := h.repo.GetClinicResources(clinic.ID, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to block date",
+			})
+			return
+		}
+		found := false
+		for _, r := range resources {
+			found = found || r.ID == *req.ResourceID
+		}
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Resource not found",
+			})
+			return
+		}
+	}
+
+	blocked := &models.ClinicBlockedDate{
+		ClinicID:   clinic.ID,
+		ResourceID: req.ResourceID,
+		Date:       date,
+		Reason:     req.Reason,
+	}
+	if err := h.repo.CreateClinicBlockedDate(blocked); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to block date",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, blocked)
+}
+
+// DeleteBlockedDate unblocks a day
+// @Summary Unblock date
+// @Description Remove a blocked date
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Blocked date ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/schedule/blocked-dates/{id} [delete]
+func (h *ClinicHandler) DeleteBlockedDate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	blockedID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid blocked date ID",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	if err := h.repo.DeleteClinicBlockedDate(clinic.ID, uint(blockedID)); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Blocked date not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to unblock date",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Date unblocked successfully",
+	})
+}
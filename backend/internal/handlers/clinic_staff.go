@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClinicStaffRequest describes a staff member
+type ClinicStaffRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Position string `json:"position"`
+	Email    string `json:"email" binding:"omitempty,email"`
+	Phone    string `json:"phone"`
+	IsActive *bool  `json:"is_active"` // defaults to true
+}
+
+// GetStaff retrieves the clinic's staff
+// @Summary Get clinic staff
+// @Description Get the clinic's staff members leads and tasks can be assigned to
+// @Tags clinic
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.ClinicStaff
+// @Failure 500 {object} ErrorResponse
+// @Router /api/clinic/staff [get]
+func (h *ClinicHandler) GetStaff(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	staff, err := h.repo.GetClinicStaff(clinic.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve staff",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, staff)
+}
+
+// CreateStaff adds a staff member
+// @Summary Create staff member
+// @Description Add a staff member leads and tasks can be assigned to
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ClinicStaffRequest true "Staff member"
+// @Success 201 {object} models.ClinicStaff
+// @Failure 400 {object} ErrorResponse
+// @Router /api/clinic/staff [post]
+func (h *ClinicHandler) CreateStaff(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req ClinicStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	member := clinicStaff(clinic.ID, &req)
+	if err := h.repo.CreateClinicStaff(member); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create staff member",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateStaff updates a staff member
+// @Summary Update staff member
+// @Description Update a staff member. Deactivated members keep their leads but get no new assignments.
+// @Tags clinic
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Staff member ID"
+// @Param request body ClinicStaffRequest true "Staff member"
+// @Success 200 {object} models.ClinicStaff
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinic/staff/{id} [put]
+func (h *ClinicHandler) UpdateStaff(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	staffID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid staff member ID",
+		})
+		return
+	}
+
+	var req ClinicStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic profile not found",
+		})
+		return
+	}
+
+	member := clinicStaff(clinic.ID, &req)
+	member.ID = uint(staffID)
+	if err := h.repo.UpdateClinicStaff(member); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Staff member not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update staff member",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func clinicStaff(clinicID uint, req *ClinicStaffRequest) *models.ClinicStaff {
+	return &models.ClinicStaff{
+		ClinicID: clinicID,
+		Name:     req.Name,
+		Position: req.Position,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		IsActive: req.IsActive == nil || *req.IsActive,
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"dental-marketplace/backend/internal/repository"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,12 +25,43 @@ type PaginationQuery struct {
 	PerPage int `form:"per_page,default=20"`
 }
 
+// bindPage reads and validates pagination parameters, capping the page size
+// at 100. It writes the error response when it fails.
+func bindPage(c *gin.Context) (PaginationQuery, bool) {
+	var page PaginationQuery
+	if err := c.ShouldBindQuery(&page); err != nil || page.Page < 1 || page.PerPage < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid pagination parameters",
+		})
+		return page, false
+	}
+	if page.PerPage > 100 {
+		page.PerPage = 100
+	}
+	return page, true
+}
+
+// Offset returns the number of items before the page
+func (p PaginationQuery) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
 // DateRangeQuery represents date range parameters
 type DateRangeQuery struct {
 	StartDate string `form:"start_date"`
 	EndDate   string `form:"end_date"`
 }
 
+// requestActor identifies the authenticated user for status history
+func requestActor(c *gin.Context) repository.Actor {
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	actor := repository.Actor{}
+	actor.UserID, _ = userID.(uint)
+	actor.Role, _ = role.(string)
+	return actor
+}
+
 type CommonHandler struct {
 	constantsRepo *repository.ConstantsRepository
 }
@@ -54,6 +86,7 @@ func (h *CommonHandler) GetConstants(c *gin.Context) {
 	priceSegments, _ := h.constantsRepo.GetPriceSegments()
 	cities, _ := h.constantsRepo.GetCities()
 	districts, _ := h.constantsRepo.GetDistricts()
+	procedures, _ := h.constantsRepo.GetProcedures()
 
 	// Convert to maps for easier frontend consumption
 	rolesMap := make(map[string]string)
@@ -125,7 +158,39 @@ func (h *CommonHandler) GetConstants(c *gin.Context) {
 		"price_segments":       priceSegmentsList,
 		"cities":               citiesList,
 		"districts_by_city":    districtsByCity,
+		"procedures":           procedures,
 	}
 
 	c.JSON(http.StatusOK, constants)
 }
+
+// SearchProcedures searches the procedure catalog
+// @Summary Search procedure catalog
+// @Description Find catalog procedures by code, name or synonym to map price list rows
+// @Tags common
+// @Produce json
+// @Param q query string false "Search text"
+// @Param specialization query string false "Specialization code"
+// @Param limit query int false "Maximum results" default(20)
+// @Success 200 {array} models.Procedure
+// @Failure 500 {object} ErrorResponse
+// @Router /api/procedures [get]
+func (h *CommonHandler) SearchProcedures(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	procedures, err := h.constantsRepo.SearchProcedures(c.Query("q"), c.Query("specialization"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to search procedures",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, procedures)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/storage"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileHandler serves objects from local storage through signed URLs
+type FileHandler struct {
+	store *storage.LocalStorage
+}
+
+func NewFileHandler(store *storage.LocalStorage) *FileHandler {
+	return &FileHandler{store: store}
+}
+
+// Download streams a stored file after verifying its URL signature
+// @Summary Download file
+// @Description Download a stored file using a signed URL
+// @Tags files
+// @Produce octet-stream
+// @Param key path string true "Object key"
+// @Param expires query int true "Expiry timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} binary
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/files/{key} [get]
+func (h *FileHandler) Download(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	err := h.store.VerifySignature(key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Invalid or expired download link",
+		})
+		return
+	}
+
+	file, err := h.store.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "File not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", `attachment; filename="`+path.Base(key)+`"`)
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, file)
+}
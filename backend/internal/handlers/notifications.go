@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler serves the authenticated user's in-app notifications.
+// It is mounted under every role's routes.
+type NotificationHandler struct {
+	repo *repository.Repository
+}
+
+func NewNotificationHandler(repo *repository.Repository) *NotificationHandler {
+	return &NotificationHandler{repo: repo}
+}
+
+// GetNotifications retrieves the user's notifications
+// @Summary Get notifications
+// @Description Get the authenticated user's most recent notifications, newest first
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {array} models.Notification
+// @Failure 500 {object} ErrorResponse
+// @Router /api/patient/notifications [get]
+// @Router /api/clinic/notifications [get]
+// @Router /api/regulator/notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	unread, _ := strconv.ParseBool(c.Query("unread"))
+	notifications, err := h.repo.GetUserNotifications(userID.(uint), unread)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkRead marks a notification as read
+// @Summary Mark notification read
+// @Description Mark one of the authenticated user's notifications as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/notifications/{id}/read [post]
+// @Router /api/clinic/notifications/{id}/read [post]
+// @Router /api/regulator/notifications/{id}/read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID",
+		})
+		return
+	}
+
+	if err := h.repo.MarkNotificationRead(userID.(uint), uint(notificationID)); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notification not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update notification",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification marked as read",
+	})
+}
+
+// MarkAllRead marks all notifications as read
+// @Summary Mark all notifications read
+// @Description Mark all of the authenticated user's notifications as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/patient/notifications/read [post]
+// @Router /api/clinic/notifications/read [post]
+// @Router /api/regulator/notifications/read [post]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.repo.MarkAllNotificationsRead(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notifications marked as read",
+	})
+}
@@ -1,20 +1,45 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"dental-marketplace/backend/internal/authz"
+	"dental-marketplace/backend/internal/config"
+	"dental-marketplace/backend/internal/installments"
 	"dental-marketplace/backend/internal/models"
 	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/scans"
+	"dental-marketplace/backend/internal/storage"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PatientHandler struct {
-	repo *repository.Repository
+	repo         *repository.Repository
+	store        storage.Storage
+	ingestor     *scans.Ingestor
+	storageCfg   config.StorageConfig
+	reviewCfg    config.ReviewConfig
+	complaintCfg config.ComplaintConfig
 }
 
-func NewPatientHandler(repo *repository.Repository) *PatientHandler {
-	return &PatientHandler{repo: repo}
+func NewPatientHandler(repo *repository.Repository, store storage.Storage, ingestor *scans.Ingestor, storageCfg config.StorageConfig, reviewCfg config.ReviewConfig, complaintCfg config.ComplaintConfig) *PatientHandler {
+	return &PatientHandler{
+		repo:         repo,
+		store:        store,
+		ingestor:     ingestor,
+		storageCfg:   storageCfg,
+		reviewCfg:    reviewCfg,
+		complaintCfg: complaintCfg,
+	}
 }
 
 // GetScans retrieves all CT scans for the patient
@@ -47,36 +72,169 @@ func (h *PatientHandler) GetScans(c *gin.Context) {
 		return
 	}
 
+	for i := range scans {
+		h.signScanURL(c.Request.Context(), &scans[i])
+	}
+
 	c.JSON(http.StatusOK, scans)
 }
 
-// GetScanByID retrieves a specific CT scan with treatment plan
-// @Summary Get CT scan by ID
-// @Description Get CT scan details with treatment plan
+// UploadScan stores an uploaded CT scan file and registers it
+// @Summary Upload CT scan
+// @Description Upload a CT scan file (multipart field "file")
 // @Tags patient
+// @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Scan ID"
-// @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} ErrorResponse
-// @Router /api/patient/scans/{id} [get]
-func (h *PatientHandler) GetScanByID(c *gin.Context) {
-	scanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+// @Param file formData file true "CT scan file"
+// @Success 201 {object} models.CTScan
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /api/patient/scans [post]
+func (h *PatientHandler) UploadScan(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
 	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.storageCfg.MaxUploadSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "File is too large",
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid scan ID",
+			"error": "File is required",
 		})
 		return
 	}
 
-	scan, err := h.repo.GetCTScanByID(uint(scanID))
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read uploaded file",
+		})
+		return
+	}
+	defer file.Close()
+
+	key, err := storage.NewKey(fmt.Sprintf("scans/%d", patient.ID), fileHeader.Filename)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store scan",
+		})
+		return
+	}
+
+	contentType := fileHeader.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Compute the checksum while streaming to storage
+	hasher := sha256.New()
+	err = h.store.Put(c.Request.Context(), key, io.TeeReader(file, hasher), fileHeader.Size, contentType)
+	if err != nil {
+		log.Printf("failed to store scan for patient %d: %v", patient.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store scan",
+		})
+		return
+	}
+
+	scan := &models.CTScan{
+		PatientID:   patient.ID,
+		UploadDate:  time.Now(),
+		Status:      models.ScanStatusUploaded,
+		StorageKey:  key,
+		FileName:    fileHeader.Filename,
+		FileSize:    fileHeader.Size,
+		ContentType: contentType,
+		Checksum:    hex.EncodeToString(hasher.Sum(nil)),
+	}
+
+	if err := h.repo.CreateCTScan(scan); err != nil {
+		h.store.Delete(c.Request.Context(), key)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create scan",
+		})
+		return
+	}
+
+	// Index DICOM headers; invalid files move the scan to error status
+	if err := h.ingestor.Ingest(c.Request.Context(), scan); err != nil {
+		log.Printf("failed to ingest scan %d: %v", scan.ID, err)
+	}
+
+	h.signScanURL(c.Request.Context(), scan)
+
+	c.JSON(http.StatusCreated, scan)
+}
+
+// DownloadScan returns a short-lived signed URL for a scan file
+// @Summary Get scan download URL
+// @Description Get a short-lived signed download URL for a CT scan
+// @Tags patient
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scan ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/scans/{id}/download [get]
+func (h *PatientHandler) DownloadScan(c *gin.Context) {
+	_, scan, ok := h.patientScan(c)
+	if !ok {
+		return
+	}
+	if scan.StorageKey == "" {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Scan not found",
 		})
 		return
 	}
 
+	url, err := h.store.SignedURL(c.Request.Context(), scan.StorageKey, h.storageCfg.SignedURLTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate download URL",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":        url,
+		"expires_at": time.Now().Add(h.storageCfg.SignedURLTTL),
+	})
+}
+
+// GetScanByID retrieves a specific CT scan with treatment plan
+// @Summary Get CT scan by ID
+// @Description Get CT scan details with treatment plan
+// @Tags patient
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scan ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/scans/{id} [get]
+func (h *PatientHandler) GetScanByID(c *gin.Context) {
+	_, scan, ok := h.patientScan(c)
+	if !ok {
+		return
+	}
+
+	h.signScanURL(c.Request.Context(), scan)
+
 	// Get treatment plan if exists
 	var treatmentPlan *models.TreatmentPlan
 	if scan.AIProcessed {
@@ -100,16 +258,13 @@ func (h *PatientHandler) GetScanByID(c *gin.Context) {
 // @Failure 404 {object} ErrorResponse
 // @Router /api/patient/scans/{id}/plan [get]
 func (h *PatientHandler) GetTreatmentPlan(c *gin.Context) {
-	scanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid scan ID",
-		})
+	patient, scan, ok := h.patientScan(c)
+	if !ok {
 		return
 	}
 
-	plan, err := h.repo.GetTreatmentPlanByScanID(uint(scanID))
-	if err != nil {
+	plan, err := h.repo.GetTreatmentPlanByScanID(scan.ID)
+	if err != nil || !authz.PatientCanViewPlan(patient, plan) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Treatment plan not found",
 		})
@@ -172,7 +327,8 @@ func (h *PatientHandler) UpdateSearchCriteria(c *gin.Context) {
 
 // GetOffers retrieves clinic offers for a treatment plan
 // @Summary Get clinic offers
-// @Description Get all clinic offers for a treatment plan
+// @Description Get all clinic offers for a treatment plan, including expired and withdrawn ones and prior versions of revised offers.
+// @Description Open offers list their installment options; an accepted offer carries the chosen payment schedule.
 // @Tags patient
 // @Produce json
 // @Security BearerAuth
@@ -181,15 +337,12 @@ func (h *PatientHandler) UpdateSearchCriteria(c *gin.Context) {
 // @Failure 404 {object} ErrorResponse
 // @Router /api/patient/plans/{plan_id}/offers [get]
 func (h *PatientHandler) GetOffers(c *gin.Context) {
-	planID, err := strconv.ParseUint(c.Param("plan_id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid plan ID",
-		})
+	_, plan, ok := h.patientPlan(c)
+	if !ok {
 		return
 	}
 
-	offers, err := h.repo.GetOffersForTreatmentPlan(uint(planID))
+	offers, err := h.repo.GetOffersForTreatmentPlan(plan.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve offers",
@@ -197,16 +350,235 @@ func (h *PatientHandler) GetOffers(c *gin.Context) {
 		return
 	}
 
+	if err := h.attachInstallmentOptions(offers); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve installment options",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, offers)
 }
 
+// attachInstallmentOptions fills in the installment schedules the patient
+// can choose from on each open offer, as if accepted now
+func (h *PatientHandler) attachInstallmentOptions(offers []models.ClinicOffer) error {
+	var clinicIDs []uint
+	for _, offer := range offers {
+		if offer.Status == models.OfferStatusSent {
+			clinicIDs = append(clinicIDs, offer.ClinicID)
+		}
+	}
+
+	products, err := h.repo.GetActiveInstallmentProducts(clinicIDs)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range offers {
+		if offers[i].Status == models.OfferStatusSent {
+			offers[i].InstallmentOptions = installments.Options(&offers[i], products, now)
+		}
+	}
+	return nil
+}
+
+// RequestOffersRequest sets an optional deadline for clinic offers
+type RequestOffersRequest struct {
+	OfferDeadline *time.Time `json:"offer_deadline"`
+}
+
+// RequestOffers publishes a treatment plan to matching clinics
+// @Summary Request clinic offers
+// @Description Publish a generated treatment plan to clinics matching the patient's search criteria.
+// @Description City, district and price segment are snapshotted on the plan.
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path int true "Treatment Plan ID"
+// @Param request body RequestOffersRequest false "Offer deadline"
+// @Success 200 {object} models.TreatmentPlan
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/patient/plans/{plan_id}/request-offers [post]
+func (h *PatientHandler) RequestOffers(c *gin.Context) {
+	var req RequestOffersRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request format",
+			})
+			return
+		}
+	}
+	if req.OfferDeadline != nil && !req.OfferDeadline.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Offer deadline must be in the future",
+		})
+		return
+	}
+
+	patient, plan, ok := h.patientPlan(c)
+	if !ok {
+		return
+	}
+
+	if patient.City == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Set search criteria before requesting offers",
+		})
+		return
+	}
+
+	targeting := repository.PlanTargeting{
+		City:         patient.City,
+		District:     patient.District,
+		PriceSegment: patient.PriceSegment,
+	}
+	plan, err := h.repo.RequestTreatmentPlanOffers(plan.ID, targeting, req.OfferDeadline, requestActor(c))
+	if err != nil {
+		h.planTransitionFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// CancelPlanRequest carries the optional reason for cancelling a plan
+type CancelPlanRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelTreatmentPlan cancels a treatment plan
+// @Summary Cancel treatment plan
+// @Description Cancel a treatment plan that is not yet completed. Its open offers are withdrawn,
+// @Description upcoming appointments cancelled and the clinic's lead rejected.
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path int true "Treatment Plan ID"
+// @Param request body CancelPlanRequest false "Cancellation reason"
+// @Success 200 {object} models.TreatmentPlan
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/patient/plans/{plan_id}/cancel [post]
+func (h *PatientHandler) CancelTreatmentPlan(c *gin.Context) {
+	var req CancelPlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request format",
+			})
+			return
+		}
+	}
+
+	_, plan, ok := h.patientPlan(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.repo.CancelTreatmentPlan(plan.ID, requestActor(c), req.Reason)
+	if err != nil {
+		h.planTransitionFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// patientPlan loads the plan in the plan_id path parameter if it belongs to
+// the authenticated patient. It writes the error response when it fails.
+func (h *PatientHandler) patientPlan(c *gin.Context) (*models.Patient, *models.TreatmentPlan, bool) {
+	userID, _ := c.Get("userID")
+
+	planID, err := strconv.ParseUint(c.Param("plan_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid plan ID",
+		})
+		return nil, nil, false
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return nil, nil, false
+	}
+
+	plan, err := h.repo.GetTreatmentPlanForPatient(uint(planID))
+	if err != nil || !authz.PatientCanViewPlan(patient, plan) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Treatment plan not found",
+		})
+		return nil, nil, false
+	}
+
+	return patient, plan, true
+}
+
+// patientScan loads the scan in the id path parameter if it belongs to the
+// authenticated patient. It writes the error response when it fails.
+func (h *PatientHandler) patientScan(c *gin.Context) (*models.Patient, *models.CTScan, bool) {
+	userID, _ := c.Get("userID")
+
+	scanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid scan ID",
+		})
+		return nil, nil, false
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return nil, nil, false
+	}
+
+	scan, err := h.repo.GetCTScanByID(uint(scanID))
+	if err != nil || !authz.PatientCanViewScan(patient, scan) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Scan not found",
+		})
+		return nil, nil, false
+	}
+
+	return patient, scan, true
+}
+
+// planTransitionFailed maps a failed plan transition to a response
+func (h *PatientHandler) planTransitionFailed(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "Failed to update treatment plan",
+	})
+}
+
 // SelectOffer accepts a clinic offer
 type SelectOfferRequest struct {
-	OfferID uint `json:"offer_id" binding:"required"`
+	OfferID              uint  `json:"offer_id" binding:"required"`
+	OfferVersion         *int  `json:"offer_version"`          // version of the offer the patient reviewed; omit to accept the current one
+	InstallmentProductID *uint `json:"installment_product_id"` // pay in installments; omit to pay in full
 }
 
 // @Summary Select clinic offer
-// @Description Accept a clinic offer and create appointment
+// @Description Accept a clinic offer. Appointments are then booked from the clinic's free slots.
+// @Description Choosing one of the offer's installment options stores its payment schedule with the offer.
+// @Description When offer_version is sent it must match the offer's current version, otherwise the offer was revised and 409 is returned.
 // @Tags patient
 // @Accept json
 // @Produce json
@@ -214,6 +586,8 @@ type SelectOfferRequest struct {
 // @Param request body SelectOfferRequest true "Offer selection"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /api/patient/select-offer [post]
 func (h *PatientHandler) SelectOffer(c *gin.Context) {
 	userID, _ := c.Get("userID")
@@ -235,17 +609,66 @@ func (h *PatientHandler) SelectOffer(c *gin.Context) {
 		return
 	}
 
-	// Accept offer and create appointment
-	err = h.repo.AcceptClinicOffer(req.OfferID, patient.ID)
+	// Only offers made on the patient's own plans can be accepted
+	offer, err := h.repo.GetClinicOfferByID(req.OfferID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to accept offer",
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Offer not found",
+		})
+		return
+	}
+	plan, err := h.repo.GetTreatmentPlanForPatient(offer.TreatmentPlanID)
+	if err != nil || !authz.PatientCanViewOffer(patient, offer, plan) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Offer not found",
 		})
 		return
 	}
 
+	var schedule *models.PaymentSchedule
+	if req.InstallmentProductID != nil {
+		product, err := h.repo.GetInstallmentProductByID(*req.InstallmentProductID)
+		if err != nil || !installments.Available(offer, product) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Installment option is not available for this offer",
+			})
+			return
+		}
+		built := installments.Build(offer, product, time.Now())
+		schedule = &built
+	}
+
+	// Accept offer
+	err = h.repo.AcceptClinicOffer(offer.ID, req.OfferVersion, patient.ID, schedule, requestActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Offer not found",
+			})
+		case errors.Is(err, repository.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Offer can no longer be accepted",
+			})
+		case errors.Is(err, repository.ErrOfferExpired):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Offer has expired",
+			})
+		case errors.Is(err, repository.ErrOfferChanged):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Offer was revised, please review it again",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to accept offer",
+			})
+		}
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Offer accepted successfully. Appointment created.",
+		"message":          "Offer accepted successfully. Book an appointment from the clinic's free slots.",
+		"payment_schedule": schedule,
 	})
 }
 
@@ -280,27 +703,28 @@ func (h *PatientHandler) GetAppointments(c *gin.Context) {
 	c.JSON(http.StatusOK, appointments)
 }
 
-// CreateReview creates a review for a clinic
-type CreateReviewRequest struct {
-	ClinicID uint   `json:"clinic_id" binding:"required"`
-	Rating   int    `json:"rating" binding:"required,min=1,max=5"`
-	Comment  string `json:"comment"`
+// CreateComplaint creates a complaint
+type CreateComplaintRequest struct {
+	ClinicID    uint   `json:"clinic_id" binding:"required"`
+	Subject     string `json:"subject" binding:"required"`
+	Description string `json:"description" binding:"required"`
 }
 
-// @Summary Create review
-// @Description Create a review for a clinic
+// @Summary Create complaint
+// @Description Create a complaint about a clinic. Regulators aim to resolve it by the returned due date.
 // @Tags patient
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body CreateReviewRequest true "Review details"
-// @Success 201 {object} SuccessResponse
+// @Param request body CreateComplaintRequest true "Complaint details"
+// @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} ErrorResponse
-// @Router /api/patient/reviews [post]
-func (h *PatientHandler) CreateReview(c *gin.Context) {
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/complaints [post]
+func (h *PatientHandler) CreateComplaint(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
-	var req CreateReviewRequest
+	var req CreateComplaintRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid request format",
@@ -316,55 +740,49 @@ func (h *PatientHandler) CreateReview(c *gin.Context) {
 		return
 	}
 
-	review := &models.Review{
-		PatientID: patient.ID,
-		ClinicID:  req.ClinicID,
-		Rating:    req.Rating,
-		Comment:   req.Comment,
-		IsPublic:  false, // Reviews are not public by default per requirements
+	dueAt := time.Now().Add(h.complaintCfg.ResolutionSLA)
+	complaint := &models.Complaint{
+		PatientID:   patient.ID,
+		ClinicID:    req.ClinicID,
+		Subject:     req.Subject,
+		Description: req.Description,
+		Status:      models.ComplaintStatusOpen,
+		DueAt:       &dueAt,
 	}
 
-	err = h.repo.CreateReview(review)
+	err = h.repo.CreateComplaint(complaint, requestActor(c))
 	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Clinic not found",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create review",
+			"error": "Failed to create complaint",
 		})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Review submitted successfully",
+		"message":      "Complaint submitted successfully",
+		"complaint_id": complaint.ID,
+		"due_at":       complaint.DueAt,
 	})
 }
 
-// CreateComplaint creates a complaint
-type CreateComplaintRequest struct {
-	ClinicID    uint   `json:"clinic_id" binding:"required"`
-	Subject     string `json:"subject" binding:"required"`
-	Description string `json:"description" binding:"required"`
-}
-
-// @Summary Create complaint
-// @Description Create a complaint about a clinic
+// GetComplaints retrieves the patient's complaints
+// @Summary Get complaints
+// @Description Get the complaints the authenticated patient has filed, with their status and attachments
 // @Tags patient
-// @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body CreateComplaintRequest true "Complaint details"
-// @Success 201 {object} SuccessResponse
-// @Failure 400 {object} ErrorResponse
-// @Router /api/patient/complaints [post]
-func (h *PatientHandler) CreateComplaint(c *gin.Context) {
+// @Success 200 {array} models.Complaint
+// @Failure 500 {object} ErrorResponse
+// @Router /api/patient/complaints [get]
+func (h *PatientHandler) GetComplaints(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
-	var req CreateComplaintRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
-		return
-	}
-
 	patient, err := h.repo.GetPatientByUserID(userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -373,25 +791,15 @@ func (h *PatientHandler) CreateComplaint(c *gin.Context) {
 		return
 	}
 
-	complaint := &models.Complaint{
-		PatientID:   patient.ID,
-		ClinicID:    req.ClinicID,
-		Subject:     req.Subject,
-		Description: req.Description,
-		Status:      "open",
-	}
-
-	err = h.repo.CreateComplaint(complaint)
+	complaints, err := h.repo.GetPatientComplaints(patient.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create complaint",
+			"error": "Failed to retrieve complaints",
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Complaint submitted successfully",
-	})
+	c.JSON(http.StatusOK, complaints)
 }
 
 // GetTreatmentPlans retrieves all treatment plans for patient
@@ -424,3 +832,17 @@ func (h *PatientHandler) GetTreatmentPlans(c *gin.Context) {
 
 	c.JSON(http.StatusOK, plans)
 }
+
+// signScanURL fills the scan's short-lived download URL
+func (h *PatientHandler) signScanURL(ctx context.Context, scan *models.CTScan) {
+	if scan.StorageKey == "" {
+		return
+	}
+
+	url, err := h.store.SignedURL(ctx, scan.StorageKey, h.storageCfg.SignedURLTTL)
+	if err != nil {
+		log.Printf("failed to sign download URL for scan %d: %v", scan.ID, err)
+		return
+	}
+	scan.DownloadURL = url
+}
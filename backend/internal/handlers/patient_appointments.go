@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/authz"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/scheduling"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlotsQuery selects the free slots to list
+type SlotsQuery struct {
+	Specialization string     `form:"specialization" binding:"required"`
+	From           *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"` // defaults to now
+	To             *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`   // defaults to a week after from
+	Duration       int        `form:"duration" binding:"omitempty,min=15,max=240"`  // minutes, defaults to 60
+}
+
+// GetClinicSlots lists a clinic's free appointment slots
+// @Summary Get free slots
+// @Description List a clinic's free appointment slots for a specialization, generated from its working hours,
+// @Description resources, blocked dates and existing bookings. At most 31 days are listed at once.
+// @Tags patient
+// @Produce json
+// @Security BearerAuth
+// @Param clinic_id path int true "Clinic ID"
+// @Param specialization query string true "Specialization"
+// @Param from query string false "Start of range, RFC 3339"
+// @Param to query string false "End of range, RFC 3339"
+// @Param duration query int false "Slot length in minutes" default(60)
+// @Success 200 {array} scheduling.Slot
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/clinics/{clinic_id}/slots [get]
+func (h *PatientHandler) GetClinicSlots(c *gin.Context) {
+	clinicID, err := strconv.ParseUint(c.Param("clinic_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clinic ID",
+		})
+		return
+	}
+
+	var query SlotsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+
+	now := time.Now()
+	from := now
+	if query.From != nil && query.From.After(now) {
+		from = *query.From
+	}
+	to := from.Add(7 * 24 * time.Hour)
+	if query.To != nil {
+		to = *query.To
+	}
+	if !to.After(from) || to.Sub(from) > scheduling.MaxRange {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Range must be positive and at most 31 days",
+		})
+		return
+	}
+	duration := 60 * time.Minute
+	if query.Duration > 0 {
+		duration = time.Duration(query.Duration) * time.Minute
+	}
+
+	clinic, err := h.repo.GetClinicByID(uint(clinicID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic not found",
+		})
+		return
+	}
+
+	calendar, err := h.loadCalendar(clinic, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve slots",
+		})
+		return
+	}
+
+	slots := calendar.FreeSlots(query.Specialization, from, to, duration, now)
+	if slots == nil {
+		slots = []scheduling.Slot{}
+	}
+	c.JSON(http.StatusOK, slots)
+}
+
+// BookAppointmentRequest books a free slot for an accepted offer
+type BookAppointmentRequest struct {
+	OfferID         uint      `json:"offer_id" binding:"required"`
+	ResourceID      uint      `json:"resource_id" binding:"required"`
+	Specialization  string    `json:"specialization" binding:"required"`
+	StartsAt        time.Time `json:"starts_at" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"omitempty,min=15,max=240"` // defaults to 60
+}
+
+// BookAppointment books an appointment
+// @Summary Book appointment
+// @Description Book a free slot at the clinic of an accepted offer. The slot must be one listed by
+// @Description the clinic's slots endpoint for a specialization the offer covers.
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BookAppointmentRequest true "Booking"
+// @Success 201 {object} models.Appointment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/patient/appointments [post]
+func (h *PatientHandler) BookAppointment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req BookAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	duration := 60
+	if req.DurationMinutes > 0 {
+		duration = req.DurationMinutes
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return
+	}
+
+	offer, err := h.repo.GetClinicOfferByID(req.OfferID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Offer not found",
+		})
+		return
+	}
+	plan, err := h.repo.GetTreatmentPlanForPatient(offer.TreatmentPlanID)
+	if err != nil || !authz.PatientCanViewOffer(patient, offer, plan) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Offer not found",
+		})
+		return
+	}
+	if offer.Status != models.OfferStatusAccepted {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Appointments can only be booked for an accepted offer",
+		})
+		return
+	}
+	if !offerCovers(offer, req.Specialization) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Offer does not cover specialization: " + req.Specialization,
+		})
+		return
+	}
+
+	clinic, err := h.repo.GetClinicByID(offer.ClinicID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic not found",
+		})
+		return
+	}
+
+	end := req.StartsAt.Add(time.Duration(duration) * time.Minute)
+	calendar, err := h.loadCalendar(clinic, req.StartsAt, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to book appointment",
+		})
+		return
+	}
+	if err := calendar.CheckSlot(req.Specialization, req.ResourceID, req.StartsAt, time.Duration(duration)*time.Minute, time.Now()); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Slot is not available",
+		})
+		return
+	}
+
+	resourceID := req.ResourceID
+	appointment := &models.Appointment{
+		PatientID:       patient.ID,
+		ClinicID:        clinic.ID,
+		TreatmentPlanID: plan.ID,
+		ClinicOfferID:   offer.ID,
+		ResourceID:      &resourceID,
+		AppointmentDate: req.StartsAt,
+		DurationMinutes: duration,
+		Specialization:  req.Specialization,
+		Status:          models.AppointmentStatusScheduled,
+	}
+	if err := h.repo.BookAppointment(appointment); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSlotTaken):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Slot is not available",
+			})
+		case errors.Is(err, repository.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Resource not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to book appointment",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, appointment)
+}
+
+// loadCalendar loads a clinic's active resources, working hours, blocked
+// dates and live bookings around from..to. Blocked dates are fetched a day
+// either side so local days at the edges of the range are covered whatever
+// the clinic's time zone.
+func (h *PatientHandler) loadCalendar(clinic *models.Clinic, from, to time.Time) (*scheduling.Calendar, error) {
+	resources, err := h.repo.GetClinicResources(clinic.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	hours, err := h.repo.GetClinicWorkingHours(clinic.ID)
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := h.repo.GetClinicBlockedDates(clinic.ID, from.AddDate(0, 0, -1), to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	booked, err := h.repo.GetBookedAppointments(clinic.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduling.Calendar{
+		Clinic:    clinic,
+		Resources: resources,
+		Hours:     hours,
+		Blocked:   blocked,
+		Booked:    booked,
+	}, nil
+}
+
+// offerCovers reports whether an offer prices at least one item of a
+// specialization
+func offerCovers(offer *models.ClinicOffer, specialization string) bool {
+	for _, item := range offer.Items {
+		if !item.Excluded && item.Specialization == specialization {
+			return true
+		}
+	}
+	return false
+}
+
+// RescheduleAppointmentRequest moves an appointment to another free slot
+type RescheduleAppointmentRequest struct {
+	ResourceID      uint      `json:"resource_id" binding:"required"`
+	StartsAt        time.Time `json:"starts_at" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"omitempty,min=15,max=240"` // defaults to the current length
+	Reason          string    `json:"reason"`
+}
+
+// RescheduleAppointment moves an appointment to another slot
+// @Summary Reschedule appointment
+// @Description Move an upcoming appointment to another free slot at the same clinic. Not allowed within
+// @Description the clinic's cancellation window before the appointment. The change is kept in its history.
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Appointment ID"
+// @Param request body RescheduleAppointmentRequest true "New slot"
+// @Success 200 {object} models.Appointment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/patient/appointments/{id}/reschedule [post]
+func (h *PatientHandler) RescheduleAppointment(c *gin.Context) {
+	var req RescheduleAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	patient, appointment, ok := h.patientAppointment(c)
+	if !ok {
+		return
+	}
+	duration := appointment.DurationMinutes
+	if req.DurationMinutes > 0 {
+		duration = req.DurationMinutes
+	}
+
+	clinic, err := h.repo.GetClinicByID(appointment.ClinicID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clinic not found",
+		})
+		return
+	}
+
+	end := req.StartsAt.Add(time.Duration(duration) * time.Minute)
+	calendar, err := h.loadCalendar(clinic, req.StartsAt, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reschedule appointment",
+		})
+		return
+	}
+	// The appointment's own slot does not block moving it
+	booked := calendar.Booked[:0]
+	for _, a := range calendar.Booked {
+		if a.ID != appointment.ID {
+			booked = append(booked, a)
+		}
+	}
+	calendar.Booked = booked
+
+	if err := calendar.CheckSlot(appointment.Specialization, req.ResourceID, req.StartsAt, time.Duration(duration)*time.Minute, time.Now()); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Slot is not available",
+		})
+		return
+	}
+
+	updated, err := h.repo.RescheduleAppointment(appointment.ID, patient.ID, req.ResourceID, req.StartsAt, duration, requestActor(c), req.Reason)
+	if err != nil {
+		appointmentChangeFailed(c, err, "Failed to reschedule appointment")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// CancelAppointmentRequest cancels an appointment
+type CancelAppointmentRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// CancelAppointment cancels an appointment
+// @Summary Cancel appointment
+// @Description Cancel an upcoming appointment with a reason. Not allowed within the clinic's cancellation
+// @Description window before the appointment. The change is kept in its history.
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Appointment ID"
+// @Param request body CancelAppointmentRequest true "Cancellation"
+// @Success 200 {object} models.Appointment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/patient/appointments/{id}/cancel [post]
+func (h *PatientHandler) CancelAppointment(c *gin.Context) {
+	var req CancelAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	patient, appointment, ok := h.patientAppointment(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.CancelAppointment(appointment.ID, patient.ID, requestActor(c), req.Reason)
+	if err != nil {
+		appointmentChangeFailed(c, err, "Failed to cancel appointment")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// patientAppointment loads the appointment in the id path parameter if it
+// belongs to the authenticated patient. It writes the error response when
+// it fails.
+func (h *PatientHandler) patientAppointment(c *gin.Context) (*models.Patient, *models.Appointment, bool) {
+	userID, _ := c.Get("userID")
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid appointment ID",
+		})
+		return nil, nil, false
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return nil, nil, false
+	}
+
+	appointment, err := h.repo.GetAppointmentByID(uint(appointmentID))
+	if err != nil || !authz.PatientCanViewAppointment(patient, appointment) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Appointment not found",
+		})
+		return nil, nil, false
+	}
+
+	return patient, appointment, true
+}
+
+// appointmentChangeFailed maps a failed reschedule or cancellation to a
+// response
+func appointmentChangeFailed(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Appointment not found",
+		})
+	case errors.Is(err, repository.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Appointment can no longer be changed",
+		})
+	case errors.Is(err, repository.ErrCancellationWindow):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, repository.ErrSlotTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Slot is not available",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message,
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/matching"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/offers"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompareWeightsQuery adjusts the weight of each comparison criterion.
+// Omitted weights keep their default; weights are normalized to sum to 1.
+type CompareWeightsQuery struct {
+	Price       *float64 `form:"price_weight"`
+	Rating      *float64 `form:"rating_weight"`
+	Warranty    *float64 `form:"warranty_weight"`
+	Installment *float64 `form:"installment_weight"`
+	Distance    *float64 `form:"distance_weight"`
+	Wait        *float64 `form:"wait_weight"`
+}
+
+// weights applies the query on top of the default weights
+func (q CompareWeightsQuery) weights() (offers.Weights, bool) {
+	w := offers.DefaultWeights
+	for _, p := range []struct {
+		value  *float64
+		target *float64
+	}{
+		{q.Price, &w.Price},
+		{q.Rating, &w.Rating},
+		{q.Warranty, &w.Warranty},
+		{q.Installment, &w.Installment},
+		{q.Distance, &w.Distance},
+		{q.Wait, &w.Wait},
+	} {
+		if p.value == nil {
+			continue
+		}
+		if *p.value < 0 || math.IsNaN(*p.value) || math.IsInf(*p.value, 0) {
+			return offers.Weights{}, false
+		}
+		*p.target = *p.value
+	}
+
+	return w, w.Normalized() != offers.Weights{}
+}
+
+// CompareOffers compares the open offers on a treatment plan
+// @Summary Compare clinic offers
+// @Description Compare the open offers on a treatment plan side by side, per specialization and per item.
+// @Description Each offer gets a weighted score combining price, clinic rating, warranty, installment
+// @Description availability, distance to the patient's district and wait time. Weights are optional,
+// @Description must be finite and not negative, and are normalized to sum to 1.
+// @Description Prices are compared over the whole plan: an item an offer excludes counts at the highest
+// @Description price another offer quoted for it, and each offer reports how many plan items it covers.
+// @Tags patient
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path int true "Treatment Plan ID"
+// @Param price_weight query number false "Price weight" default(0.35)
+// @Param rating_weight query number false "Clinic rating weight" default(0.2)
+// @Param warranty_weight query number false "Warranty weight" default(0.15)
+// @Param installment_weight query number false "Installment weight" default(0.1)
+// @Param distance_weight query number false "Distance weight" default(0.1)
+// @Param wait_weight query number false "Wait time weight" default(0.1)
+// @Success 200 {object} offers.Comparison
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/plans/{plan_id}/offers/compare [get]
+func (h *PatientHandler) CompareOffers(c *gin.Context) {
+	var query CompareWeightsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid weights",
+		})
+		return
+	}
+	weights, ok := query.weights()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Weights must be finite numbers, not negative, and at least one must be positive",
+		})
+		return
+	}
+
+	_, plan, ok := h.patientPlan(c)
+	if !ok {
+		return
+	}
+
+	planOffers, err := h.repo.GetOffersForTreatmentPlan(plan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve offers",
+		})
+		return
+	}
+
+	// Only offers the patient can still accept are compared
+	open := make([]models.ClinicOffer, 0, len(planOffers))
+	for _, offer := range planOffers {
+		if offer.Status == models.OfferStatusSent {
+			open = append(open, offer)
+		}
+	}
+
+	districts, err := h.repo.GetDistrictLocations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve offers",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, offers.Compare(plan, open, weights, matching.NewLocations(districts)))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/offers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompareOffersRejectsInvalidWeights(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Weights are validated before the plan is loaded, so no repository is needed
+	router := gin.New()
+	router.GET("/api/patient/plans/:plan_id/offers/compare", (&PatientHandler{}).CompareOffers)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"not a number", "price_weight=cheap"},
+		{"negative", "price_weight=-0.1"},
+		{"NaN", "price_weight=NaN"},
+		{"infinite", "rating_weight=Inf"},
+		{"negative infinity", "wait_weight=-Inf"},
+		{"all zero", "price_weight=0&rating_weight=0&warranty_weight=0&installment_weight=0&distance_weight=0&wait_weight=0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/patient/plans/1/offers/compare?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestCompareWeightsQuery(t *testing.T) {
+	zero, half := 0.0, 0.5
+
+	w, ok := CompareWeightsQuery{}.weights()
+	if !ok || w != offers.DefaultWeights {
+		t.Errorf("no query = %+v, %v; want the default weights", w, ok)
+	}
+
+	w, ok = CompareWeightsQuery{Price: &half, Wait: &zero}.weights()
+	want := offers.DefaultWeights
+	want.Price, want.Wait = 0.5, 0
+	if !ok || w != want {
+		t.Errorf("partial query = %+v, %v; want %+v", w, ok, want)
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateReviewRequest reviews a completed appointment
+type CreateReviewRequest struct {
+	AppointmentID uint   `json:"appointment_id" binding:"required"`
+	Rating        int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment       string `json:"comment"`
+}
+
+// CreateReview creates a review for a clinic
+// @Summary Create review
+// @Description Review the clinic of one of the patient's completed appointments. Each appointment can be
+// @Description reviewed once; the review carries a verified visit badge. Reviews are published once a
+// @Description regulator approves them.
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateReviewRequest true "Review details"
+// @Success 201 {object} models.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/patient/reviews [post]
+func (h *PatientHandler) CreateReview(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return
+	}
+
+	appointmentID := req.AppointmentID
+	review := &models.Review{
+		PatientID:     patient.ID,
+		AppointmentID: &appointmentID,
+		Rating:        req.Rating,
+		Comment:       req.Comment,
+	}
+
+	if err := h.repo.CreateReview(review); err != nil {
+		reviewFailed(c, err, "Appointment not found", "Failed to create review")
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// UpdateReviewRequest edits a review
+type UpdateReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+// UpdateReview edits a review
+// @Summary Update review
+// @Description Edit the rating and comment of a review while its edit window is open. The edited review
+// @Description is unpublished until a regulator approves it again.
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body UpdateReviewRequest true "Review details"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/patient/reviews/{id} [put]
+func (h *PatientHandler) UpdateReview(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return
+	}
+
+	var req UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return
+	}
+
+	review, err := h.repo.UpdateReview(uint(reviewID), patient.ID, req.Rating, req.Comment, h.reviewCfg.EditWindow)
+	if err != nil {
+		reviewFailed(c, err, "Review not found", "Failed to update review")
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// GetReviews retrieves the patient's reviews
+// @Summary Get reviews
+// @Description Get the reviews the authenticated patient has written
+// @Tags patient
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Review
+// @Failure 500 {object} ErrorResponse
+// @Router /api/patient/reviews [get]
+func (h *PatientHandler) GetReviews(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return
+	}
+
+	reviews, err := h.repo.GetPatientReviews(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve reviews",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// reviewFailed maps a failed review operation to a response
+func reviewFailed(c *gin.Context, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": notFound,
+		})
+	case errors.Is(err, repository.ErrReviewNotAllowed),
+		errors.Is(err, repository.ErrReviewExists),
+		errors.Is(err, repository.ErrReviewEditWindow):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message,
+		})
+	}
+}
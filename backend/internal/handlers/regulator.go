@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"dental-marketplace/backend/internal/config"
 	"dental-marketplace/backend/internal/repository"
 	"net/http"
 	"strconv"
@@ -10,11 +11,12 @@ import (
 )
 
 type RegulatorHandler struct {
-	repo *repository.Repository
+	repo         *repository.Repository
+	complaintCfg config.ComplaintConfig
 }
 
-func NewRegulatorHandler(repo *repository.Repository) *RegulatorHandler {
-	return &RegulatorHandler{repo: repo}
+func NewRegulatorHandler(repo *repository.Repository, complaintCfg config.ComplaintConfig) *RegulatorHandler {
+	return &RegulatorHandler{repo: repo, complaintCfg: complaintCfg}
 }
 
 // GetDashboard retrieves regional overview dashboard
@@ -209,30 +211,6 @@ func (h *RegulatorHandler) GetClinics(c *gin.Context) {
 	c.JSON(http.StatusOK, clinics)
 }
 
-// GetComplaints retrieves all complaints
-// @Summary Get complaints
-// @Description Get all complaints with optional status filter
-// @Tags regulator
-// @Produce json
-// @Security BearerAuth
-// @Param status query string false "Filter by status"
-// @Success 200 {array} models.Complaint
-// @Failure 500 {object} ErrorResponse
-// @Router /api/regulator/complaints [get]
-func (h *RegulatorHandler) GetComplaints(c *gin.Context) {
-	status := c.Query("status")
-
-	complaints, err := h.repo.GetAllComplaints(status)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve complaints",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, complaints)
-}
-
 // GetClinicDetails retrieves detailed information about a specific clinic
 // @Summary Get clinic details
 // @Description Get detailed information and statistics for a specific clinic
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Complaints are worked as cases: a regulator takes the case, may ask the
+// clinic for its side, and resolves it before the SLA deadline. Every step
+// lands on the complaint's timeline.
+
+// ComplaintsQuery filters the complaint list
+type ComplaintsQuery struct {
+	Status         string `form:"status"`
+	ClinicID       *uint  `form:"clinic_id"`
+	AssignedUserID *uint  `form:"assigned_user_id"` // 0 for unassigned
+	Overdue        bool   `form:"overdue"`
+}
+
+// GetComplaints retrieves all complaints
+// @Summary Get complaints
+// @Description Get complaints with SLA overdue flags, optionally filtered
+// @Tags regulator
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status"
+// @Param clinic_id query int false "Filter by clinic"
+// @Param assigned_user_id query int false "Filter by assignee; 0 for unassigned"
+// @Param overdue query bool false "Only complaints past a resolution or clinic response deadline"
+// @Success 200 {array} models.Complaint
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/regulator/complaints [get]
+func (h *RegulatorHandler) GetComplaints(c *gin.Context) {
+	var query ComplaintsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Status != "" && !repository.IsComplaintStatus(query.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown complaint status: " + query.Status,
+		})
+		return
+	}
+
+	filter := repository.ComplaintFilter{
+		Status:         query.Status,
+		ClinicID:       query.ClinicID,
+		AssignedUserID: query.AssignedUserID,
+		Overdue:        query.Overdue,
+	}
+	complaints, err := h.repo.GetAllComplaints(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve complaints",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, complaints)
+}
+
+// GetComplaint retrieves one complaint
+// @Summary Get complaint
+// @Description Get a complaint with its full activity timeline, including internal notes
+// @Tags regulator
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Complaint ID"
+// @Success 200 {object} models.Complaint
+// @Failure 404 {object} ErrorResponse
+// @Router /api/regulator/complaints/{id} [get]
+func (h *RegulatorHandler) GetComplaint(c *gin.Context) {
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+
+	complaint, err := h.repo.GetComplaint(complaintID)
+	if err != nil {
+		complaintFailed(c, err, "Failed to retrieve complaint")
+		return
+	}
+
+	c.JSON(http.StatusOK, complaint)
+}
+
+// UpdateComplaintRequest moves a complaint through its lifecycle or reassigns it
+type UpdateComplaintRequest struct {
+	Status         string `json:"status"`           // new status; omit to keep
+	Resolution     string `json:"resolution"`       // required when resolving or closing an open complaint
+	AssignedUserID *uint  `json:"assigned_user_id"` // 0 unassigns; omit to keep
+}
+
+// UpdateComplaint updates a complaint
+// @Summary Update complaint
+// @Description Move a complaint through its lifecycle and/or assign it to a regulator.
+// @Description open → in_progress → resolved → closed; open complaints may be closed with a resolution
+// @Description and resolved ones reopened. Resolving or dismissing a complaint notifies the patient.
+// @Tags regulator
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Complaint ID"
+// @Param request body UpdateComplaintRequest true "Complaint update"
+// @Success 200 {object} models.Complaint
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/regulator/complaints/{id} [put]
+func (h *RegulatorHandler) UpdateComplaint(c *gin.Context) {
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateComplaintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	if req.Status == "" && req.AssignedUserID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nothing to update",
+		})
+		return
+	}
+	if req.Status != "" && !repository.IsComplaintStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown complaint status: " + req.Status,
+		})
+		return
+	}
+	update := repository.ComplaintUpdate{
+		Status:         req.Status,
+		Resolution:     req.Resolution,
+		AssignedUserID: req.AssignedUserID,
+	}
+	if err := h.repo.UpdateComplaint(complaintID, update, requestActor(c)); err != nil {
+		complaintFailed(c, err, "Failed to update complaint")
+		return
+	}
+
+	complaint, err := h.repo.GetComplaint(complaintID)
+	if err != nil {
+		complaintFailed(c, err, "Failed to retrieve complaint")
+		return
+	}
+
+	c.JSON(http.StatusOK, complaint)
+}
+
+// ComplaintNoteRequest is an internal note on a complaint
+type ComplaintNoteRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// CreateComplaintNote adds an internal note
+// @Summary Add complaint note
+// @Description Add an internal note to a complaint's timeline. Notes are visible to regulators only.
+// @Tags regulator
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Complaint ID"
+// @Param request body ComplaintNoteRequest true "Note"
+// @Success 201 {object} models.ComplaintEvent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/regulator/complaints/{id}/notes [post]
+func (h *RegulatorHandler) CreateComplaintNote(c *gin.Context) {
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ComplaintNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	event, err := h.repo.AddComplaintNote(complaintID, req.Note, requestActor(c))
+	if err != nil {
+		complaintFailed(c, err, "Failed to add note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
+
+// ClinicResponseRequest asks the clinic to answer a complaint
+type ClinicResponseRequest struct {
+	Message string     `json:"message" binding:"required"`
+	DueAt   *time.Time `json:"due_at"` // defaults to the clinic response SLA
+}
+
+// RequestClinicResponse asks the clinic to answer a complaint
+// @Summary Request clinic response
+// @Description Send a complaint to the clinic for its response and notify the clinic. An open complaint
+// @Description moves to in_progress. The clinic's answer is due by due_at, or by the configured SLA.
+// @Tags regulator
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Complaint ID"
+// @Param request body ClinicResponseRequest true "Request"
+// @Success 200 {object} models.Complaint
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/regulator/complaints/{id}/response-request [post]
+func (h *RegulatorHandler) RequestClinicResponse(c *gin.Context) {
+	complaintID, ok := complaintIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ClinicResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	due := time.Now().Add(h.complaintCfg.ClinicResponseSLA)
+	if req.DueAt != nil {
+		if !req.DueAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "due_at must be in the future",
+			})
+			return
+		}
+		due = *req.DueAt
+	}
+
+	if err := h.repo.RequestClinicResponse(complaintID, req.Message, due, requestActor(c)); err != nil {
+		complaintFailed(c, err, "Failed to request clinic response")
+		return
+	}
+
+	complaint, err := h.repo.GetComplaint(complaintID)
+	if err != nil {
+		complaintFailed(c, err, "Failed to retrieve complaint")
+		return
+	}
+
+	c.JSON(http.StatusOK, complaint)
+}
+
+// complaintIDParam parses the id path parameter. It writes the error
+// response when it fails.
+func complaintIDParam(c *gin.Context) (uint, bool) {
+	complaintID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid complaint ID",
+		})
+		return 0, false
+	}
+	return uint(complaintID), true
+}
+
+// complaintFailed maps a failed complaint operation to a response
+func complaintFailed(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Complaint not found",
+		})
+	case errors.Is(err, repository.ErrAssigneeNotFound),
+		errors.Is(err, repository.ErrResolutionRequired):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, repository.ErrInvalidTransition),
+		errors.Is(err, repository.ErrComplaintNotActive),
+		errors.Is(err, repository.ErrResponseNotRequested):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": message,
+		})
+	}
+}
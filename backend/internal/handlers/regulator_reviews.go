@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModerationQuery filters the review moderation queue
+type ModerationQuery struct {
+	Status   string `form:"status,default=pending"` // pending, approved, rejected; "all" for any
+	ClinicID *uint  `form:"clinic_id"`
+	Flagged  *bool  `form:"flagged"`
+}
+
+// GetReviews retrieves the review moderation queue
+// @Summary Get reviews for moderation
+// @Description Get reviews for moderation, oldest first, with their auto-flags (profanity, email, phone, link).
+// @Description Defaults to pending reviews. The total is returned in the X-Total-Count header.
+// @Tags regulator
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Review status, or all" default(pending)
+// @Param clinic_id query int false "Filter by clinic"
+// @Param flagged query bool false "Only reviews with (true) or without (false) auto-flags"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Reviews per page" default(20)
+// @Success 200 {array} models.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/regulator/reviews [get]
+func (h *RegulatorHandler) GetReviews(c *gin.Context) {
+	var query ModerationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Status == "all" {
+		query.Status = ""
+	} else if !repository.IsReviewStatus(query.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown review status: " + query.Status,
+		})
+		return
+	}
+
+	page, ok := bindPage(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.ReviewFilter{
+		Status:   query.Status,
+		ClinicID: query.ClinicID,
+		Flagged:  query.Flagged,
+	}
+	reviews, total, err := h.repo.GetModerationReviews(filter, page.Offset(), page.PerPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve reviews",
+		})
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, reviews)
+}
+
+// ModerateReviewRequest records a moderation decision
+type ModerateReviewRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Reason string `json:"reason"` // required when rejecting
+}
+
+// ModerateReview approves or rejects a review
+// @Summary Moderate review
+// @Description Approve a review to publish it, or reject it with a reason. Decisions can be reversed.
+// @Description Only approved reviews count towards the clinic's rating.
+// @Tags regulator
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body ModerateReviewRequest true "Moderation decision"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/regulator/reviews/{id} [put]
+func (h *RegulatorHandler) ModerateReview(c *gin.Context) {
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid review ID",
+		})
+		return
+	}
+
+	var req ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	if req.Status == models.ReviewStatusRejected && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A reason is required to reject a review",
+		})
+		return
+	}
+
+	review, err := h.repo.ModerateReview(uint(reviewID), req.Status, req.Reason, requestActor(c))
+	if err != nil {
+		reviewFailed(c, err, "Review not found", "Failed to moderate review")
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewHandler serves published reviews to anyone, without authentication
+type ReviewHandler struct {
+	repo *repository.Repository
+}
+
+func NewReviewHandler(repo *repository.Repository) *ReviewHandler {
+	return &ReviewHandler{repo: repo}
+}
+
+// PublicReview is an approved review as shown to the public. The author is
+// reduced to a first name and initial.
+type PublicReview struct {
+	ID            uint         `json:"id"`
+	Author        string       `json:"author"`
+	Rating        int          `json:"rating"`
+	Comment       string       `json:"comment"`
+	VerifiedVisit bool         `json:"verified_visit"`
+	CreatedAt     time.Time    `json:"created_at"`
+	EditedAt      *time.Time   `json:"edited_at,omitempty"`
+	Reply         *PublicReply `json:"reply,omitempty"`
+
+	// Attachments are listed only to the reviewed clinic
+	Attachments []models.Attachment `json:"attachments,omitempty"`
+}
+
+// PublicReply is the clinic's answer to a public review
+type PublicReply struct {
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetClinicReviews retrieves a clinic's published reviews
+// @Summary Get clinic reviews
+// @Description Get a clinic's approved reviews with the clinic's replies, newest first.
+// @Description The total number of reviews is returned in the X-Total-Count header.
+// @Tags reviews
+// @Produce json
+// @Param id path int true "Clinic ID"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Reviews per page" default(20)
+// @Success 200 {array} PublicReview
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/clinics/{id}/reviews [get]
+func (h *ReviewHandler) GetClinicReviews(c *gin.Context) {
+	clinicID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid clinic ID",
+		})
+		return
+	}
+
+	page, ok := bindPage(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.repo.GetClinicByID(uint(clinicID)); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Clinic not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve reviews",
+		})
+		return
+	}
+
+	reviews, total, err := h.repo.GetPublishedClinicReviews(uint(clinicID), page.Offset(), page.PerPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve reviews",
+		})
+		return
+	}
+
+	result := make([]PublicReview, 0, len(reviews))
+	for i := range reviews {
+		result = append(result, publicReview(&reviews[i]))
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, result)
+}
+
+func publicReview(review *models.Review) PublicReview {
+	public := PublicReview{
+		ID:            review.ID,
+		Author:        reviewAuthor(&review.Patient),
+		Rating:        review.Rating,
+		Comment:       review.Comment,
+		VerifiedVisit: review.VerifiedVisit,
+		CreatedAt:     review.CreatedAt,
+		EditedAt:      review.EditedAt,
+	}
+	if review.Reply != nil {
+		public.Reply = &PublicReply{
+			Text:      review.Reply.Text,
+			UpdatedAt: review.Reply.UpdatedAt,
+		}
+	}
+	return public
+}
+
+// reviewAuthor shows the patient as "Анна К."
+func reviewAuthor(patient *models.Patient) string {
+	author := strings.TrimSpace(patient.FirstName)
+	if initial, _ := utf8.DecodeRuneInString(strings.TrimSpace(patient.LastName)); initial != utf8.RuneError {
+		author = strings.TrimSpace(author + " " + string(initial) + ".")
+	}
+	if author == "" {
+		return "Пациент"
+	}
+	return author
+}
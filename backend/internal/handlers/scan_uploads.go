@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"dental-marketplace/backend/internal/authz"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resumable uploads follow the offset/PATCH scheme of the tus protocol:
+// the client creates a session, sends chunks with PATCH carrying
+// Upload-Offset and Upload-Checksum headers, and can query the current
+// offset with HEAD to resume after a dropped connection. The session is
+// finalized into a CT scan once the last byte has been received.
+
+// statusChecksumMismatch is the tus status code for a failed chunk checksum
+const statusChecksumMismatch = 460
+
+var errUploadChecksumMismatch = errors.New("upload checksum mismatch")
+
+// CreateUploadRequest starts a resumable upload
+type CreateUploadRequest struct {
+	FileName    string `json:"file_name" binding:"required"`
+	FileSize    int64  `json:"file_size" binding:"required,min=1"`
+	ContentType string `json:"content_type"`
+	Checksum    string `json:"checksum"` // optional hex SHA-256 of the whole file
+}
+
+// CreateUpload starts a resumable CT scan upload session
+// @Summary Create resumable upload
+// @Description Start a resumable chunked upload for a large CT scan
+// @Tags patient
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateUploadRequest true "Upload details"
+// @Success 201 {object} models.UploadSession
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /api/patient/scans/uploads [post]
+func (h *PatientHandler) CreateUpload(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	if req.FileSize > h.storageCfg.MaxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "File is too large",
+		})
+		return
+	}
+
+	if req.Checksum != "" {
+		if sum, err := hex.DecodeString(req.Checksum); err != nil || len(sum) != sha256.Size {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Checksum must be a hex-encoded SHA-256 digest",
+			})
+			return
+		}
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return
+	}
+
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	session := &models.UploadSession{
+		PatientID:        patient.ID,
+		FileName:         req.FileName,
+		ContentType:      contentType,
+		TotalSize:        req.FileSize,
+		ExpectedChecksum: strings.ToLower(req.Checksum),
+		Status:           models.UploadStatusActive,
+		ExpiresAt:        time.Now().Add(h.storageCfg.UploadSessionTTL),
+	}
+
+	if err := h.repo.CreateUploadSession(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create upload session",
+		})
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Location", fmt.Sprintf("/api/patient/scans/uploads/%d", session.ID))
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetUploadOffset reports how many bytes of an upload were received
+// @Summary Get upload offset
+// @Description Return Upload-Offset and Upload-Length headers for resuming
+// @Tags patient
+// @Security BearerAuth
+// @Param upload_id path int true "Upload session ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/scans/uploads/{upload_id} [head]
+func (h *PatientHandler) GetUploadOffset(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusNoContent)
+}
+
+// GetUpload retrieves an upload session
+// @Summary Get upload session
+// @Description Get state of a resumable upload session
+// @Tags patient
+// @Produce json
+// @Security BearerAuth
+// @Param upload_id path int true "Upload session ID"
+// @Success 200 {object} models.UploadSession
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/scans/uploads/{upload_id} [get]
+func (h *PatientHandler) GetUpload(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusOK, session)
+}
+
+// UploadChunk appends a chunk to an upload session
+// @Summary Upload chunk
+// @Description Append a chunk at Upload-Offset; Upload-Checksum is "sha256 <base64 digest>".
+// @Description Completes the upload and creates the CT scan when the last byte is received.
+// @Tags patient
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Security BearerAuth
+// @Param upload_id path int true "Upload session ID"
+// @Param Upload-Offset header int true "Offset of this chunk"
+// @Param Upload-Checksum header string true "Chunk checksum"
+// @Success 201 {object} map[string]interface{}
+// @Success 204
+// @Failure 409 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 460 {object} ErrorResponse
+// @Router /api/patient/scans/uploads/{upload_id} [patch]
+func (h *PatientHandler) UploadChunk(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status != models.UploadStatusActive || time.Now().After(session.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{
+			"error": "Upload session is no longer active",
+		})
+		return
+	}
+
+	contentType := c.ContentType()
+	if contentType != "application/offset+octet-stream" && contentType != "application/octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be application/offset+octet-stream",
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Upload-Offset header is required",
+		})
+		return
+	}
+	if offset != session.Offset {
+		setUploadHeaders(c, session)
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Upload-Offset does not match the current offset",
+		})
+		return
+	}
+
+	size := c.Request.ContentLength
+	if size < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{
+			"error": "Content-Length header is required",
+		})
+		return
+	}
+	if size > h.storageCfg.MaxChunkSize || offset+size > session.TotalSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Chunk exceeds the allowed size",
+		})
+		return
+	}
+
+	// An empty PATCH at the final offset retries a failed finalization
+	if size == 0 {
+		if offset == session.TotalSize {
+			h.finalizeUpload(c, session)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Chunk is empty",
+		})
+		return
+	}
+
+	expectedSum, err := parseUploadChecksum(c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	key, err := storage.NewKey(fmt.Sprintf("uploads/%d", session.ID), "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store chunk",
+		})
+		return
+	}
+
+	hasher := sha256.New()
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, size)
+	if err := h.store.Put(ctx, key, io.TeeReader(body, hasher), size, "application/octet-stream"); err != nil {
+		log.Printf("failed to store chunk for upload %d: %v", session.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store chunk",
+		})
+		return
+	}
+
+	sum := hasher.Sum(nil)
+	if string(sum) != string(expectedSum) {
+		h.store.Delete(ctx, key)
+		c.JSON(statusChecksumMismatch, gin.H{
+			"error": "Chunk checksum mismatch",
+		})
+		return
+	}
+
+	chunk := &models.UploadChunk{
+		Offset:     offset,
+		Size:       size,
+		Checksum:   hex.EncodeToString(sum),
+		StorageKey: key,
+	}
+
+	session, err = h.repo.AppendUploadChunk(session.ID, chunk, time.Now().Add(h.storageCfg.UploadSessionTTL))
+	if err != nil {
+		h.store.Delete(ctx, key)
+		switch {
+		case errors.Is(err, repository.ErrUploadOffsetMismatch):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Upload-Offset does not match the current offset",
+			})
+		case errors.Is(err, repository.ErrUploadNotActive):
+			c.JSON(http.StatusGone, gin.H{
+				"error": "Upload session is no longer active",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to record chunk",
+			})
+		}
+		return
+	}
+
+	if session.Offset == session.TotalSize {
+		h.finalizeUpload(c, session)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Status(http.StatusNoContent)
+}
+
+// AbortUpload cancels an upload session and discards received chunks
+// @Summary Abort upload
+// @Description Cancel a resumable upload session
+// @Tags patient
+// @Security BearerAuth
+// @Param upload_id path int true "Upload session ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/patient/scans/uploads/{upload_id} [delete]
+func (h *PatientHandler) AbortUpload(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status != models.UploadStatusActive {
+		c.JSON(http.StatusGone, gin.H{
+			"error": "Upload session is no longer active",
+		})
+		return
+	}
+
+	if err := h.repo.UpdateUploadSessionStatus(session.ID, models.UploadStatusAborted); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to abort upload",
+		})
+		return
+	}
+
+	h.discardUploadChunks(c.Request.Context(), session)
+	c.Status(http.StatusNoContent)
+}
+
+// finalizeUpload assembles the received chunks into the scan file and
+// creates the CT scan record
+func (h *PatientHandler) finalizeUpload(c *gin.Context, session *models.UploadSession) {
+	ctx := c.Request.Context()
+
+	// Reload to get the full, ordered chunk list
+	session, err := h.repo.GetUploadSession(session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to finalize upload",
+		})
+		return
+	}
+
+	scan, err := h.assembleUpload(ctx, session)
+	if err != nil {
+		if errors.Is(err, errUploadChecksumMismatch) {
+			h.repo.UpdateUploadSessionStatus(session.ID, models.UploadStatusFailed)
+			h.discardUploadChunks(ctx, session)
+			c.JSON(statusChecksumMismatch, gin.H{
+				"error": "File checksum mismatch",
+			})
+			return
+		}
+		log.Printf("failed to finalize upload %d: %v", session.ID, err)
+		setUploadHeaders(c, session)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to finalize upload, retry with an empty PATCH",
+		})
+		return
+	}
+
+	h.discardUploadChunks(ctx, session)
+
+	// Index DICOM headers; invalid files move the scan to error status
+	if err := h.ingestor.Ingest(ctx, scan); err != nil {
+		log.Printf("failed to ingest scan %d: %v", scan.ID, err)
+	}
+
+	session.Status = models.UploadStatusCompleted
+	session.CTScanID = &scan.ID
+	session.Chunks = nil
+
+	h.signScanURL(ctx, scan)
+
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusCreated, gin.H{
+		"upload": session,
+		"scan":   scan,
+	})
+}
+
+// assembleUpload concatenates chunks into the final object and creates the scan
+func (h *PatientHandler) assembleUpload(ctx context.Context, session *models.UploadSession) (*models.CTScan, error) {
+	keys := make([]string, len(session.Chunks))
+	for i, chunk := range session.Chunks {
+		keys[i] = chunk.StorageKey
+	}
+
+	key, err := storage.NewKey(fmt.Sprintf("scans/%d", session.PatientID), session.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := storage.NewConcatReader(ctx, h.store, keys)
+	defer reader.Close()
+
+	hasher := sha256.New()
+	if err := h.store.Put(ctx, key, io.TeeReader(reader, hasher), session.TotalSize, session.ContentType); err != nil {
+		return nil, err
+	}
+
+	checksum := hex.EncodeToString(hasher.Sum(nil))
+	if session.ExpectedChecksum != "" && checksum != session.ExpectedChecksum {
+		h.store.Delete(ctx, key)
+		return nil, errUploadChecksumMismatch
+	}
+
+	scan := &models.CTScan{
+		PatientID:   session.PatientID,
+		UploadDate:  time.Now(),
+		Status:      models.ScanStatusUploaded,
+		StorageKey:  key,
+		FileName:    session.FileName,
+		FileSize:    session.TotalSize,
+		ContentType: session.ContentType,
+		Checksum:    checksum,
+	}
+
+	if err := h.repo.CompleteUploadSession(session.ID, scan); err != nil {
+		h.store.Delete(ctx, key)
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+// discardUploadChunks removes chunk objects and records of a session
+func (h *PatientHandler) discardUploadChunks(ctx context.Context, session *models.UploadSession) {
+	for _, chunk := range session.Chunks {
+		if err := h.store.Delete(ctx, chunk.StorageKey); err != nil {
+			log.Printf("failed to delete chunk %s: %v", chunk.StorageKey, err)
+		}
+	}
+	if err := h.repo.DeleteUploadChunks(session.ID); err != nil {
+		log.Printf("failed to delete chunk records for upload %d: %v", session.ID, err)
+	}
+}
+
+// loadUploadSession fetches the session from the path and checks ownership
+func (h *PatientHandler) loadUploadSession(c *gin.Context) (*models.UploadSession, bool) {
+	userID, _ := c.Get("userID")
+
+	sessionID, err := strconv.ParseUint(c.Param("upload_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid upload ID",
+		})
+		return nil, false
+	}
+
+	patient, err := h.repo.GetPatientByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Patient profile not found",
+		})
+		return nil, false
+	}
+
+	session, err := h.repo.GetUploadSession(uint(sessionID))
+	if err != nil || !authz.PatientCanViewUpload(patient, session) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Upload session not found",
+		})
+		return nil, false
+	}
+
+	return session, true
+}
+
+func setUploadHeaders(c *gin.Context, session *models.UploadSession) {
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.TotalSize, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// parseUploadChecksum decodes a tus "sha256 <base64 digest>" header
+func parseUploadChecksum(header string) ([]byte, error) {
+	algorithm, encoded, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found {
+		return nil, errors.New("Upload-Checksum header is required")
+	}
+	if !strings.EqualFold(algorithm, "sha256") {
+		return nil, errors.New("Upload-Checksum algorithm must be sha256")
+	}
+
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, errors.New("Upload-Checksum digest is invalid")
+	}
+	return sum, nil
+}
@@ -0,0 +1,125 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event statuses
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// ProductID identifies the feed's generator
+const ProductID = "-//Dental Marketplace//Appointments//RU"
+
+// Event is one VEVENT. UID must stay the same for the life of the event;
+// calendar clients replace their copy when Sequence grows.
+type Event struct {
+	UID          string
+	Sequence     int
+	Start        time.Time
+	End          time.Time
+	LastModified time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Status       string
+}
+
+// Calendar is a read-only RFC 5545 VCALENDAR with its events
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Write encodes the calendar with CRLF line endings, escaped text values
+// and lines folded at 75 octets. stamp is used as every event's DTSTAMP.
+func (c *Calendar) Write(w io.Writer, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", ProductID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeText(e.UID))
+		line("DTSTAMP", formatTime(stamp))
+		line("DTSTART", formatTime(e.Start))
+		line("DTEND", formatTime(e.End))
+		line("SEQUENCE", strconv.Itoa(e.Sequence))
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", formatTime(e.LastModified))
+		}
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escapeText(e.Location))
+		}
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// formatTime formats t as a UTC DATE-TIME
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes a content line, folding it so no line exceeds 75
+// octets without splitting a UTF-8 sequence
+func writeFolded(w *bufio.Writer, s string) {
+	const limit = 75
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		width = limit - 1 // continuation lines start with a space
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
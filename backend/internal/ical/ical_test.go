@@ -0,0 +1,167 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Кресло 1", "Кресло 1"},
+		{`C:\path`, `C:\\path`},
+		{"пломба; коронка, мост", `пломба\; коронка\, мост`},
+		{"first\nsecond", `first\nsecond`},
+		{"first\r\nsecond\rthird", `first\nsecond\nthird`},
+		{`\;`, `\\\;`},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// unfold reverses folding per RFC 5545 section 3.1
+func unfold(s string) string {
+	return strings.ReplaceAll(s, "\r\n ", "")
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		lines int
+	}{
+		{"short line", "SUMMARY:Осмотр", 1},
+		{"exactly 75 octets", "DESCRIPTION:" + strings.Repeat("a", 63), 1},
+		{"76 octets", "DESCRIPTION:" + strings.Repeat("a", 64), 2},
+		{"long ASCII", "DESCRIPTION:" + strings.Repeat("a", 200), 3},
+		// Two-octet runes never line up with the fold points
+		{"long Cyrillic", "DESCRIPTION:" + strings.Repeat("ж", 100), 3},
+		{"mixed widths", "LOCATION:" + strings.Repeat("a€ж", 40), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := bufio.NewWriter(&buf)
+			writeFolded(w, tt.in)
+			w.Flush()
+			out := buf.String()
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(lines) != tt.lines {
+				t.Errorf("got %d lines, want %d", len(lines), tt.lines)
+			}
+			for i, line := range lines {
+				if len(line) > 75 {
+					t.Errorf("line %d is %d octets", i, len(line))
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 sequence: %q", i, line)
+				}
+			}
+			if got := unfold(strings.TrimSuffix(out, "\r\n")); got != tt.in {
+				t.Errorf("unfolded = %q, want %q", got, tt.in)
+			}
+		})
+	}
+}
+
+func TestCalendarWrite(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	calendar := &Calendar{
+		Name: "Клиника; центр",
+		Events: []Event{
+			{
+				UID:          "appointment-7@dental-marketplace",
+				Sequence:     2,
+				Start:        time.Date(2026, time.March, 2, 9, 30, 0, 0, moscow),
+				End:          time.Date(2026, time.March, 2, 10, 30, 0, 0, moscow),
+				LastModified: time.Date(2026, time.February, 20, 12, 0, 0, 0, time.UTC),
+				Summary:      "Лечение, кариес",
+				Description:  "Кресло 1\nпринести снимки",
+				Status:       StatusConfirmed,
+			},
+			{
+				UID:     "appointment-8@dental-marketplace",
+				Start:   time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC),
+				End:     time.Date(2026, time.March, 3, 10, 0, 0, 0, time.UTC),
+				Summary: "Осмотр",
+			},
+		},
+	}
+	stamp := time.Date(2026, time.March, 1, 8, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf, stamp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + ProductID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Клиника\; центр`,
+		"BEGIN:VEVENT",
+		"UID:appointment-7@dental-marketplace",
+		"DTSTAMP:20260301T080000Z",
+		"DTSTART:20260302T063000Z",
+		"DTEND:20260302T073000Z",
+		"SEQUENCE:2",
+		"LAST-MODIFIED:20260220T120000Z",
+		`SUMMARY:Лечение\, кариес`,
+		`DESCRIPTION:Кресло 1\nпринести снимки`,
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:appointment-8@dental-marketplace",
+		"DTSTAMP:20260301T080000Z",
+		"DTSTART:20260303T090000Z",
+		"DTEND:20260303T100000Z",
+		"SEQUENCE:0",
+		"SUMMARY:Осмотр",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Write =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCalendarWriteIsStable(t *testing.T) {
+	calendar := &Calendar{Events: []Event{{
+		UID:      "appointment-7@dental-marketplace",
+		Sequence: 1,
+		Start:    time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC),
+		End:      time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC),
+		Summary:  "Осмотр",
+	}}}
+	stamp := time.Date(2026, time.March, 1, 8, 0, 0, 0, time.UTC)
+
+	// The same events always encode to the same feed
+	var first, second bytes.Buffer
+	if err := calendar.Write(&first, stamp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := calendar.Write(&second, stamp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("feeds differ:\n%s\n%s", first.String(), second.String())
+	}
+}
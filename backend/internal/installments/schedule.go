@@ -0,0 +1,104 @@
+package installments
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"math"
+	"time"
+)
+
+// Available reports whether a product can be used to pay for an offer: it
+// must be the offering clinic's, active, and fit the offer's installment term
+func Available(offer *models.ClinicOffer, product *models.InstallmentProduct) bool {
+	return product.IsActive &&
+		product.ClinicID == offer.ClinicID &&
+		product.TermMonths > 0 &&
+		product.TermMonths <= offer.InstallmentMonths &&
+		offer.TotalCost > 0
+}
+
+// Options builds a schedule for every product available for the offer,
+// starting at start
+func Options(offer *models.ClinicOffer, products []models.InstallmentProduct, start time.Time) []models.PaymentSchedule {
+	var options []models.PaymentSchedule
+	for i := range products {
+		if Available(offer, &products[i]) {
+			options = append(options, Build(offer, &products[i], start))
+		}
+	}
+	return options
+}
+
+// Build computes the amortization schedule of an offer under a product.
+// The markup is added to the offer's total cost, the down payment is due at
+// start and the rest is repaid in equal monthly annuity payments, the first
+// one month after start. Amounts are rounded to whole units; the last
+// payment absorbs rounding so the balance ends at zero.
+func Build(offer *models.ClinicOffer, product *models.InstallmentProduct, start time.Time) models.PaymentSchedule {
+	markup := percentOf(offer.TotalCost, product.MarkupPercent)
+	cost := offer.TotalCost + markup
+	downPayment := percentOf(cost, product.DownPaymentPercent)
+	financed := cost - downPayment
+
+	schedule := models.PaymentSchedule{
+		ClinicOfferID:        offer.ID,
+		InstallmentProductID: &product.ID,
+		ProductName:          product.Name,
+		TermMonths:           product.TermMonths,
+		AnnualInterestRate:   product.AnnualInterestRate,
+		MarkupPercent:        product.MarkupPercent,
+		TreatmentCost:        offer.TotalCost,
+		Markup:               markup,
+		DownPayment:          downPayment,
+		FinancedAmount:       financed,
+		StartDate:            start,
+	}
+
+	n := product.TermMonths
+	if n <= 0 {
+		schedule.TotalPayable = cost
+		return schedule
+	}
+
+	rate := product.AnnualInterestRate / 12 / 100
+	monthly := float64(financed) / float64(n)
+	if rate > 0 {
+		monthly = float64(financed) * rate / (1 - math.Pow(1+rate, -float64(n)))
+	}
+	schedule.MonthlyPayment = int(math.Round(monthly))
+
+	balance := financed
+	schedule.TotalPayable = downPayment
+	schedule.Payments = make([]models.ScheduledPayment, n)
+	for k := 1; k <= n; k++ {
+		interest := int(math.Round(float64(balance) * rate))
+		principal := min(max(schedule.MonthlyPayment-interest, 0), balance)
+		if k == n {
+			principal = balance
+		}
+		balance -= principal
+
+		schedule.Payments[k-1] = models.ScheduledPayment{
+			Number:    k,
+			DueDate:   addMonths(start, k),
+			Amount:    principal + interest,
+			Principal: principal,
+			Interest:  interest,
+			Balance:   balance,
+		}
+		schedule.TotalInterest += interest
+		schedule.TotalPayable += principal + interest
+	}
+	return schedule
+}
+
+// addMonths moves t by n calendar months, clamping the day to the end of
+// shorter months so a schedule started on the 31st stays at month end
+func addMonths(t time.Time, n int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+func percentOf(amount int, percent float64) int {
+	return int(math.Round(float64(amount) * percent / 100))
+}
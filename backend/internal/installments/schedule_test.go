@@ -0,0 +1,172 @@
+package installments
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2026, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		product      models.InstallmentProduct
+		markup       int
+		downPayment  int
+		financed     int
+		monthly      int
+		amounts      []int
+		totalPayable int
+	}{
+		{
+			name:         "interest-free, leftover on the last payment",
+			total:        10000,
+			product:      models.InstallmentProduct{TermMonths: 3},
+			financed:     10000,
+			monthly:      3333,
+			amounts:      []int{3333, 3333, 3334},
+			totalPayable: 10000,
+		},
+		{
+			name:         "markup and down payment",
+			total:        10000,
+			product:      models.InstallmentProduct{TermMonths: 3, MarkupPercent: 10, DownPaymentPercent: 20},
+			markup:       1000,
+			downPayment:  2200,
+			financed:     8800,
+			monthly:      2933,
+			amounts:      []int{2933, 2933, 2934},
+			totalPayable: 11000,
+		},
+		{
+			name:         "single payment",
+			total:        5000,
+			product:      models.InstallmentProduct{TermMonths: 1, DownPaymentPercent: 10},
+			downPayment:  500,
+			financed:     4500,
+			monthly:      4500,
+			amounts:      []int{4500},
+			totalPayable: 5000,
+		},
+		{
+			name:         "single payment with interest",
+			total:        12000,
+			product:      models.InstallmentProduct{TermMonths: 1, AnnualInterestRate: 12},
+			financed:     12000,
+			monthly:      12120,
+			amounts:      []int{12120},
+			totalPayable: 12120,
+		},
+		{
+			name:         "no payments",
+			total:        5000,
+			product:      models.InstallmentProduct{TermMonths: 0, MarkupPercent: 5},
+			markup:       250,
+			financed:     5250,
+			totalPayable: 5250,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer := &models.ClinicOffer{ID: 1, TotalCost: tt.total}
+			s := Build(offer, &tt.product, testStart)
+
+			if s.TreatmentCost != tt.total || s.Markup != tt.markup || s.DownPayment != tt.downPayment ||
+				s.FinancedAmount != tt.financed || s.MonthlyPayment != tt.monthly {
+				t.Errorf("schedule = cost %d, markup %d, down %d, financed %d, monthly %d; want %d, %d, %d, %d, %d",
+					s.TreatmentCost, s.Markup, s.DownPayment, s.FinancedAmount, s.MonthlyPayment,
+					tt.total, tt.markup, tt.downPayment, tt.financed, tt.monthly)
+			}
+			if len(s.Payments) != len(tt.amounts) {
+				t.Fatalf("got %d payments, want %d", len(s.Payments), len(tt.amounts))
+			}
+			for i, want := range tt.amounts {
+				if got := s.Payments[i].Amount; got != want {
+					t.Errorf("payment %d = %d, want %d", i+1, got, want)
+				}
+			}
+			if s.TotalPayable != tt.totalPayable {
+				t.Errorf("total payable = %d, want %d", s.TotalPayable, tt.totalPayable)
+			}
+		})
+	}
+}
+
+func TestBuildSumsExactly(t *testing.T) {
+	products := []models.InstallmentProduct{
+		{TermMonths: 3},
+		{TermMonths: 7, DownPaymentPercent: 15},
+		{TermMonths: 6, AnnualInterestRate: 19.9},
+		{TermMonths: 12, AnnualInterestRate: 24, MarkupPercent: 3.5, DownPaymentPercent: 10},
+		{TermMonths: 24, AnnualInterestRate: 7.5, MarkupPercent: 12.25},
+	}
+	for _, total := range []int{1, 999, 10001, 123457, 1000000} {
+		for _, product := range products {
+			s := Build(&models.ClinicOffer{TotalCost: total}, &product, testStart)
+
+			if s.Markup+total != s.DownPayment+s.FinancedAmount {
+				t.Errorf("total %d, %+v: markup and cost %d != down payment and financed %d",
+					total, product, s.Markup+total, s.DownPayment+s.FinancedAmount)
+			}
+			principal, interest, paid := 0, 0, s.DownPayment
+			for i, p := range s.Payments {
+				if p.Number != i+1 || p.Amount != p.Principal+p.Interest || p.Principal < 0 {
+					t.Errorf("total %d, %+v: payment %+v is inconsistent", total, product, p)
+				}
+				principal += p.Principal
+				interest += p.Interest
+				paid += p.Amount
+			}
+			if principal != s.FinancedAmount {
+				t.Errorf("total %d, %+v: principal %d, want the financed %d", total, product, principal, s.FinancedAmount)
+			}
+			if last := s.Payments[len(s.Payments)-1]; last.Balance != 0 {
+				t.Errorf("total %d, %+v: final balance %d, want 0", total, product, last.Balance)
+			}
+			if interest != s.TotalInterest || paid != s.TotalPayable {
+				t.Errorf("total %d, %+v: interest %d, paid %d; schedule says %d, %d",
+					total, product, interest, paid, s.TotalInterest, s.TotalPayable)
+			}
+		}
+	}
+}
+
+func TestBuildDueDates(t *testing.T) {
+	s := Build(&models.ClinicOffer{TotalCost: 4000}, &models.InstallmentProduct{TermMonths: 4}, testStart)
+
+	// Started on the 31st, payments stay at month end
+	want := []string{"2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"}
+	for i, p := range s.Payments {
+		if got := p.DueDate.Format(time.DateOnly); got != want[i] {
+			t.Errorf("payment %d due %s, want %s", p.Number, got, want[i])
+		}
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	offer := &models.ClinicOffer{ClinicID: 1, TotalCost: 10000, InstallmentMonths: 12}
+
+	tests := []struct {
+		name    string
+		offer   *models.ClinicOffer
+		product models.InstallmentProduct
+		want    bool
+	}{
+		{"fits the offer", offer, models.InstallmentProduct{ClinicID: 1, TermMonths: 12, IsActive: true}, true},
+		{"shorter term", offer, models.InstallmentProduct{ClinicID: 1, TermMonths: 6, IsActive: true}, true},
+		{"longer than offered", offer, models.InstallmentProduct{ClinicID: 1, TermMonths: 24, IsActive: true}, false},
+		{"inactive", offer, models.InstallmentProduct{ClinicID: 1, TermMonths: 6}, false},
+		{"another clinic", offer, models.InstallmentProduct{ClinicID: 2, TermMonths: 6, IsActive: true}, false},
+		{"no term", offer, models.InstallmentProduct{ClinicID: 1, IsActive: true}, false},
+		{"unpriced offer", &models.ClinicOffer{ClinicID: 1, InstallmentMonths: 12}, models.InstallmentProduct{ClinicID: 1, TermMonths: 6, IsActive: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Available(tt.offer, &tt.product); got != tt.want {
+				t.Errorf("Available = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
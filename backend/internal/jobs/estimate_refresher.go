@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"dental-marketplace/backend/internal/pricing"
+	"sort"
+	"sync"
+)
+
+// EstimateRefresher re-estimates open plans in cities whose price lists
+// changed. Price list edits only schedule their city; each run recomputes a
+// scheduled city once, however many edits came in since the last run.
+type EstimateRefresher struct {
+	estimator *pricing.Estimator
+
+	mu     sync.Mutex
+	cities map[string]bool
+}
+
+func NewEstimateRefresher(estimator *pricing.Estimator) *EstimateRefresher {
+	return &EstimateRefresher{estimator: estimator, cities: map[string]bool{}}
+}
+
+// Schedule queues the city's open plans for the next run
+func (r *EstimateRefresher) Schedule(city string) {
+	if city == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cities[city] = true
+}
+
+// Refresh re-estimates plans in the scheduled cities. Cities that were not
+// recomputed because of an error stay scheduled for the next run.
+func (r *EstimateRefresher) Refresh(ctx context.Context) error {
+	cities := r.take()
+	for i, city := range cities {
+		if ctx.Err() != nil {
+			r.requeue(cities[i:])
+			return ctx.Err()
+		}
+		if err := r.estimator.RecomputeCity(city); err != nil {
+			r.requeue(cities[i:])
+			return err
+		}
+	}
+	return nil
+}
+
+// take removes and returns the scheduled cities in a stable order
+func (r *EstimateRefresher) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cities := make([]string, 0, len(r.cities))
+	for city := range r.cities {
+		cities = append(cities, city)
+	}
+	r.cities = map[string]bool{}
+	sort.Strings(cities)
+	return cities
+}
+
+func (r *EstimateRefresher) requeue(cities []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, city := range cities {
+		r.cities[city] = true
+	}
+}
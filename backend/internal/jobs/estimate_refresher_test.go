@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/pricing"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/testdb"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEstimateRefresherCollapsesSchedules(t *testing.T) {
+	r := NewEstimateRefresher(nil)
+	r.Schedule("Москва")
+	r.Schedule("Казань")
+	r.Schedule("Москва")
+	r.Schedule("")
+
+	if got, want := r.take(), []string{"Казань", "Москва"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take = %v, want %v", got, want)
+	}
+	if got := r.take(); len(got) != 0 {
+		t.Errorf("second take = %v, want nothing", got)
+	}
+
+	r.requeue([]string{"Москва"})
+	if got, want := r.take(), []string{"Москва"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("take after requeue = %v, want %v", got, want)
+	}
+}
+
+func TestEstimateRefresherRecomputesScheduledCity(t *testing.T) {
+	db := testdb.Open(t)
+	repo := repository.NewRepository(db)
+	refresher := NewEstimateRefresher(pricing.NewEstimator(repo))
+
+	// A city of its own keeps seeded price lists out of the estimate
+	city := fmt.Sprintf("Город-%d", time.Now().UnixNano())
+	clinic := testdb.Clinic(t, db)
+	patient := testdb.Patient(t, db)
+	for _, value := range []interface{}{clinic, patient} {
+		if err := db.Model(value).Update("city", city).Error; err != nil {
+			t.Fatalf("failed to move %T: %v", value, err)
+		}
+	}
+	plan := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersRequested)
+
+	price := &models.PriceList{
+		ClinicID:       clinic.ID,
+		Specialization: models.SpecTherapy,
+		ServiceName:    "Лечение кариеса",
+		ProcedureCode:  plan.Items[0].ProcedureCode,
+		Price:          9000,
+	}
+	if err := db.Create(price).Error; err != nil {
+		t.Fatalf("failed to create price: %v", err)
+	}
+
+	refresher.Schedule(city)
+	refresher.Schedule(city)
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	var item models.TreatmentItem
+	if err := db.First(&item, plan.Items[0].ID).Error; err != nil {
+		t.Fatalf("failed to reload item: %v", err)
+	}
+	if item.EstimatedCost != price.Price {
+		t.Errorf("item estimate = %d, want %d", item.EstimatedCost, price.Price)
+	}
+	if pending := refresher.take(); len(pending) != 0 {
+		t.Errorf("cities still scheduled after a refresh: %v", pending)
+	}
+}
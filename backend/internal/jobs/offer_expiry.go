@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"dental-marketplace/backend/internal/repository"
+	"log"
+	"time"
+)
+
+// OfferSweeper expires sent offers whose validity has passed
+type OfferSweeper struct {
+	repo *repository.Repository
+}
+
+func NewOfferSweeper(repo *repository.Repository) *OfferSweeper {
+	return &OfferSweeper{repo: repo}
+}
+
+// Sweep expires all sent offers that are past their validity
+func (s *OfferSweeper) Sweep(ctx context.Context) error {
+	expired, err := s.repo.ExpireClinicOffers(time.Now())
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("Expired %d clinic offers", expired)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunPeriodic calls fn every interval until ctx is cancelled.
+// Errors are logged and do not stop the loop.
+func RunPeriodic(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("⏱️  Background task %s started (every %s)", name, interval)
+
+	for {
+		if err := fn(ctx); err != nil {
+			log.Printf("background task %s failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"context"
+	"dental-marketplace/backend/internal/analysis"
+	"dental-marketplace/backend/internal/config"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/pricing"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ScanErrorAnalysisFailed is the scan error reason once retries are exhausted
+const ScanErrorAnalysisFailed = "analysis_failed"
+
+// maxRetryBackoff caps the exponential delay between attempts
+const maxRetryBackoff = time.Hour
+
+// ScanProcessor runs queued scan analysis jobs on a pool of workers
+type ScanProcessor struct {
+	repo      *repository.Repository
+	store     storage.Storage
+	analyzer  analysis.Analyzer
+	estimator *pricing.Estimator
+	cfg       config.ScanConfig
+}
+
+func NewScanProcessor(repo *repository.Repository, store storage.Storage, analyzer analysis.Analyzer, estimator *pricing.Estimator, cfg config.ScanConfig) *ScanProcessor {
+	return &ScanProcessor{
+		repo:      repo,
+		store:     store,
+		analyzer:  analyzer,
+		estimator: estimator,
+		cfg:       cfg,
+	}
+}
+
+// Run starts the configured number of workers and blocks until ctx is
+// cancelled and all workers have stopped
+func (p *ScanProcessor) Run(ctx context.Context) {
+	host, _ := os.Hostname()
+
+	log.Printf("⏱️  Scan processor started (%d workers, analyzer %s)", p.cfg.Workers, p.analyzer.Name())
+
+	var wg sync.WaitGroup
+	for i := 1; i <= p.cfg.Workers; i++ {
+		wg.Add(1)
+		workerID := fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i)
+		go func() {
+			defer wg.Done()
+			p.work(ctx, workerID)
+		}()
+	}
+	wg.Wait()
+}
+
+// work claims and processes jobs until ctx is cancelled, sleeping for the
+// poll interval whenever the queue is empty
+func (p *ScanProcessor) work(ctx context.Context, workerID string) {
+	for {
+		now := time.Now()
+		job, err := p.repo.ClaimScanJob(workerID, now, now.Add(-p.cfg.JobTimeout))
+		if err == nil {
+			p.process(ctx, job)
+			continue
+		}
+		if !errors.Is(err, repository.ErrRecordNotFound) {
+			log.Printf("scan worker %s failed to claim a job: %v", workerID, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.cfg.JobPollInterval):
+		}
+	}
+}
+
+// process runs one attempt and schedules a retry or dead-letters the job
+func (p *ScanProcessor) process(ctx context.Context, job *models.ScanJob) {
+	jobCtx, cancel := context.WithTimeout(ctx, p.cfg.JobTimeout)
+	defer cancel()
+
+	err := p.analyze(jobCtx, job)
+	if err == nil {
+		log.Printf("Scan %d analyzed (attempt %d)", job.CTScanID, job.Attempts)
+		return
+	}
+	if errors.Is(err, repository.ErrScanJobLost) {
+		log.Printf("scan %d job was reclaimed by another worker, discarding attempt %d", job.CTScanID, job.Attempts)
+		return
+	}
+
+	if analysis.IsPermanent(err) || job.Attempts >= p.cfg.MaxAttempts {
+		log.Printf("scan %d analysis failed permanently after %d attempts: %v", job.CTScanID, job.Attempts, err)
+		if err := p.repo.DeadLetterScanJob(job, ScanErrorAnalysisFailed, err.Error()); err != nil {
+			log.Printf("failed to dead-letter scan job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	runAt := time.Now().Add(p.backoff(job.Attempts))
+	log.Printf("scan %d analysis failed (attempt %d), retrying at %s: %v", job.CTScanID, job.Attempts, runAt.Format(time.RFC3339), err)
+	if err := p.repo.RetryScanJob(job, err.Error(), runAt); err != nil {
+		log.Printf("failed to reschedule scan job %d: %v", job.ID, err)
+	}
+}
+
+func (p *ScanProcessor) analyze(ctx context.Context, job *models.ScanJob) error {
+	scan, err := p.repo.GetCTScanByID(job.CTScanID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return analysis.Permanent(err)
+		}
+		return err
+	}
+
+	// Analyzers only ever see the de-identified copy
+	if scan.AnonymizedKey == "" {
+		return analysis.Permanent(errors.New("scan has no anonymized copy"))
+	}
+
+	result, err := p.analyzer.Analyze(ctx, analysis.Scan{
+		ID:       scan.ID,
+		Checksum: scan.AnonymizedChecksum,
+		Metadata: scan.Metadata,
+		Open: func(ctx context.Context) (io.ReadCloser, error) {
+			return p.store.Get(ctx, scan.AnonymizedKey)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	patient, err := p.repo.GetPatientByID(scan.PatientID)
+	if err != nil {
+		return err
+	}
+
+	plan := analysis.BuildPlan(scan, result)
+	if err := p.estimator.Estimate(plan, patient.City); err != nil {
+		return err
+	}
+
+	return p.repo.CompleteScanJob(job, plan)
+}
+
+// backoff doubles the configured delay after each failed attempt
+func (p *ScanProcessor) backoff(attempts int) time.Duration {
+	delay := p.cfg.RetryBackoff
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
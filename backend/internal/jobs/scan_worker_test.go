@@ -0,0 +1,315 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"dental-marketplace/backend/internal/analysis"
+	"dental-marketplace/backend/internal/config"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/pricing"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"dental-marketplace/backend/internal/testdb"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// flakyAnalyzer fails the first failures calls with err and then defers
+// to the fake analyzer
+type flakyAnalyzer struct {
+	failures int
+	err      error
+	calls    int
+	fake     *analysis.FakeAnalyzer
+}
+
+func (a *flakyAnalyzer) Name() string {
+	return "flaky"
+}
+
+func (a *flakyAnalyzer) Analyze(ctx context.Context, scan analysis.Scan) (*analysis.Result, error) {
+	a.calls++
+
+	// The analyzer must be handed the de-identified file
+	f, err := scan.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(data, anonymizedContent) {
+		return nil, errors.New("analyzer was not given the anonymized copy")
+	}
+
+	if a.calls <= a.failures {
+		return nil, a.err
+	}
+	return a.fake.Analyze(ctx, scan)
+}
+
+var anonymizedContent = []byte("de-identified dicom")
+
+type workerFixture struct {
+	db        *gorm.DB
+	repo      *repository.Repository
+	processor *ScanProcessor
+	analyzer  *flakyAnalyzer
+	scan      *models.CTScan
+}
+
+func newWorkerFixture(t *testing.T, failures int, failErr error) *workerFixture {
+	t.Helper()
+
+	db := testdb.Open(t)
+	repo := repository.NewRepository(db)
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files", "test-secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	key := "anonymized/scan.dcm"
+	if err := store.Put(context.Background(), key, bytes.NewReader(anonymizedContent), int64(len(anonymizedContent)), "application/dicom"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	scan := testdb.Scan(t, db, testdb.Patient(t, db))
+	if err := db.Model(scan).Updates(map[string]interface{}{
+		"status":              models.ScanStatusUploaded,
+		"AIProcessed":         false,
+		"anonymized_key":      key,
+		"anonymized_checksum": "0123456789abcdef",
+	}).Error; err != nil {
+		t.Fatalf("update scan: %v", err)
+	}
+
+	analyzer := &flakyAnalyzer{failures: failures, err: failErr, fake: analysis.NewFakeAnalyzer()}
+	processor := NewScanProcessor(repo, store, analyzer, pricing.NewEstimator(repo), config.ScanConfig{
+		Workers:         1,
+		MaxAttempts:     3,
+		JobTimeout:      time.Minute,
+		RetryBackoff:    time.Minute,
+		JobPollInterval: time.Second,
+	})
+
+	// Jobs left queued by other data in the database must not be claimed
+	// ahead of this test's job
+	if err := db.Model(&models.ScanJob{}).
+		Where("status IN ?", []string{models.ScanJobStatusQueued, models.ScanJobStatusRunning}).
+		Update("status", models.ScanJobStatusDead).Error; err != nil {
+		t.Fatalf("park existing jobs: %v", err)
+	}
+
+	if _, err := repo.EnqueueScanJob(scan.ID, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("EnqueueScanJob: %v", err)
+	}
+
+	return &workerFixture{db: db, repo: repo, processor: processor, analyzer: analyzer, scan: scan}
+}
+
+// runOnce claims the job as worker at now and processes it
+func (f *workerFixture) runOnce(t *testing.T, worker string, now time.Time) *models.ScanJob {
+	t.Helper()
+	job, err := f.repo.ClaimScanJob(worker, now, now.Add(-f.processor.cfg.JobTimeout))
+	if err != nil {
+		t.Fatalf("ClaimScanJob: %v", err)
+	}
+	if job.CTScanID != f.scan.ID {
+		t.Fatalf("claimed job of scan %d, want %d", job.CTScanID, f.scan.ID)
+	}
+	f.processor.process(context.Background(), job)
+	return job
+}
+
+func (f *workerFixture) state(t *testing.T) (models.ScanJob, models.CTScan, []models.TreatmentPlan) {
+	t.Helper()
+	var job models.ScanJob
+	if err := f.db.Where("ct_scan_id = ?", f.scan.ID).First(&job).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	var scan models.CTScan
+	if err := f.db.First(&scan, f.scan.ID).Error; err != nil {
+		t.Fatalf("load scan: %v", err)
+	}
+	var plans []models.TreatmentPlan
+	if err := f.db.Preload("Items").Where("ct_scan_id = ?", f.scan.ID).Find(&plans).Error; err != nil {
+		t.Fatalf("load plans: %v", err)
+	}
+	return job, scan, plans
+}
+
+func TestScanWorkerSuccess(t *testing.T) {
+	f := newWorkerFixture(t, 0, nil)
+
+	f.runOnce(t, "worker-1", time.Now())
+
+	job, scan, plans := f.state(t)
+	if job.Status != models.ScanJobStatusSucceeded || job.Attempts != 1 || job.FinishedAt == nil || job.LockedBy != "" {
+		t.Errorf("job = %s after %d attempts (finished %v, locked by %q), want succeeded after 1",
+			job.Status, job.Attempts, job.FinishedAt, job.LockedBy)
+	}
+	if scan.Status != models.ScanStatusCompleted || !scan.AIProcessed {
+		t.Errorf("scan = %s, processed %v; want completed and processed", scan.Status, scan.AIProcessed)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("scan has %d plans, want 1", len(plans))
+	}
+	plan := plans[0]
+	if plan.PatientID != f.scan.PatientID || plan.Status != models.PlanStatusGenerated {
+		t.Errorf("plan patient %d status %s, want patient %d generated", plan.PatientID, plan.Status, f.scan.PatientID)
+	}
+	if n := len(plan.Items); n < 2 || n > 5 {
+		t.Errorf("plan has %d items, want the fake analyzer's 2-5", n)
+	}
+}
+
+func TestScanWorkerRetriesTransientFailure(t *testing.T) {
+	f := newWorkerFixture(t, 1, errors.New("provider unavailable"))
+
+	start := time.Now()
+	f.runOnce(t, "worker-1", start)
+
+	job, scan, plans := f.state(t)
+	if job.Status != models.ScanJobStatusQueued || job.LastError != "provider unavailable" || job.LockedBy != "" {
+		t.Errorf("job = %s, last error %q, locked by %q; want queued for retry", job.Status, job.LastError, job.LockedBy)
+	}
+	if !job.RunAt.After(start.Add(59 * time.Second)) {
+		t.Errorf("retry scheduled at %s, want after the one minute backoff", job.RunAt)
+	}
+	if scan.Status != models.ScanStatusProcessing {
+		t.Errorf("scan = %s while the job is queued for retry, want processing", scan.Status)
+	}
+	if len(plans) != 0 {
+		t.Errorf("failed attempt created %d plans", len(plans))
+	}
+
+	// The retry is not due before its backoff has passed
+	if _, err := f.repo.ClaimScanJob("worker-2", start, start.Add(-time.Minute)); !errors.Is(err, repository.ErrRecordNotFound) {
+		t.Fatalf("claimed a job before its retry was due: %v", err)
+	}
+
+	f.runOnce(t, "worker-2", job.RunAt)
+
+	job, scan, plans = f.state(t)
+	if job.Status != models.ScanJobStatusSucceeded || job.Attempts != 2 {
+		t.Errorf("job = %s after %d attempts, want succeeded after 2", job.Status, job.Attempts)
+	}
+	if scan.Status != models.ScanStatusCompleted {
+		t.Errorf("scan = %s, want completed", scan.Status)
+	}
+	if len(plans) != 1 {
+		t.Errorf("scan has %d plans, want 1", len(plans))
+	}
+}
+
+func TestScanWorkerDeadLettersAfterMaxAttempts(t *testing.T) {
+	f := newWorkerFixture(t, 100, errors.New("provider unavailable"))
+
+	now := time.Now()
+	for attempt := 1; attempt <= f.processor.cfg.MaxAttempts; attempt++ {
+		f.runOnce(t, "worker-1", now)
+		job, _, _ := f.state(t)
+		now = job.RunAt
+	}
+
+	job, scan, plans := f.state(t)
+	if job.Status != models.ScanJobStatusDead || job.Attempts != 3 || job.FinishedAt == nil {
+		t.Errorf("job = %s after %d attempts, want dead after 3", job.Status, job.Attempts)
+	}
+	if scan.Status != models.ScanStatusError || scan.ErrorReason != ScanErrorAnalysisFailed || scan.ErrorDetail != "provider unavailable" {
+		t.Errorf("scan = %s (%s: %s), want error analysis_failed", scan.Status, scan.ErrorReason, scan.ErrorDetail)
+	}
+	if len(plans) != 0 {
+		t.Errorf("dead job created %d plans", len(plans))
+	}
+	if f.analyzer.calls != 3 {
+		t.Errorf("analyzer called %d times, want 3", f.analyzer.calls)
+	}
+
+	if _, err := f.repo.ClaimScanJob("worker-1", now.Add(time.Hour), now); !errors.Is(err, repository.ErrRecordNotFound) {
+		t.Errorf("dead job was claimed again: %v", err)
+	}
+}
+
+func TestScanWorkerDeadLettersPermanentFailure(t *testing.T) {
+	f := newWorkerFixture(t, 1, analysis.Permanent(errors.New("unsupported modality")))
+
+	f.runOnce(t, "worker-1", time.Now())
+
+	job, scan, plans := f.state(t)
+	if job.Status != models.ScanJobStatusDead || job.Attempts != 1 {
+		t.Errorf("job = %s after %d attempts, want dead after 1", job.Status, job.Attempts)
+	}
+	if scan.Status != models.ScanStatusError || scan.ErrorReason != ScanErrorAnalysisFailed {
+		t.Errorf("scan = %s (%s), want error analysis_failed", scan.Status, scan.ErrorReason)
+	}
+	if len(plans) != 0 {
+		t.Errorf("dead job created %d plans", len(plans))
+	}
+}
+
+func TestScanWorkerRequiresAnonymizedCopy(t *testing.T) {
+	f := newWorkerFixture(t, 0, nil)
+	if err := f.db.Model(f.scan).Update("anonymized_key", "").Error; err != nil {
+		t.Fatalf("update scan: %v", err)
+	}
+
+	f.runOnce(t, "worker-1", time.Now())
+
+	job, scan, _ := f.state(t)
+	if job.Status != models.ScanJobStatusDead || scan.Status != models.ScanStatusError {
+		t.Errorf("job = %s, scan = %s; want dead and error", job.Status, scan.Status)
+	}
+	if f.analyzer.calls != 0 {
+		t.Errorf("analyzer called %d times for a scan without an anonymized copy", f.analyzer.calls)
+	}
+}
+
+func TestScanWorkerCreatesPlanOnce(t *testing.T) {
+	f := newWorkerFixture(t, 0, nil)
+
+	// Worker 1 claims the job and stalls past the job timeout, so worker 2
+	// reclaims it; both then finish their analysis
+	start := time.Now()
+	stale, err := f.repo.ClaimScanJob("worker-1", start, start.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("ClaimScanJob: %v", err)
+	}
+	later := start.Add(2 * time.Minute)
+	f.runOnce(t, "worker-2", later)
+	f.processor.process(context.Background(), stale)
+
+	job, _, plans := f.state(t)
+	if job.Status != models.ScanJobStatusSucceeded || job.Attempts != 2 {
+		t.Errorf("job = %s after %d attempts, want succeeded after 2", job.Status, job.Attempts)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("scan has %d plans, want exactly 1", len(plans))
+	}
+
+	// Completing directly with a lost lock is refused
+	plan := analysis.BuildPlan(f.scan, &analysis.Result{})
+	if err := f.repo.CompleteScanJob(stale, plan); !errors.Is(err, repository.ErrScanJobLost) {
+		t.Errorf("CompleteScanJob with a lost lock = %v, want ErrScanJobLost", err)
+	}
+
+	// Re-enqueueing a finished scan returns the finished job
+	again, err := f.repo.EnqueueScanJob(f.scan.ID, time.Now())
+	if err != nil {
+		t.Fatalf("EnqueueScanJob: %v", err)
+	}
+	if again.ID != job.ID || again.Status != models.ScanJobStatusSucceeded {
+		t.Errorf("re-enqueue returned job %d (%s), want the finished job %d", again.ID, again.Status, job.ID)
+	}
+
+	_, _, plans = f.state(t)
+	if len(plans) != 1 {
+		t.Errorf("scan has %d plans after re-enqueueing, want 1", len(plans))
+	}
+}
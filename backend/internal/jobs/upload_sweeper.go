@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"log"
+	"time"
+)
+
+// UploadSweeper expires abandoned resumable upload sessions and frees
+// the storage held by their chunks
+type UploadSweeper struct {
+	repo      *repository.Repository
+	store     storage.Storage
+	batchSize int
+}
+
+func NewUploadSweeper(repo *repository.Repository, store storage.Storage) *UploadSweeper {
+	return &UploadSweeper{
+		repo:      repo,
+		store:     store,
+		batchSize: 100,
+	}
+}
+
+// Sweep expires all active sessions whose expiry has passed
+func (s *UploadSweeper) Sweep(ctx context.Context) error {
+	for {
+		now := time.Now()
+		sessions, err := s.repo.GetExpiredUploadSessions(now, s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, session := range sessions {
+			// The session may have been completed or resumed since the
+			// batch was read; its chunks then belong to a live upload
+			expired, err := s.repo.ExpireUploadSession(session.ID, now)
+			if err != nil {
+				return err
+			}
+			if !expired {
+				continue
+			}
+
+			for _, chunk := range session.Chunks {
+				if err := s.store.Delete(ctx, chunk.StorageKey); err != nil {
+					log.Printf("failed to delete chunk %s: %v", chunk.StorageKey, err)
+				}
+			}
+			if err := s.repo.DeleteUploadChunks(session.ID); err != nil {
+				return err
+			}
+
+			log.Printf("Expired upload session %d (%d/%d bytes received)", session.ID, session.Offset, session.TotalSize)
+		}
+
+		if len(sessions) < s.batchSize {
+			return nil
+		}
+	}
+}
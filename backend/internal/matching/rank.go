@@ -0,0 +1,133 @@
+package matching
+
+import (
+	"dental-marketplace/backend/internal/geo"
+	"dental-marketplace/backend/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// Weights of the relevance components; they sum to 1
+const (
+	urgencyWeight  = 0.5
+	distanceWeight = 0.3
+	ageWeight      = 0.2
+)
+
+const (
+	// distanceScaleKm is the distance at which the distance score halves
+	distanceScaleKm = 5.0
+	// ageHalfLife is the plan age at which the freshness score halves
+	ageHalfLife = 7 * 24 * time.Hour
+	// unknownDistanceScore is used when either side has no coordinates
+	unknownDistanceScore = 0.5
+)
+
+// urgencyScores maps item urgency codes to their contribution
+var urgencyScores = map[string]float64{
+	"high":   1,
+	"medium": 0.5,
+	"low":    0.2,
+}
+
+// Match is a treatment plan scored for one clinic
+type Match struct {
+	Plan       models.TreatmentPlan
+	Score      float64
+	DistanceKm *float64
+}
+
+// Locations resolves district centroids by city and district name
+type Locations map[string]geo.Point
+
+// NewLocations indexes districts that have coordinates. Districts must be
+// loaded with their City.
+func NewLocations(districts []models.District) Locations {
+	locations := make(Locations, len(districts))
+	for _, d := range districts {
+		if point, ok := geo.NewPoint(d.Latitude, d.Longitude); ok {
+			locations[locationKey(d.City.Name, d.Name)] = point
+		}
+	}
+	return locations
+}
+
+// Lookup returns the centroid of a district
+func (l Locations) Lookup(city, district string) (geo.Point, bool) {
+	point, ok := l[locationKey(city, district)]
+	return point, ok
+}
+
+func locationKey(city, district string) string {
+	return city + "\x00" + district
+}
+
+// Rank scores plans for a clinic and orders them best first. Ties keep the
+// newest plan first.
+func Rank(plans []models.TreatmentPlan, clinic *models.Clinic, locations Locations, now time.Time) []Match {
+	clinicPoint, clinicLocated := geo.NewPoint(clinic.Latitude, clinic.Longitude)
+	if !clinicLocated {
+		clinicPoint, clinicLocated = locations.Lookup(clinic.City, clinic.District)
+	}
+
+	matches := make([]Match, len(plans))
+	for i, plan := range plans {
+		match := Match{Plan: plan}
+
+		distance := unknownDistanceScore
+		if planPoint, ok := locations.Lookup(plan.TargetCity, plan.TargetDistrict); ok && clinicLocated {
+			km := geo.DistanceKm(clinicPoint, planPoint)
+			match.DistanceKm = &km
+			distance = distanceScaleKm / (distanceScaleKm + km)
+		}
+
+		match.Score = urgencyWeight*urgencyScore(plan.Items) +
+			distanceWeight*distance +
+			ageWeight*freshnessScore(planAge(&plan, now))
+		matches[i] = match
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Plan.CreatedAt.After(matches[j].Plan.CreatedAt)
+	})
+	return matches
+}
+
+// urgencyScore blends the most urgent item with the average so one urgent
+// item outweighs many routine ones
+func urgencyScore(items []models.TreatmentItem) float64 {
+	if len(items) == 0 {
+		return 0
+	}
+
+	var top, sum float64
+	for _, item := range items {
+		score := urgencyScores[item.Urgency]
+		sum += score
+		if score > top {
+			top = score
+		}
+	}
+	return 0.6*top + 0.4*sum/float64(len(items))
+}
+
+// planAge measures from when the plan was published to clinics
+func planAge(plan *models.TreatmentPlan, now time.Time) time.Duration {
+	published := plan.CreatedAt
+	if plan.OffersRequestedAt != nil {
+		published = *plan.OffersRequestedAt
+	}
+	if now.Before(published) {
+		return 0
+	}
+	return now.Sub(published)
+}
+
+// freshnessScore decays exponentially with age
+func freshnessScore(age time.Duration) float64 {
+	return math.Exp2(-float64(age) / float64(ageHalfLife))
+}
@@ -133,9 +133,33 @@ type District struct {
 	City      City           `gorm:"foreignKey:CityID" json:"city,omitempty"`
 	Code      string         `gorm:"unique;not null" json:"code"`
 	Name      string         `gorm:"not null" json:"name"`
+	Latitude  *float64       `json:"latitude,omitempty"` // centroid, used for distance ranking
+	Longitude *float64       `json:"longitude,omitempty"`
 	IsActive  bool           `gorm:"default:true" json:"is_active"`
 	SortOrder int            `gorm:"default:0" json:"sort_order"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+// Procedure represents a catalog procedure with a canonical service code.
+// Price list rows and treatment plan items refer to it by Code.
+type Procedure struct {
+	ID             uint               `gorm:"primarykey" json:"id"`
+	Code           string             `gorm:"unique;not null" json:"code"`
+	Name           string             `gorm:"not null" json:"name"`
+	Specialization string             `gorm:"not null;index" json:"specialization"`
+	Synonyms       []ProcedureSynonym `gorm:"foreignKey:ProcedureID" json:"synonyms,omitempty"`
+	IsActive       bool               `gorm:"default:true" json:"is_active"`
+	SortOrder      int                `gorm:"default:0" json:"sort_order"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt     `gorm:"index" json:"-"`
+}
+
+// ProcedureSynonym is an alternative name clinics use for a procedure
+type ProcedureSynonym struct {
+	ID          uint   `gorm:"primarykey" json:"-"`
+	ProcedureID uint   `gorm:"not null;uniqueIndex:idx_procedure_synonym" json:"-"`
+	Name        string `gorm:"not null;uniqueIndex:idx_procedure_synonym" json:"name"`
+}
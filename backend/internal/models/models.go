@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -30,12 +31,32 @@ const (
 	ScanStatusError      = "error"
 )
 
+// Upload session statuses
+const (
+	UploadStatusActive    = "active"
+	UploadStatusCompleted = "completed"
+	UploadStatusExpired   = "expired"
+	UploadStatusAborted   = "aborted"
+	UploadStatusFailed    = "failed"
+)
+
+// Scan processing job statuses
+const (
+	ScanJobStatusQueued    = "queued"
+	ScanJobStatusRunning   = "running"
+	ScanJobStatusSucceeded = "succeeded"
+	ScanJobStatusDead      = "dead" // retries exhausted
+)
+
 // Treatment plan statuses
 const (
 	PlanStatusGenerated = "generated"
 	PlanStatusOffersRequested = "offers_requested"
 	PlanStatusOffersReceived = "offers_received"
 	PlanStatusOfferSelected = "offer_selected"
+	PlanStatusInTreatment = "in_treatment"
+	PlanStatusCompleted = "completed"
+	PlanStatusCancelled = "cancelled"
 )
 
 // Offer statuses
@@ -44,6 +65,8 @@ const (
 	OfferStatusSent = "sent"
 	OfferStatusAccepted = "accepted"
 	OfferStatusRejected = "rejected"
+	OfferStatusExpired = "expired"
+	OfferStatusWithdrawn = "withdrawn"
 )
 
 // Appointment statuses
@@ -55,6 +78,19 @@ const (
 	AppointmentStatusNoShow = "no_show"
 )
 
+// Appointment change actions
+const (
+	AppointmentChangeRescheduled = "rescheduled"
+	AppointmentChangeCancelled = "cancelled"
+	AppointmentChangeStatus = "status_changed"
+)
+
+// Clinic resource kinds
+const (
+	ResourceKindChair  = "chair"
+	ResourceKindDoctor = "doctor"
+)
+
 // Lead statuses
 const (
 	LeadStatusNew = "new"
@@ -65,6 +101,47 @@ const (
 	LeadStatusRejected = "rejected"
 )
 
+// Lead contact channels
+const (
+	ContactChannelPhone = "phone"
+	ContactChannelEmail = "email"
+	ContactChannelMessenger = "messenger"
+	ContactChannelVisit = "visit"
+)
+
+// Review moderation statuses
+const (
+	ReviewStatusPending = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// Complaint statuses
+const (
+	ComplaintStatusOpen = "open"
+	ComplaintStatusInProgress = "in_progress"
+	ComplaintStatusResolved = "resolved"
+	ComplaintStatusClosed = "closed"
+)
+
+// Complaint timeline event kinds
+const (
+	ComplaintEventCreated = "created"
+	ComplaintEventAssigned = "assigned"
+	ComplaintEventStatusChanged = "status_changed"
+	ComplaintEventNote = "note"
+	ComplaintEventResponseRequested = "response_requested"
+	ComplaintEventClinicResponded = "clinic_responded"
+	ComplaintEventAttachmentAdded = "attachment_added"
+)
+
+// Notification kinds
+const (
+	NotificationComplaintResponseRequested = "complaint_response_requested"
+	NotificationComplaintClinicResponded = "complaint_clinic_responded"
+	NotificationComplaintResolved = "complaint_resolved"
+)
+
 // User represents all system users (patients, clinics, regulators)
 type User struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
@@ -129,6 +206,15 @@ type Clinic struct {
 	District string `json:"district"`
 	Address  string `json:"address"`
 	
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	
+	PriceSegment string `json:"price_segment"` // economy, medium, premium
+	TimeZone     string `gorm:"not null;default:'Europe/Moscow'" json:"time_zone"` // IANA zone of working hours
+	
+	// Patients cannot cancel or reschedule appointments starting within this many hours
+	CancellationWindowHours int `gorm:"not null;default:24" json:"cancellation_window_hours"`
+	
 	// Capabilities
 	HasTherapy      bool `gorm:"default:true" json:"has_therapy"`
 	HasOrthopedics  bool `gorm:"default:true" json:"has_orthopedics"`
@@ -169,12 +255,111 @@ type CTScan struct {
 	
 	PatientID   uint      `gorm:"not null;index" json:"patient_id"`
 	UploadDate  time.Time `json:"upload_date"`
-	FileURL     string    `json:"file_url"`
+	FileURL     string    `json:"-"` // legacy raw location, never exposed to clients
 	Status      string    `gorm:"default:'uploaded'" json:"status"` // uploaded, processing, completed, error
 	AIProcessed bool      `gorm:"default:false" json:"ai_processed"`
 	
+	// Stored file
+	StorageKey  string `gorm:"index" json:"-"`
+	FileName    string `json:"file_name"`
+	FileSize    int64  `json:"file_size"`
+	ContentType string `json:"content_type"`
+	Checksum    string `json:"checksum"` // hex-encoded SHA-256
+	
+	// De-identified copy, the only file ever shared with clinics
+	AnonymizedKey      string     `json:"-"`
+	AnonymizedChecksum string     `json:"-"`
+	AnonymizedAt       *time.Time `json:"anonymized_at,omitempty"`
+	
+	// Short-lived signed download URL, filled in per response
+	DownloadURL string `gorm:"-" json:"download_url,omitempty"`
+	
+	// Machine-readable cause when Status is error (e.g. not_dicom, truncated)
+	ErrorReason string `json:"error_reason,omitempty"`
+	ErrorDetail string `json:"error_detail,omitempty"`
+	
+	// Relationships
+	Metadata      *CTScanMetadata `gorm:"foreignKey:CTScanID" json:"metadata,omitempty"`
+	Job           *ScanJob        `gorm:"foreignKey:CTScanID" json:"job,omitempty"`
+	TreatmentPlan *TreatmentPlan  `gorm:"foreignKey:CTScanID" json:"treatment_plan,omitempty"`
+}
+
+// CTScanMetadata DICOM header attributes extracted from an uploaded scan
+type CTScanMetadata struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	CTScanID          uint       `gorm:"uniqueIndex;not null" json:"ct_scan_id"`
+	StudyInstanceUID  string     `gorm:"index" json:"study_instance_uid"`
+	StudyDate         *time.Time `gorm:"index" json:"study_date"`
+	Modality          string     `gorm:"index" json:"modality"`
+	Manufacturer      string     `gorm:"index" json:"manufacturer"`
+	ManufacturerModel string     `json:"manufacturer_model"`
+	TransferSyntaxUID string     `json:"transfer_syntax_uid"`
+	
+	// Geometry in millimetres
+	SliceThickness float64 `json:"slice_thickness"`
+	VoxelSizeX     float64 `json:"voxel_size_x"`
+	VoxelSizeY     float64 `json:"voxel_size_y"`
+	VoxelSizeZ     float64 `json:"voxel_size_z"`
+	Rows           int     `json:"rows"`
+	Columns        int     `json:"columns"`
+	
+	SeriesCount   int `json:"series_count"`
+	InstanceCount int `json:"instance_count"`
+	FrameCount    int `json:"frame_count"`
+}
+
+// ScanJob queued AI analysis of a CT scan
+type ScanJob struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	CTScanID   uint       `gorm:"uniqueIndex;not null" json:"ct_scan_id"`
+	Status     string     `gorm:"not null;index:idx_scan_job_pending,priority:1" json:"status"` // queued, running, succeeded, dead
+	RunAt      time.Time  `gorm:"not null;index:idx_scan_job_pending,priority:2" json:"run_at"` // next attempt, pushed back after failures
+	Attempts   int        `gorm:"default:0" json:"attempts"`
+	LastError  string     `json:"last_error,omitempty"`
+	LockedBy   string     `json:"-"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// UploadSession tracks a resumable chunked CT scan upload
+type UploadSession struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	PatientID        uint      `gorm:"not null;index" json:"patient_id"`
+	FileName         string    `json:"file_name"`
+	ContentType      string    `json:"content_type"`
+	TotalSize        int64     `gorm:"not null" json:"total_size"`
+	Offset           int64     `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	ExpectedChecksum string    `json:"expected_checksum,omitempty"` // optional hex SHA-256 of the whole file
+	Status           string    `gorm:"default:'active';index" json:"status"` // active, completed, expired, aborted, failed
+	ExpiresAt        time.Time `gorm:"index" json:"expires_at"`
+	CTScanID         *uint     `json:"ct_scan_id,omitempty"`
+	
 	// Relationships
-	TreatmentPlan *TreatmentPlan `gorm:"foreignKey:CTScanID" json:"treatment_plan,omitempty"`
+	Chunks []UploadChunk `gorm:"foreignKey:UploadSessionID" json:"chunks,omitempty"`
+}
+
+// UploadChunk single received part of an upload session
+type UploadChunk struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	UploadSessionID uint   `gorm:"not null;uniqueIndex:idx_upload_chunk_offset" json:"upload_session_id"`
+	Offset          int64  `gorm:"column:chunk_offset;not null;uniqueIndex:idx_upload_chunk_offset" json:"offset"`
+	Size            int64  `gorm:"not null" json:"size"`
+	Checksum        string `gorm:"not null" json:"checksum"` // hex SHA-256 of the chunk
+	StorageKey      string `gorm:"not null" json:"-"`
 }
 
 // TreatmentPlan generated by AI
@@ -188,6 +373,13 @@ type TreatmentPlan struct {
 	CTScanID  uint   `gorm:"uniqueIndex" json:"ct_scan_id"`
 	Status    string `gorm:"default:'generated'" json:"status"`
 	
+	// Targeting, snapshotted from the patient's search criteria when offers are requested
+	TargetCity         string     `gorm:"not null;default:'';index" json:"target_city"`
+	TargetDistrict     string     `gorm:"not null;default:''" json:"target_district"`
+	TargetPriceSegment string     `gorm:"not null;default:''" json:"target_price_segment"`
+	OffersRequestedAt  *time.Time `json:"offers_requested_at"`
+	OfferDeadline      *time.Time `json:"offer_deadline"`
+	
 	// Summary by specialization
 	RequiresTherapy      bool `json:"requires_therapy"`
 	RequiresOrthopedics  bool `json:"requires_orthopedics"`
@@ -208,8 +400,23 @@ type TreatmentPlan struct {
 	PeriodonticsMaxCost int `json:"periodontics_max_cost"`
 	
 	// Relationships
-	Items  []TreatmentItem `gorm:"foreignKey:TreatmentPlanID" json:"items,omitempty"`
-	Offers []ClinicOffer   `gorm:"foreignKey:TreatmentPlanID" json:"offers,omitempty"`
+	Patient       *Patient                    `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Items         []TreatmentItem             `gorm:"foreignKey:TreatmentPlanID" json:"items,omitempty"`
+	Offers        []ClinicOffer               `gorm:"foreignKey:TreatmentPlanID" json:"offers,omitempty"`
+	StatusHistory []TreatmentPlanStatusChange `gorm:"foreignKey:TreatmentPlanID" json:"status_history,omitempty"`
+}
+
+// TreatmentPlanStatusChange records one transition of a plan's status
+type TreatmentPlanStatusChange struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	TreatmentPlanID uint   `gorm:"not null;index" json:"treatment_plan_id"`
+	FromStatus      string `gorm:"not null" json:"from_status"`
+	ToStatus        string `gorm:"not null" json:"to_status"`
+	ActorUserID     *uint  `gorm:"index" json:"actor_user_id,omitempty"` // nil for system transitions
+	ActorRole       string `json:"actor_role"` // patient, clinic, regulator, system
+	Reason          string `json:"reason,omitempty"`
 }
 
 // TreatmentItem individual procedure in treatment plan
@@ -224,6 +431,7 @@ type TreatmentItem struct {
 	ToothNumber     string `json:"tooth_number"` // International notation: 11-48
 	Diagnosis       string `json:"diagnosis"`
 	Procedure       string `json:"procedure"`
+	ProcedureCode   string `gorm:"index" json:"procedure_code"` // catalog code, see Procedure
 	Urgency         string `json:"urgency"` // high, medium, low
 	EstimatedCost   int    `json:"estimated_cost"`
 }
@@ -238,10 +446,83 @@ type PriceList struct {
 	ClinicID       uint   `gorm:"not null;index" json:"clinic_id"`
 	Specialization string `gorm:"not null" json:"specialization"`
 	ServiceName    string `gorm:"not null" json:"service_name"`
+	ProcedureCode  string `gorm:"index" json:"procedure_code"` // catalog code, see Procedure
 	Price          int    `gorm:"not null" json:"price"`
 	WarrantyYears  int    `json:"warranty_years"`
 }
 
+// ClinicDiscount is a percentage discount a clinic applies to auto-quoted offers
+type ClinicDiscount struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	ClinicID       uint   `gorm:"not null;index" json:"clinic_id"`
+	Name           string `gorm:"not null" json:"name"`
+	Specialization string `json:"specialization"` // empty applies to every specialization
+	Percent        int    `gorm:"not null" json:"percent"`
+	MinPlanTotal   int    `json:"min_plan_total"` // list price of the whole offer needed to qualify
+	IsActive       bool   `gorm:"not null" json:"is_active"`
+}
+
+// InstallmentProduct is an installment plan a clinic offers for treatment.
+// An offer allows products whose term fits its InstallmentMonths.
+type InstallmentProduct struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	ClinicID           uint    `gorm:"not null;index" json:"clinic_id"`
+	Name               string  `gorm:"not null" json:"name"`
+	TermMonths         int     `gorm:"not null" json:"term_months"`
+	AnnualInterestRate float64 `json:"annual_interest_rate"` // percent per year on the outstanding balance
+	MarkupPercent      float64 `json:"markup_percent"`       // flat markup on the treatment cost
+	DownPaymentPercent float64 `json:"down_payment_percent"` // share paid when the offer is accepted
+	IsActive           bool    `gorm:"not null" json:"is_active"`
+}
+
+// PaymentSchedule is the amortization schedule of an offer under an
+// installment product. It is stored when the patient accepts the offer.
+type PaymentSchedule struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	ClinicOfferID        uint  `gorm:"not null;uniqueIndex" json:"clinic_offer_id"`
+	InstallmentProductID *uint `json:"installment_product_id"`
+	
+	// Product terms at the time of acceptance
+	ProductName        string  `json:"product_name"`
+	TermMonths         int     `json:"term_months"`
+	AnnualInterestRate float64 `json:"annual_interest_rate"`
+	MarkupPercent      float64 `json:"markup_percent"`
+	
+	TreatmentCost  int       `json:"treatment_cost"` // the offer's total cost
+	Markup         int       `json:"markup"`
+	DownPayment    int       `json:"down_payment"`
+	FinancedAmount int       `json:"financed_amount"`
+	MonthlyPayment int       `json:"monthly_payment"`
+	TotalInterest  int       `json:"total_interest"`
+	TotalPayable   int       `json:"total_payable"` // down payment plus all installments
+	StartDate      time.Time `json:"start_date"`
+	
+	Payments []ScheduledPayment `gorm:"foreignKey:PaymentScheduleID" json:"payments"`
+}
+
+// ScheduledPayment is one monthly installment of a payment schedule
+type ScheduledPayment struct {
+	ID uint `gorm:"primarykey" json:"id"`
+	
+	PaymentScheduleID uint      `gorm:"not null;uniqueIndex:idx_scheduled_payment_number" json:"payment_schedule_id"`
+	Number            int       `gorm:"not null;uniqueIndex:idx_scheduled_payment_number" json:"number"`
+	DueDate           time.Time `json:"due_date"`
+	Amount            int       `json:"amount"`
+	Principal         int       `json:"principal"`
+	Interest          int       `json:"interest"`
+	Balance           int       `json:"balance"` // outstanding after this payment
+}
+
 // ClinicOffer from clinic to patient
 type ClinicOffer struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
@@ -251,24 +532,85 @@ type ClinicOffer struct {
 	
 	TreatmentPlanID uint   `gorm:"not null;index" json:"treatment_plan_id"`
 	ClinicID        uint   `gorm:"not null;index" json:"clinic_id"`
-	Status          string `gorm:"default:'pending'" json:"status"` // pending, sent, accepted, rejected
+	Status          string `gorm:"default:'pending'" json:"status"` // pending, sent, accepted, rejected, expired, withdrawn
+	Version         int    `gorm:"not null;default:1" json:"version"` // bumped by each revision of a sent offer
+	
+	ValidUntil  *time.Time `gorm:"index" json:"valid_until"` // set when sent
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`
 	
-	// Costs by specialization
+	// Costs by specialization, computed from Items
 	TherapyCost      int `json:"therapy_cost"`
 	OrthopedicsCost  int `json:"orthopedics_cost"`
 	SurgeryCost      int `json:"surgery_cost"`
 	HygieneCost      int `json:"hygiene_cost"`
 	PeriodonticsCost int `json:"periodontics_cost"`
 	TotalCost        int `json:"total_cost"`
+	DiscountAmount   int `json:"discount_amount"` // list price minus TotalCost
 	
 	// Terms
 	EstimatedDuration string `json:"estimated_duration"` // e.g., "2-3 months"
-	InstallmentMonths int    `json:"installment_months"`
+	InstallmentMonths int    `json:"installment_months"` // longest installment term offered; 0 for none
 	WarrantyDetails   string `json:"warranty_details"`
 	Notes             string `json:"notes"`
+	WaitDays          *int   `json:"wait_days"` // days until treatment can start; nil if not stated
+	
+	// Relationships
+	Clinic          Clinic                `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	TreatmentPlan   *TreatmentPlan        `gorm:"foreignKey:TreatmentPlanID" json:"treatment_plan,omitempty"`
+	Items           []OfferItem           `gorm:"foreignKey:ClinicOfferID" json:"items,omitempty"`
+	Revisions       []ClinicOfferRevision `gorm:"foreignKey:ClinicOfferID" json:"revisions,omitempty"`
+	PaymentSchedule *PaymentSchedule      `gorm:"foreignKey:ClinicOfferID" json:"payment_schedule,omitempty"` // chosen on acceptance
+	
+	// Installment schedules the patient can choose from, filled in per response
+	InstallmentOptions []PaymentSchedule `gorm:"-" json:"installment_options,omitempty"`
+}
+
+// ClinicOfferRevision is an immutable copy of a sent offer taken before it
+// was revised
+type ClinicOfferRevision struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	ClinicOfferID uint `gorm:"not null;uniqueIndex:idx_offer_revision_version" json:"clinic_offer_id"`
+	Version       int  `gorm:"not null;uniqueIndex:idx_offer_revision_version" json:"version"`
+	
+	TherapyCost       int        `json:"therapy_cost"`
+	OrthopedicsCost   int        `json:"orthopedics_cost"`
+	SurgeryCost       int        `json:"surgery_cost"`
+	HygieneCost       int        `json:"hygiene_cost"`
+	PeriodonticsCost  int        `json:"periodontics_cost"`
+	TotalCost         int        `json:"total_cost"`
+	DiscountAmount    int        `json:"discount_amount"`
+	EstimatedDuration string     `json:"estimated_duration"`
+	InstallmentMonths int        `json:"installment_months"`
+	WarrantyDetails   string     `json:"warranty_details"`
+	Notes             string     `json:"notes"`
+	WaitDays          *int       `json:"wait_days"`
+	ValidUntil        *time.Time `json:"valid_until"`
+	
+	Items json.RawMessage `gorm:"type:jsonb" json:"items"` // the offer's items at this version
+}
+
+// OfferItem prices one treatment plan item in a clinic offer, or excludes it
+type OfferItem struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	ClinicOfferID   uint   `gorm:"not null;uniqueIndex:idx_offer_item_treatment_item" json:"clinic_offer_id"`
+	TreatmentItemID uint   `gorm:"not null;uniqueIndex:idx_offer_item_treatment_item;index" json:"treatment_item_id"`
+	PriceListID     *uint  `gorm:"index" json:"price_list_id,omitempty"`
+	Specialization  string `gorm:"not null" json:"specialization"` // copied from the treatment item
+	Excluded        bool   `gorm:"default:false" json:"excluded"` // clinic does not offer this item
+	ListPrice       int    `json:"list_price"` // price before clinic discounts
+	Price           int    `json:"price"`
+	WarrantyYears   int    `json:"warranty_years"`
+	Notes           string `json:"notes"`
 	
 	// Relationships
-	Clinic Clinic `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	TreatmentItem *TreatmentItem `gorm:"foreignKey:TreatmentItemID" json:"treatment_item,omitempty"`
+	PriceList     *PriceList     `gorm:"foreignKey:PriceListID" json:"price_list,omitempty"`
 }
 
 // Appointment between patient and clinic
@@ -283,14 +625,171 @@ type Appointment struct {
 	TreatmentPlanID uint      `json:"treatment_plan_id"`
 	ClinicOfferID   uint      `json:"clinic_offer_id"`
 	
-	AppointmentDate time.Time `json:"appointment_date"`
+	// A resource holds at most one live appointment per start time
+	ResourceID      *uint     `gorm:"uniqueIndex:idx_appointment_resource_slot,where:status <> 'cancelled' AND deleted_at IS NULL" json:"resource_id"`
+	AppointmentDate time.Time `gorm:"uniqueIndex:idx_appointment_resource_slot,where:status <> 'cancelled' AND deleted_at IS NULL" json:"appointment_date"`
+	DurationMinutes int       `gorm:"not null;default:60" json:"duration_minutes"`
 	Specialization  string    `json:"specialization"`
 	Status          string    `gorm:"default:'scheduled'" json:"status"`
 	Notes           string    `json:"notes"`
 	
 	// Relationships
-	Patient Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
-	Clinic  Clinic  `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	Patient  Patient             `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Clinic   Clinic              `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	Resource *ClinicResource     `gorm:"foreignKey:ResourceID" json:"resource,omitempty"`
+	History  []AppointmentChange `gorm:"foreignKey:AppointmentID" json:"history,omitempty"`
+}
+
+// AppointmentChange records one reschedule, cancellation or status change of
+// an appointment
+type AppointmentChange struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	AppointmentID  uint       `gorm:"not null;index" json:"appointment_id"`
+	Action         string     `gorm:"not null" json:"action"` // rescheduled, cancelled, status_changed
+	FromStatus     string     `json:"from_status"`
+	ToStatus       string     `json:"to_status"`
+	FromResourceID *uint      `json:"from_resource_id,omitempty"`
+	ToResourceID   *uint      `json:"to_resource_id,omitempty"`
+	FromDate       time.Time  `json:"from_date"`
+	ToDate         *time.Time `json:"to_date,omitempty"` // set when rescheduled
+	ActorUserID    *uint      `gorm:"index" json:"actor_user_id,omitempty"`
+	ActorRole      string     `json:"actor_role"` // patient, clinic, system
+	Reason         string     `json:"reason,omitempty"`
+}
+
+// CalendarToken grants read-only access to a user's appointment feed. Only
+// the token's SHA-256 hash is stored.
+type CalendarToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// ClinicResource is a chair or doctor appointments are booked on
+type ClinicResource struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	ClinicID       uint   `gorm:"not null;index" json:"clinic_id"`
+	Kind           string `gorm:"not null" json:"kind"` // chair, doctor
+	Name           string `gorm:"not null" json:"name"`
+	Specialization string `json:"specialization"` // empty serves every specialization
+	IsActive       bool   `gorm:"not null" json:"is_active"`
+}
+
+// ClinicWorkingHours is one opening interval on a weekday, in the clinic's
+// time zone. Rows with a ResourceID are that resource's own hours; a
+// resource without its own rows follows the clinic's hours.
+type ClinicWorkingHours struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	ClinicID   uint   `gorm:"not null;index" json:"clinic_id"`
+	ResourceID *uint  `gorm:"index" json:"resource_id"`
+	Weekday    int    `gorm:"not null" json:"weekday"`   // 0 = Sunday
+	OpensAt    string `gorm:"not null" json:"opens_at"`  // HH:MM
+	ClosesAt   string `gorm:"not null" json:"closes_at"` // HH:MM
+}
+
+// ClinicBlockedDate closes the whole clinic, or one resource, for a day
+type ClinicBlockedDate struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	ClinicID   uint      `gorm:"not null;index" json:"clinic_id"`
+	ResourceID *uint     `json:"resource_id"` // nil blocks the whole clinic
+	Date       time.Time `gorm:"type:date;not null" json:"date"`
+	Reason     string    `json:"reason"`
+}
+
+// ClinicStaff is a clinic employee leads and follow-up tasks are assigned to
+type ClinicStaff struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	ClinicID uint   `gorm:"not null;index" json:"clinic_id"`
+	Name     string `gorm:"not null" json:"name"`
+	Position string `json:"position"` // e.g. administrator, coordinator
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	IsActive bool   `gorm:"not null;default:true" json:"is_active"`
+}
+
+// Lead tracks a patient who accepted a clinic's offer through the clinic's
+// pipeline, from first contact to completed treatment
+type Lead struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	ClinicID        uint       `gorm:"not null;index" json:"clinic_id"`
+	ClinicOfferID   uint       `gorm:"not null;uniqueIndex" json:"clinic_offer_id"`
+	TreatmentPlanID uint       `gorm:"not null;index" json:"treatment_plan_id"`
+	PatientID       uint       `gorm:"not null;index" json:"patient_id"`
+	Status          string     `gorm:"not null;default:'new';index" json:"status"`
+	AssignedStaffID *uint      `gorm:"index" json:"assigned_staff_id"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	ConvertedAt     *time.Time `json:"converted_at,omitempty"` // treatment started
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`    // treatment completed or lead rejected
+	
+	// Relationships
+	Offer         *ClinicOffer       `gorm:"foreignKey:ClinicOfferID" json:"offer,omitempty"`
+	Patient       *Patient           `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	AssignedStaff *ClinicStaff       `gorm:"foreignKey:AssignedStaffID" json:"assigned_staff,omitempty"`
+	Tasks         []LeadTask         `gorm:"foreignKey:LeadID" json:"tasks,omitempty"`
+	Contacts      []LeadContact      `gorm:"foreignKey:LeadID" json:"contacts,omitempty"`
+	StatusHistory []LeadStatusChange `gorm:"foreignKey:LeadID" json:"status_history,omitempty"`
+}
+
+// LeadStatusChange records one transition of a lead's status
+type LeadStatusChange struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	LeadID      uint   `gorm:"not null;index" json:"lead_id"`
+	FromStatus  string `gorm:"not null" json:"from_status"`
+	ToStatus    string `gorm:"not null" json:"to_status"`
+	ActorUserID *uint  `gorm:"index" json:"actor_user_id,omitempty"` // nil for system transitions
+	ActorRole   string `json:"actor_role"` // clinic, patient, system
+	Reason      string `json:"reason,omitempty"`
+}
+
+// LeadTask is a follow-up the clinic owes a lead
+type LeadTask struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	LeadID          uint       `gorm:"not null;index" json:"lead_id"`
+	AssignedStaffID *uint      `gorm:"index" json:"assigned_staff_id"`
+	Title           string     `gorm:"not null" json:"title"`
+	Notes           string     `json:"notes"`
+	DueAt           time.Time  `gorm:"not null;index" json:"due_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+}
+
+// LeadContact is one entry in a lead's contact log
+type LeadContact struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	LeadID      uint      `gorm:"not null;index" json:"lead_id"`
+	StaffID     *uint     `gorm:"index" json:"staff_id"`
+	Channel     string    `gorm:"not null" json:"channel"` // phone, email, messenger, visit
+	Summary     string    `gorm:"not null" json:"summary"`
+	ContactedAt time.Time `gorm:"not null" json:"contacted_at"`
 }
 
 // Review patient feedback
@@ -306,9 +805,35 @@ type Review struct {
 	Comment   string `json:"comment"`
 	IsPublic  bool   `gorm:"default:false" json:"is_public"`
 	
+	// Verified reviews are tied to a completed appointment, one review each
+	AppointmentID *uint      `gorm:"uniqueIndex" json:"appointment_id,omitempty"`
+	VerifiedVisit bool       `gorm:"not null;default:false" json:"verified_visit"`
+	EditedAt      *time.Time `json:"edited_at,omitempty"`
+	
+	// Reviews are published once a regulator approves them
+	Status           string     `gorm:"not null;default:'pending';index" json:"status"` // pending, approved, rejected
+	ModerationFlags  string     `json:"moderation_flags,omitempty"`                     // comma-separated auto-flags
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	ModeratorUserID  *uint      `json:"moderator_user_id,omitempty"`
+	
 	// Relationships
-	Patient Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
-	Clinic  Clinic  `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	Patient     Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Clinic      Clinic       `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	Appointment *Appointment `gorm:"foreignKey:AppointmentID" json:"appointment,omitempty"`
+	Reply       *ReviewReply `gorm:"foreignKey:ReviewID" json:"reply,omitempty"`
+	Attachments []Attachment `gorm:"foreignKey:ReviewID" json:"attachments,omitempty"`
+}
+
+// ReviewReply is a clinic's public answer to a review, one per review
+type ReviewReply struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	
+	ReviewID uint   `gorm:"not null;uniqueIndex" json:"review_id"`
+	ClinicID uint   `gorm:"not null;index" json:"clinic_id"`
+	Text     string `gorm:"not null" json:"text"`
 }
 
 // Complaint tracking
@@ -325,9 +850,84 @@ type Complaint struct {
 	Status      string `gorm:"default:'open'" json:"status"` // open, in_progress, resolved, closed
 	Resolution  string `json:"resolution"`
 	
+	// Case handling
+	AssignedUserID *uint      `gorm:"index" json:"assigned_user_id"` // regulator user
+	DueAt          *time.Time `gorm:"index" json:"due_at"`           // resolution SLA deadline
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	
+	// Clinic response step
+	ClinicResponseRequestedAt *time.Time `json:"clinic_response_requested_at,omitempty"`
+	ClinicResponseDueAt       *time.Time `json:"clinic_response_due_at,omitempty"`
+	ClinicResponse            string     `json:"clinic_response,omitempty"`
+	ClinicRespondedAt         *time.Time `json:"clinic_responded_at,omitempty"`
+	
+	// SLA flags, computed when the complaint is loaded
+	Overdue               bool `gorm:"-" json:"overdue"`
+	ClinicResponseOverdue bool `gorm:"-" json:"clinic_response_overdue"`
+	
 	// Relationships
-	Patient Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
-	Clinic  Clinic  `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	Patient      Patient          `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Clinic       Clinic           `gorm:"foreignKey:ClinicID" json:"clinic,omitempty"`
+	AssignedUser *User            `gorm:"foreignKey:AssignedUserID" json:"assigned_user,omitempty"`
+	Events       []ComplaintEvent `gorm:"foreignKey:ComplaintID" json:"events,omitempty"`
+	Attachments  []Attachment     `gorm:"foreignKey:ComplaintID" json:"attachments,omitempty"`
+}
+
+// ComplaintEvent is an entry in a complaint's activity timeline. Internal
+// events (notes, assignments) are shown to regulators only.
+type ComplaintEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	ComplaintID    uint   `gorm:"not null;index" json:"complaint_id"`
+	Kind           string `gorm:"not null" json:"kind"` // created, assigned, status_changed, note, response_requested, clinic_responded, attachment_added
+	FromStatus     string `json:"from_status,omitempty"`
+	ToStatus       string `json:"to_status,omitempty"`
+	AssignedUserID *uint  `json:"assigned_user_id,omitempty"`
+	Body           string `json:"body,omitempty"`
+	Internal       bool   `gorm:"not null;default:false" json:"internal"`
+	ActorUserID    *uint  `gorm:"index" json:"actor_user_id,omitempty"` // nil for system events
+	ActorRole      string `json:"actor_role"`
+}
+
+// Attachment is a file (photo, invoice, X-ray) a patient attached to a
+// complaint or review. Only the patient, the clinic concerned and regulators
+// may download it.
+type Attachment struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	
+	ComplaintID      *uint  `gorm:"index" json:"complaint_id,omitempty"`
+	ReviewID         *uint  `gorm:"index" json:"review_id,omitempty"`
+	UploadedByUserID uint   `gorm:"not null;index" json:"uploaded_by_user_id"`
+	StorageKey       string `gorm:"not null" json:"-"`
+	FileName         string `json:"file_name"`
+	FileSize         int64  `json:"file_size"`
+	ContentType      string `json:"content_type"` // detected from the content, not the client's claim
+	Checksum         string `json:"checksum"`     // hex-encoded SHA-256
+	ScannedBy        string `json:"scanned_by"`   // virus scanner that cleared the file
+	
+	// Relationships
+	Complaint *Complaint `gorm:"foreignKey:ComplaintID" json:"-"`
+	Review    *Review    `gorm:"foreignKey:ReviewID" json:"-"`
+}
+
+// Notification is an in-app message to a user about something that needs
+// their attention
+type Notification struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Kind       string     `gorm:"not null" json:"kind"`
+	Title      string     `gorm:"not null" json:"title"`
+	Body       string     `json:"body,omitempty"`
+	EntityType string     `json:"entity_type,omitempty"` // e.g. complaint
+	EntityID   *uint      `json:"entity_id,omitempty"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
 }
 
 // Statistics for regulator dashboard (time-series data)
@@ -0,0 +1,84 @@
+package moderation
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Flags raised by Scan. Flagged text is not rejected automatically; the
+// flags tell the moderator what to look at.
+const (
+	FlagProfanity = "profanity"
+	FlagEmail     = "email"
+	FlagPhone     = "phone"
+	FlagLink      = "link"
+)
+
+// minPhoneDigits is the shortest digit run treated as a phone number. It
+// skips prices, dates and tooth numbers.
+const minPhoneDigits = 10
+
+var (
+	emailPattern = regexp.MustCompile(`[\p{L}\d._%+-]+@[\p{L}\d-]+(?:\.[\p{L}\d-]+)*\.\p{L}{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().-]{8,}\d`)
+	linkPattern  = regexp.MustCompile(`(?i)(?:https?://|www\.)\S+|\b[\p{L}\d-]+\.(?:ru|com|net|org|рф|su|me|io)\b`)
+)
+
+// profaneStems match the start of a word. Stems are kept specific enough not
+// to match ordinary words such as "страхуешь" or "ребята".
+var profaneStems = []string{
+	"хуй", "хуе", "хуя", "хуи",
+	"пизд", "бляд", "блять",
+	"ебан", "ебат", "ебал", "ебл", "заеб", "поеб", "уеб", "выеб", "отъеб",
+	"мудак", "мудил", "сука", "суки", "сучк", "гандон", "долбоеб",
+	"fuck", "shit", "bitch", "asshole",
+}
+
+// Scan checks text for profanity and personal data and returns the raised
+// flags in a stable order, or nil for clean text
+func Scan(text string) []string {
+	var flags []string
+	if hasProfanity(text) {
+		flags = append(flags, FlagProfanity)
+	}
+	if emailPattern.MatchString(text) {
+		flags = append(flags, FlagEmail)
+	}
+	if hasPhone(text) {
+		flags = append(flags, FlagPhone)
+	}
+	// Email domains would otherwise also read as links
+	if linkPattern.MatchString(emailPattern.ReplaceAllString(text, "")) {
+		flags = append(flags, FlagLink)
+	}
+	return flags
+}
+
+func hasProfanity(text string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return slices.ContainsFunc(words, func(word string) bool {
+		word = strings.ReplaceAll(word, "ё", "е")
+		return slices.ContainsFunc(profaneStems, func(stem string) bool {
+			return strings.HasPrefix(word, stem)
+		})
+	})
+}
+
+func hasPhone(text string) bool {
+	for _, match := range phonePattern.FindAllString(text, -1) {
+		digits := 0
+		for _, r := range match {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits >= minPhoneDigits {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package offers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"fmt"
+	"strings"
+)
+
+// ItemInput is a clinic's answer for one treatment plan item
+type ItemInput struct {
+	TreatmentItemID uint
+	PriceListID     *uint // optional price list row the price comes from
+	Excluded        bool
+	Price           int
+	WarrantyYears   int
+	Notes           string
+}
+
+// ValidationError lists every problem found in an offer's items
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid offer items: " + strings.Join(e.Problems, "; ")
+}
+
+// Build validates a clinic's answers against the plan and the clinic's price
+// list, fills offer.Items and computes the offer's totals. A priced item
+// that refers to a price list row defaults to that row's price and warranty.
+// Drafts may leave items unpriced; Complete checks an offer before it is
+// sent.
+func Build(offer *models.ClinicOffer, plan *models.TreatmentPlan, priceList []models.PriceList, inputs []ItemInput) error {
+	planItems := make(map[uint]*models.TreatmentItem, len(plan.Items))
+	for i := range plan.Items {
+		planItems[plan.Items[i].ID] = &plan.Items[i]
+	}
+	priceRows := make(map[uint]*models.PriceList, len(priceList))
+	for i := range priceList {
+		priceRows[priceList[i].ID] = &priceList[i]
+	}
+
+	var problems []string
+	seen := make(map[uint]bool, len(inputs))
+	items := make([]models.OfferItem, 0, len(inputs))
+
+	for _, in := range inputs {
+		planItem, ok := planItems[in.TreatmentItemID]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("item %d is not part of treatment plan %d", in.TreatmentItemID, plan.ID))
+			continue
+		}
+		if seen[in.TreatmentItemID] {
+			problems = append(problems, fmt.Sprintf("item %d is listed more than once", in.TreatmentItemID))
+			continue
+		}
+		seen[in.TreatmentItemID] = true
+
+		item := models.OfferItem{
+			TreatmentItemID: planItem.ID,
+			Specialization:  planItem.Specialization,
+			Excluded:        in.Excluded,
+			Notes:           in.Notes,
+		}
+
+		if in.Excluded {
+			if in.Price != 0 || in.PriceListID != nil || in.WarrantyYears != 0 {
+				problems = append(problems, fmt.Sprintf("item %d is excluded but has a price or warranty", in.TreatmentItemID))
+			}
+			items = append(items, item)
+			continue
+		}
+
+		item.Price = in.Price
+		item.ListPrice = in.Price
+		item.WarrantyYears = in.WarrantyYears
+		if in.PriceListID != nil {
+			row, ok := priceRows[*in.PriceListID]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("item %d refers to price list row %d that is not in the clinic's price list", in.TreatmentItemID, *in.PriceListID))
+				continue
+			}
+			if row.Specialization != planItem.Specialization {
+				problems = append(problems, fmt.Sprintf("item %d is %s but price list row %d is %s", in.TreatmentItemID, planItem.Specialization, row.ID, row.Specialization))
+				continue
+			}
+			item.PriceListID = &row.ID
+			item.ListPrice = row.Price
+			if item.Price == 0 {
+				item.Price = row.Price
+			}
+			if item.WarrantyYears == 0 {
+				item.WarrantyYears = row.WarrantyYears
+			}
+		}
+
+		if item.Price < 0 {
+			problems = append(problems, fmt.Sprintf("item %d has a negative price", in.TreatmentItemID))
+		}
+		if item.WarrantyYears < 0 {
+			problems = append(problems, fmt.Sprintf("item %d has a negative warranty", in.TreatmentItemID))
+		}
+		items = append(items, item)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	offer.Items = items
+	ApplyTotals(offer)
+	return nil
+}
+
+// Complete checks that every plan item is either priced or explicitly
+// excluded and that at least one item is priced
+func Complete(offer *models.ClinicOffer, plan *models.TreatmentPlan) error {
+	answered := make(map[uint]bool, len(offer.Items))
+	var problems []string
+	priced := false
+	for _, item := range offer.Items {
+		switch {
+		case item.Excluded:
+			answered[item.TreatmentItemID] = true
+		case item.Price > 0:
+			answered[item.TreatmentItemID] = true
+			priced = true
+		}
+	}
+
+	for _, planItem := range plan.Items {
+		if !answered[planItem.ID] {
+			problems = append(problems, fmt.Sprintf("item %d (%s, tooth %s) is neither priced nor excluded", planItem.ID, planItem.Procedure, planItem.ToothNumber))
+		}
+	}
+	if len(problems) == 0 && !priced {
+		problems = append(problems, "at least one item must be priced")
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ApplyTotals recomputes the per-specialization and total costs and the
+// discount of an offer from its priced items
+func ApplyTotals(offer *models.ClinicOffer) {
+	totals := make(map[string]int)
+	total, listTotal := 0, 0
+	for _, item := range offer.Items {
+		if item.Excluded {
+			continue
+		}
+		totals[item.Specialization] += item.Price
+		total += item.Price
+		listTotal += item.ListPrice
+	}
+
+	offer.TherapyCost = totals[models.SpecTherapy]
+	offer.OrthopedicsCost = totals[models.SpecOrthopedics]
+	offer.SurgeryCost = totals[models.SpecSurgery]
+	offer.HygieneCost = totals[models.SpecHygiene]
+	offer.PeriodonticsCost = totals[models.SpecPeriodontics]
+	offer.TotalCost = total
+	offer.DiscountAmount = max(listTotal-total, 0)
+}
@@ -0,0 +1,306 @@
+package offers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func ptr(id uint) *uint {
+	return &id
+}
+
+// testPlan has a therapy item (1), a surgery item (2) and a second therapy
+// item (3)
+func testPlan() *models.TreatmentPlan {
+	return &models.TreatmentPlan{
+		ID: 100,
+		Items: []models.TreatmentItem{
+			{ID: 1, Specialization: models.SpecTherapy, Procedure: "Лечение кариеса", ToothNumber: "16"},
+			{ID: 2, Specialization: models.SpecSurgery, Procedure: "Удаление зуба", ToothNumber: "38"},
+			{ID: 3, Specialization: models.SpecTherapy, Procedure: "Лечение пульпита", ToothNumber: "26"},
+		},
+	}
+}
+
+func testPriceList() []models.PriceList {
+	return []models.PriceList{
+		{ID: 10, Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", Price: 5000, WarrantyYears: 2},
+		{ID: 20, Specialization: models.SpecSurgery, ServiceName: "Удаление зуба", Price: 3000, WarrantyYears: 0},
+	}
+}
+
+func TestBuildRejectsInvalidItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []ItemInput
+		problem string
+	}{
+		{
+			"duplicate item",
+			[]ItemInput{{TreatmentItemID: 1, Price: 5000}, {TreatmentItemID: 1, Price: 4000}},
+			"item 1 is listed more than once",
+		},
+		{
+			"item from another plan",
+			[]ItemInput{{TreatmentItemID: 99, Price: 5000}},
+			"item 99 is not part of treatment plan 100",
+		},
+		{
+			"excluded item with a price",
+			[]ItemInput{{TreatmentItemID: 1, Excluded: true, Price: 5000}},
+			"item 1 is excluded but has a price or warranty",
+		},
+		{
+			"excluded item with a price list row",
+			[]ItemInput{{TreatmentItemID: 1, Excluded: true, PriceListID: ptr(10)}},
+			"item 1 is excluded but has a price or warranty",
+		},
+		{
+			"excluded item with a warranty",
+			[]ItemInput{{TreatmentItemID: 1, Excluded: true, WarrantyYears: 1}},
+			"item 1 is excluded but has a price or warranty",
+		},
+		{
+			"price list row of another specialization",
+			[]ItemInput{{TreatmentItemID: 1, PriceListID: ptr(20)}},
+			"item 1 is therapy but price list row 20 is surgery",
+		},
+		{
+			"price list row of another clinic",
+			[]ItemInput{{TreatmentItemID: 1, PriceListID: ptr(30)}},
+			"item 1 refers to price list row 30 that is not in the clinic's price list",
+		},
+		{
+			"negative price",
+			[]ItemInput{{TreatmentItemID: 1, Price: -1}},
+			"item 1 has a negative price",
+		},
+		{
+			"negative warranty",
+			[]ItemInput{{TreatmentItemID: 1, Price: 5000, WarrantyYears: -1}},
+			"item 1 has a negative warranty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer := &models.ClinicOffer{TotalCost: 777}
+			err := Build(offer, testPlan(), testPriceList(), tt.inputs)
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Build = %v, want a *ValidationError", err)
+			}
+			found := false
+			for _, problem := range validationErr.Problems {
+				if strings.Contains(problem, tt.problem) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("problems %q do not mention %q", validationErr.Problems, tt.problem)
+			}
+			// A rejected offer is left untouched
+			if offer.Items != nil || offer.TotalCost != 777 {
+				t.Errorf("Build changed a rejected offer: %d items, total %d", len(offer.Items), offer.TotalCost)
+			}
+		})
+	}
+}
+
+func TestBuildReportsEveryProblem(t *testing.T) {
+	err := Build(&models.ClinicOffer{}, testPlan(), testPriceList(), []ItemInput{
+		{TreatmentItemID: 99, Price: 5000},
+		{TreatmentItemID: 1, Excluded: true, Price: 5000},
+		{TreatmentItemID: 2, Price: -1},
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Build = %v, want a *ValidationError", err)
+	}
+	if len(validationErr.Problems) != 3 {
+		t.Errorf("got %d problems, want 3: %q", len(validationErr.Problems), validationErr.Problems)
+	}
+}
+
+func TestBuildComputesTotals(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []ItemInput
+		check  func(t *testing.T, offer *models.ClinicOffer)
+	}{
+		{
+			"defaults copied from the price row",
+			[]ItemInput{{TreatmentItemID: 1, PriceListID: ptr(10)}},
+			func(t *testing.T, offer *models.ClinicOffer) {
+				item := offer.Items[0]
+				if item.Price != 5000 || item.ListPrice != 5000 || item.WarrantyYears != 2 {
+					t.Errorf("item = price %d, list %d, warranty %d; want 5000, 5000, 2", item.Price, item.ListPrice, item.WarrantyYears)
+				}
+				if item.PriceListID == nil || *item.PriceListID != 10 {
+					t.Errorf("item price list row = %v, want 10", item.PriceListID)
+				}
+				if item.Specialization != models.SpecTherapy {
+					t.Errorf("item specialization = %s, want the plan item's", item.Specialization)
+				}
+			},
+		},
+		{
+			"explicit price and warranty override the row",
+			[]ItemInput{{TreatmentItemID: 1, PriceListID: ptr(10), Price: 4000, WarrantyYears: 5}},
+			func(t *testing.T, offer *models.ClinicOffer) {
+				item := offer.Items[0]
+				if item.Price != 4000 || item.ListPrice != 5000 || item.WarrantyYears != 5 {
+					t.Errorf("item = price %d, list %d, warranty %d; want 4000, 5000, 5", item.Price, item.ListPrice, item.WarrantyYears)
+				}
+				if offer.DiscountAmount != 1000 {
+					t.Errorf("discount = %d, want 1000", offer.DiscountAmount)
+				}
+			},
+		},
+		{
+			"price above the list price is no discount",
+			[]ItemInput{{TreatmentItemID: 1, PriceListID: ptr(10), Price: 6000}},
+			func(t *testing.T, offer *models.ClinicOffer) {
+				if offer.DiscountAmount != 0 {
+					t.Errorf("discount = %d, want 0", offer.DiscountAmount)
+				}
+			},
+		},
+		{
+			"totals per specialization skip excluded items",
+			[]ItemInput{
+				{TreatmentItemID: 1, PriceListID: ptr(10), Price: 4500},
+				{TreatmentItemID: 2, PriceListID: ptr(20)},
+				{TreatmentItemID: 3, Excluded: true, Notes: "не лечим"},
+			},
+			func(t *testing.T, offer *models.ClinicOffer) {
+				if offer.TherapyCost != 4500 || offer.SurgeryCost != 3000 || offer.TotalCost != 7500 {
+					t.Errorf("costs = therapy %d, surgery %d, total %d; want 4500, 3000, 7500", offer.TherapyCost, offer.SurgeryCost, offer.TotalCost)
+				}
+				if offer.DiscountAmount != 500 {
+					t.Errorf("discount = %d, want 500", offer.DiscountAmount)
+				}
+				if !offer.Items[2].Excluded || offer.Items[2].Notes != "не лечим" {
+					t.Errorf("excluded item = %+v", offer.Items[2])
+				}
+			},
+		},
+		{
+			"drafts may leave items unpriced",
+			[]ItemInput{{TreatmentItemID: 1}},
+			func(t *testing.T, offer *models.ClinicOffer) {
+				if len(offer.Items) != 1 || offer.TotalCost != 0 {
+					t.Errorf("draft = %d items, total %d; want 1 item, total 0", len(offer.Items), offer.TotalCost)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Stale totals from the client are replaced
+			offer := &models.ClinicOffer{TotalCost: 1, OrthopedicsCost: 1, DiscountAmount: 1}
+			if err := Build(offer, testPlan(), testPriceList(), tt.inputs); err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if offer.OrthopedicsCost != 0 {
+				t.Errorf("orthopedics cost = %d, want 0", offer.OrthopedicsCost)
+			}
+			tt.check(t, offer)
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []models.OfferItem
+		problems []string
+	}{
+		{
+			"every item priced or excluded",
+			[]models.OfferItem{
+				{TreatmentItemID: 1, Price: 5000},
+				{TreatmentItemID: 2, Excluded: true},
+				{TreatmentItemID: 3, Price: 7000},
+			},
+			nil,
+		},
+		{
+			"unpriced item",
+			[]models.OfferItem{
+				{TreatmentItemID: 1, Price: 5000},
+				{TreatmentItemID: 2},
+				{TreatmentItemID: 3, Excluded: true},
+			},
+			[]string{"item 2 (Удаление зуба, tooth 38) is neither priced nor excluded"},
+		},
+		{
+			"missing items",
+			[]models.OfferItem{{TreatmentItemID: 1, Price: 5000}},
+			[]string{"item 2 ", "item 3 "},
+		},
+		{
+			"nothing priced",
+			[]models.OfferItem{
+				{TreatmentItemID: 1, Excluded: true},
+				{TreatmentItemID: 2, Excluded: true},
+				{TreatmentItemID: 3, Excluded: true},
+			},
+			[]string{"at least one item must be priced"},
+		},
+		{
+			"no items at all",
+			nil,
+			[]string{"item 1 ", "item 2 ", "item 3 "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Complete(&models.ClinicOffer{Items: tt.items}, testPlan())
+			if tt.problems == nil {
+				if err != nil {
+					t.Fatalf("Complete: %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Complete = %v, want a *ValidationError", err)
+			}
+			if len(validationErr.Problems) != len(tt.problems) {
+				t.Fatalf("problems = %q, want %d", validationErr.Problems, len(tt.problems))
+			}
+			for i, want := range tt.problems {
+				if !strings.Contains(validationErr.Problems[i], want) {
+					t.Errorf("problem %d = %q, want it to mention %q", i, validationErr.Problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyTotals(t *testing.T) {
+	offer := &models.ClinicOffer{Items: []models.OfferItem{
+		{Specialization: models.SpecTherapy, Price: 4000, ListPrice: 5000},
+		{Specialization: models.SpecOrthopedics, Price: 30000, ListPrice: 30000},
+		{Specialization: models.SpecHygiene, Price: 3000, ListPrice: 3500},
+		{Specialization: models.SpecPeriodontics, Price: 2000, ListPrice: 2000},
+		{Specialization: models.SpecSurgery, Excluded: true, Price: 9999, ListPrice: 9999},
+	}}
+	ApplyTotals(offer)
+
+	if offer.TherapyCost != 4000 || offer.OrthopedicsCost != 30000 || offer.HygieneCost != 3000 ||
+		offer.PeriodonticsCost != 2000 || offer.SurgeryCost != 0 {
+		t.Errorf("per-specialization costs = %d/%d/%d/%d/%d", offer.TherapyCost, offer.OrthopedicsCost,
+			offer.HygieneCost, offer.PeriodonticsCost, offer.SurgeryCost)
+	}
+	if offer.TotalCost != 39000 {
+		t.Errorf("total = %d, want 39000", offer.TotalCost)
+	}
+	if offer.DiscountAmount != 1500 {
+		t.Errorf("discount = %d, want 1500", offer.DiscountAmount)
+	}
+}
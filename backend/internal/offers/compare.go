@@ -0,0 +1,385 @@
+package offers
+
+import (
+	"dental-marketplace/backend/internal/geo"
+	"dental-marketplace/backend/internal/matching"
+	"dental-marketplace/backend/internal/models"
+	"sort"
+)
+
+const (
+	// maxRating is the top of the clinic rating scale
+	maxRating = 5.0
+	// distanceScaleKm is the distance at which the distance score halves
+	distanceScaleKm = 5.0
+	// unknownScore is used for criteria an offer or clinic does not state
+	unknownScore = 0.5
+)
+
+// Weights sets how much each criterion contributes to an offer's score.
+// They need not sum to 1; Compare normalizes them.
+type Weights struct {
+	Price       float64 `json:"price"`
+	Rating      float64 `json:"rating"`
+	Warranty    float64 `json:"warranty"`
+	Installment float64 `json:"installment"`
+	Distance    float64 `json:"distance"`
+	Wait        float64 `json:"wait"`
+}
+
+// DefaultWeights favours price, then clinic reputation and warranty
+var DefaultWeights = Weights{
+	Price:       0.35,
+	Rating:      0.2,
+	Warranty:    0.15,
+	Installment: 0.1,
+	Distance:    0.1,
+	Wait:        0.1,
+}
+
+func (w Weights) sum() float64 {
+	return w.Price + w.Rating + w.Warranty + w.Installment + w.Distance + w.Wait
+}
+
+// Normalized scales the weights to sum to 1. All-zero weights stay zero.
+func (w Weights) Normalized() Weights {
+	total := w.sum()
+	if total <= 0 {
+		return Weights{}
+	}
+	return Weights{
+		Price:       w.Price / total,
+		Rating:      w.Rating / total,
+		Warranty:    w.Warranty / total,
+		Installment: w.Installment / total,
+		Distance:    w.Distance / total,
+		Wait:        w.Wait / total,
+	}
+}
+
+// CriterionScores holds an offer's score per criterion, each from 0 to 1
+type CriterionScores struct {
+	Price       float64 `json:"price"`
+	Rating      float64 `json:"rating"`
+	Warranty    float64 `json:"warranty"`
+	Installment float64 `json:"installment"`
+	Distance    float64 `json:"distance"`
+	Wait        float64 `json:"wait"`
+}
+
+// ScoredOffer is one offer's summary and weighted score. The price score
+// uses ComparableCost, which charges the items the offer does not cover at
+// the highest price another offer quoted for them.
+type ScoredOffer struct {
+	OfferID           uint            `json:"offer_id"`
+	ClinicID          uint            `json:"clinic_id"`
+	ClinicName        string          `json:"clinic_name"`
+	ClinicRating      float64         `json:"clinic_rating"`
+	TotalCost         int             `json:"total_cost"`
+	ComparableCost    int             `json:"comparable_cost"`
+	CoveredItems      int             `json:"covered_items"` // plan items the offer prices
+	PlanItems         int             `json:"plan_items"`
+	AvgWarrantyYears  float64         `json:"avg_warranty_years"`
+	InstallmentMonths int             `json:"installment_months"`
+	DistanceKm        *float64        `json:"distance_km"`
+	WaitDays          *int            `json:"wait_days"`
+	Scores            CriterionScores `json:"scores"`
+	Score             float64         `json:"score"`
+}
+
+// SpecializationRow compares the offers' costs for one specialization
+type SpecializationRow struct {
+	Specialization string       `json:"specialization"`
+	Costs          map[uint]int `json:"costs"` // by offer ID
+	LowestOfferID  *uint        `json:"lowest_offer_id"`
+}
+
+// ItemCell is one offer's answer for one plan item
+type ItemCell struct {
+	Price         int    `json:"price"`
+	WarrantyYears int    `json:"warranty_years"`
+	Excluded      bool   `json:"excluded"`
+	Notes         string `json:"notes,omitempty"`
+}
+
+// ItemRow compares the offers' answers for one plan item. Offers that did
+// not answer the item have no cell.
+type ItemRow struct {
+	TreatmentItemID uint              `json:"treatment_item_id"`
+	Specialization  string            `json:"specialization"`
+	Procedure       string            `json:"procedure"`
+	ToothNumber     string            `json:"tooth_number"`
+	Cells           map[uint]ItemCell `json:"cells"` // by offer ID
+	LowestOfferID   *uint             `json:"lowest_offer_id"`
+}
+
+// Comparison is a side-by-side view of a plan's offers
+type Comparison struct {
+	Weights         Weights             `json:"weights"`
+	Offers          []ScoredOffer       `json:"offers"` // best score first
+	Specializations []SpecializationRow `json:"specializations"`
+	Items           []ItemRow           `json:"items"`
+}
+
+// Compare scores offers on a plan and builds the per-specialization and
+// per-item matrices. Price, warranty and wait are scored relative to the
+// other offers; rating, installment and distance on absolute scales.
+// Offers must be loaded with their Clinic and Items.
+func Compare(plan *models.TreatmentPlan, offers []models.ClinicOffer, weights Weights, locations matching.Locations) Comparison {
+	weights = weights.Normalized()
+	planPoint, planLocated := locations.Lookup(plan.TargetCity, plan.TargetDistrict)
+	costs, covered := comparableCosts(plan, offers)
+
+	scored := make([]ScoredOffer, len(offers))
+	for i := range offers {
+		offer := &offers[i]
+		s := ScoredOffer{
+			OfferID:           offer.ID,
+			ClinicID:          offer.ClinicID,
+			ClinicName:        offer.Clinic.Name,
+			ClinicRating:      offer.Clinic.Rating,
+			TotalCost:         offer.TotalCost,
+			ComparableCost:    costs[i],
+			CoveredItems:      covered[i],
+			PlanItems:         len(plan.Items),
+			AvgWarrantyYears:  avgWarrantyYears(offer.Items),
+			InstallmentMonths: offer.InstallmentMonths,
+			WaitDays:          offer.WaitDays,
+		}
+		if clinicPoint, ok := geo.NewPoint(offer.Clinic.Latitude, offer.Clinic.Longitude); ok && planLocated {
+			km := geo.DistanceKm(clinicPoint, planPoint)
+			s.DistanceKm = &km
+		}
+		scored[i] = s
+	}
+
+	priceScores := relativeScores(scored, func(s *ScoredOffer) (float64, bool) { return float64(s.ComparableCost), true }, false)
+	warrantyScores := relativeScores(scored, func(s *ScoredOffer) (float64, bool) { return s.AvgWarrantyYears, true }, true)
+	waitScores := relativeScores(scored, func(s *ScoredOffer) (float64, bool) {
+		if s.WaitDays == nil {
+			return 0, false
+		}
+		return float64(*s.WaitDays), true
+	}, false)
+
+	for i := range scored {
+		s := &scored[i]
+		s.Scores = CriterionScores{
+			Price:       priceScores[i],
+			Rating:      min(max(s.ClinicRating/maxRating, 0), 1),
+			Warranty:    warrantyScores[i],
+			Installment: installmentScore(s.InstallmentMonths),
+			Distance:    unknownScore,
+			Wait:        waitScores[i],
+		}
+		if s.DistanceKm != nil {
+			s.Scores.Distance = distanceScaleKm / (distanceScaleKm + *s.DistanceKm)
+		}
+		s.Score = weights.Price*s.Scores.Price +
+			weights.Rating*s.Scores.Rating +
+			weights.Warranty*s.Scores.Warranty +
+			weights.Installment*s.Scores.Installment +
+			weights.Distance*s.Scores.Distance +
+			weights.Wait*s.Scores.Wait
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].ComparableCost < scored[j].ComparableCost
+	})
+
+	return Comparison{
+		Weights:         weights,
+		Offers:          scored,
+		Specializations: specializationRows(plan, offers),
+		Items:           itemRows(plan, offers),
+	}
+}
+
+// relativeScores scales each offer's value between the lowest and highest
+// value among the offers. Offers without a value score unknownScore; when
+// all known values are equal they score 1.
+func relativeScores(scored []ScoredOffer, value func(*ScoredOffer) (float64, bool), higherIsBetter bool) []float64 {
+	values := make([]float64, len(scored))
+	known := make([]bool, len(scored))
+	lo, hi, seen := 0.0, 0.0, false
+	for i := range scored {
+		v, ok := value(&scored[i])
+		if !ok {
+			continue
+		}
+		values[i], known[i] = v, true
+		if !seen || v < lo {
+			lo = v
+		}
+		if !seen || v > hi {
+			hi = v
+		}
+		seen = true
+	}
+
+	scores := make([]float64, len(scored))
+	for i := range scored {
+		switch {
+		case !known[i]:
+			scores[i] = unknownScore
+		case hi == lo:
+			scores[i] = 1
+		case higherIsBetter:
+			scores[i] = (values[i] - lo) / (hi - lo)
+		default:
+			scores[i] = (hi - values[i]) / (hi - lo)
+		}
+	}
+	return scores
+}
+
+// comparableCosts prices each offer over the whole plan and counts the plan
+// items it covers. An item the offer excludes or leaves unpriced is charged
+// at the highest price any offer quoted for it, so leaving out expensive work
+// does not make an offer look cheaper. Items no offer prices count for nothing.
+func comparableCosts(plan *models.TreatmentPlan, offers []models.ClinicOffer) (costs, covered []int) {
+	prices := make([]map[uint]int, len(offers))
+	highest := make(map[uint]int, len(plan.Items))
+	for i := range offers {
+		prices[i] = make(map[uint]int, len(offers[i].Items))
+		for _, item := range offers[i].Items {
+			if item.Excluded || item.Price <= 0 {
+				continue
+			}
+			prices[i][item.TreatmentItemID] = item.Price
+			highest[item.TreatmentItemID] = max(highest[item.TreatmentItemID], item.Price)
+		}
+	}
+
+	costs = make([]int, len(offers))
+	covered = make([]int, len(offers))
+	for i := range offers {
+		for _, item := range plan.Items {
+			if price, ok := prices[i][item.ID]; ok {
+				costs[i] += price
+				covered[i]++
+				continue
+			}
+			costs[i] += highest[item.ID]
+		}
+	}
+	return costs, covered
+}
+
+// avgWarrantyYears averages the warranty of the priced items
+func avgWarrantyYears(items []models.OfferItem) float64 {
+	total, count := 0, 0
+	for _, item := range items {
+		if item.Excluded || item.Price <= 0 {
+			continue
+		}
+		total += item.WarrantyYears
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return float64(total) / float64(count)
+}
+
+// installmentScore rewards any installment plan, longer ones slightly more
+func installmentScore(months int) float64 {
+	switch {
+	case months <= 0:
+		return 0
+	case months >= 12:
+		return 1
+	default:
+		return 0.5 + 0.5*float64(months)/12
+	}
+}
+
+var specializationOrder = []string{
+	models.SpecTherapy,
+	models.SpecOrthopedics,
+	models.SpecSurgery,
+	models.SpecHygiene,
+	models.SpecPeriodontics,
+}
+
+// specializationRows lists the plan's specializations in a fixed order
+func specializationRows(plan *models.TreatmentPlan, offers []models.ClinicOffer) []SpecializationRow {
+	needed := make(map[string]bool)
+	for _, item := range plan.Items {
+		needed[item.Specialization] = true
+	}
+
+	rows := []SpecializationRow{}
+	for _, spec := range specializationOrder {
+		if !needed[spec] {
+			continue
+		}
+		row := SpecializationRow{Specialization: spec, Costs: make(map[uint]int, len(offers))}
+		for i := range offers {
+			cost := specializationCost(&offers[i], spec)
+			row.Costs[offers[i].ID] = cost
+			if cost > 0 && (row.LowestOfferID == nil || cost < row.Costs[*row.LowestOfferID]) {
+				row.LowestOfferID = &offers[i].ID
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func specializationCost(offer *models.ClinicOffer, spec string) int {
+	switch spec {
+	case models.SpecTherapy:
+		return offer.TherapyCost
+	case models.SpecOrthopedics:
+		return offer.OrthopedicsCost
+	case models.SpecSurgery:
+		return offer.SurgeryCost
+	case models.SpecHygiene:
+		return offer.HygieneCost
+	case models.SpecPeriodontics:
+		return offer.PeriodonticsCost
+	}
+	return 0
+}
+
+// itemRows lists the plan's items in plan order
+func itemRows(plan *models.TreatmentPlan, offers []models.ClinicOffer) []ItemRow {
+	rows := make([]ItemRow, len(plan.Items))
+	index := make(map[uint]int, len(plan.Items))
+	for i, item := range plan.Items {
+		rows[i] = ItemRow{
+			TreatmentItemID: item.ID,
+			Specialization:  item.Specialization,
+			Procedure:       item.Procedure,
+			ToothNumber:     item.ToothNumber,
+			Cells:           make(map[uint]ItemCell, len(offers)),
+		}
+		index[item.ID] = i
+	}
+
+	for i := range offers {
+		offer := &offers[i]
+		for _, item := range offer.Items {
+			r, ok := index[item.TreatmentItemID]
+			if !ok {
+				continue
+			}
+			row := &rows[r]
+			row.Cells[offer.ID] = ItemCell{
+				Price:         item.Price,
+				WarrantyYears: item.WarrantyYears,
+				Excluded:      item.Excluded,
+				Notes:         item.Notes,
+			}
+			if !item.Excluded && item.Price > 0 &&
+				(row.LowestOfferID == nil || item.Price < row.Cells[*row.LowestOfferID].Price) {
+				row.LowestOfferID = &offer.ID
+			}
+		}
+	}
+	return rows
+}
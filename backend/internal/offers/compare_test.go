@@ -0,0 +1,198 @@
+package offers
+
+import (
+	"dental-marketplace/backend/internal/matching"
+	"dental-marketplace/backend/internal/models"
+	"testing"
+)
+
+// testOffer prices plan items by ID; a zero price marks the item excluded
+func testOffer(id uint, prices map[uint]int) models.ClinicOffer {
+	offer := models.ClinicOffer{ID: id, ClinicID: id, Clinic: models.Clinic{ID: id}}
+	for itemID, price := range prices {
+		offer.Items = append(offer.Items, models.OfferItem{TreatmentItemID: itemID, Price: price, Excluded: price == 0})
+		offer.TotalCost += price
+	}
+	return offer
+}
+
+func TestCompareChargesUncoveredItems(t *testing.T) {
+	complete := testOffer(1, map[uint]int{1: 5000, 2: 3000, 3: 7000})
+	// Cheapest total only because it leaves out the pulpitis treatment
+	partial := testOffer(2, map[uint]int{1: 5200, 2: 3100, 3: 0})
+
+	comparison := Compare(testPlan(), []models.ClinicOffer{partial, complete}, Weights{Price: 1}, matching.Locations{})
+
+	first, second := comparison.Offers[0], comparison.Offers[1]
+	if first.OfferID != complete.ID {
+		t.Fatalf("best offer = %d, want the complete offer %d", first.OfferID, complete.ID)
+	}
+	if first.ComparableCost != 15000 || first.CoveredItems != 3 || first.PlanItems != 3 {
+		t.Errorf("complete offer = cost %d, %d/%d items; want 15000, 3/3", first.ComparableCost, first.CoveredItems, first.PlanItems)
+	}
+	if second.TotalCost != 8300 || second.ComparableCost != 15300 || second.CoveredItems != 2 {
+		t.Errorf("partial offer = total %d, comparable %d, %d items; want 8300, 15300, 2",
+			second.TotalCost, second.ComparableCost, second.CoveredItems)
+	}
+	if first.Scores.Price != 1 || second.Scores.Price != 0 {
+		t.Errorf("price scores = %v, %v; want 1, 0", first.Scores.Price, second.Scores.Price)
+	}
+}
+
+func TestComparableCosts(t *testing.T) {
+	offers := []models.ClinicOffer{
+		testOffer(1, map[uint]int{1: 5000, 2: 3000}),
+		testOffer(2, map[uint]int{1: 4000, 2: 0}),
+		testOffer(3, map[uint]int{2: 3500}),
+	}
+
+	costs, covered := comparableCosts(testPlan(), offers)
+
+	// Item 2 is charged at the highest quote (3500) where it is not priced;
+	// item 3 is priced by no offer and counts for nothing
+	wantCosts, wantCovered := []int{8000, 7500, 8500}, []int{2, 1, 1}
+	for i := range offers {
+		if costs[i] != wantCosts[i] || covered[i] != wantCovered[i] {
+			t.Errorf("offer %d = cost %d, %d items; want %d, %d", offers[i].ID, costs[i], covered[i], wantCosts[i], wantCovered[i])
+		}
+	}
+}
+
+func TestRelativeScores(t *testing.T) {
+	tests := []struct {
+		name           string
+		values         []float64 // negative values are unknown
+		higherIsBetter bool
+		want           []float64
+	}{
+		{"lower is better", []float64{1000, 2000, 3000}, false, []float64{1, 0.5, 0}},
+		{"higher is better", []float64{1000, 2000, 3000}, true, []float64{0, 0.5, 1}},
+		{"all equal", []float64{2000, 2000}, false, []float64{1, 1}},
+		{"single offer", []float64{2000}, true, []float64{1}},
+		{"unknown values", []float64{-1, 10, 30}, false, []float64{unknownScore, 1, 0}},
+		{"nothing known", []float64{-1, -1}, false, []float64{unknownScore, unknownScore}},
+		{"no offers", nil, false, []float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scored := make([]ScoredOffer, len(tt.values))
+			for i, v := range tt.values {
+				scored[i].AvgWarrantyYears = v
+			}
+			got := relativeScores(scored, func(s *ScoredOffer) (float64, bool) {
+				return s.AvgWarrantyYears, s.AvgWarrantyYears >= 0
+			}, tt.higherIsBetter)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("relativeScores = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("relativeScores = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWeightsNormalized(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Weights
+		want Weights
+	}{
+		{"scaled to sum to 1", Weights{Price: 2, Rating: 1, Wait: 1}, Weights{Price: 0.5, Rating: 0.25, Wait: 0.25}},
+		{"already normalized", Weights{Price: 0.5, Distance: 0.5}, Weights{Price: 0.5, Distance: 0.5}},
+		{"all zero stays zero", Weights{}, Weights{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.Normalized(); got != tt.want {
+				t.Errorf("Normalized = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallmentScore(t *testing.T) {
+	tests := []struct {
+		months int
+		want   float64
+	}{
+		{0, 0},
+		{-3, 0},
+		{6, 0.75},
+		{12, 1},
+		{24, 1},
+	}
+	for _, tt := range tests {
+		if got := installmentScore(tt.months); got != tt.want {
+			t.Errorf("installmentScore(%d) = %v, want %v", tt.months, got, tt.want)
+		}
+	}
+}
+
+func TestCompareScores(t *testing.T) {
+	expensive := testOffer(1, map[uint]int{1: 5000, 2: 3000, 3: 7000})
+	expensive.Clinic.Rating = 5
+	expensive.InstallmentMonths = 12
+	wait := 3
+	expensive.WaitDays = &wait
+	cheap := testOffer(2, map[uint]int{1: 2500, 2: 1500, 3: 3500})
+	cheap.Clinic.Rating = 2.5
+
+	comparison := Compare(testPlan(), []models.ClinicOffer{expensive, cheap},
+		Weights{Price: 2, Rating: 2, Installment: 1, Distance: 1, Wait: 2}, matching.Locations{})
+
+	if comparison.Weights != (Weights{Price: 0.25, Rating: 0.25, Installment: 0.125, Distance: 0.125, Wait: 0.25}) {
+		t.Errorf("weights = %+v, want them normalized", comparison.Weights)
+	}
+	tests := []struct {
+		offerID uint
+		scores  CriterionScores
+		score   float64
+	}{
+		// Without coordinates distance is unknown; a stated wait beats a missing one
+		{1, CriterionScores{Price: 0, Rating: 1, Warranty: 1, Installment: 1, Distance: unknownScore, Wait: 1}, 0.6875},
+		{2, CriterionScores{Price: 1, Rating: 0.5, Warranty: 1, Installment: 0, Distance: unknownScore, Wait: unknownScore}, 0.5625},
+	}
+	if len(comparison.Offers) != len(tests) {
+		t.Fatalf("compared %d offers, want %d", len(comparison.Offers), len(tests))
+	}
+	for i, tt := range tests {
+		got := comparison.Offers[i]
+		if got.OfferID != tt.offerID {
+			t.Fatalf("offer %d = %d, want %d", i, got.OfferID, tt.offerID)
+		}
+		if got.Scores != tt.scores || got.Score != tt.score {
+			t.Errorf("offer %d = %+v, %v; want %+v, %v", got.OfferID, got.Scores, got.Score, tt.scores, tt.score)
+		}
+	}
+}
+
+func TestCompareBreaksTiesOnPrice(t *testing.T) {
+	first := testOffer(1, map[uint]int{1: 5000, 2: 3000, 3: 7000})
+	second := testOffer(2, map[uint]int{1: 5000, 2: 3000, 3: 7000})
+	cheaper := testOffer(3, map[uint]int{1: 4000, 2: 3000, 3: 7000})
+	for _, offer := range []*models.ClinicOffer{&first, &second, &cheaper} {
+		offer.Clinic.Rating = 4
+	}
+
+	// Rating alone scores every offer the same
+	comparison := Compare(testPlan(), []models.ClinicOffer{first, second, cheaper}, Weights{Rating: 1}, matching.Locations{})
+
+	want := []uint{3, 1, 2}
+	for i, offer := range comparison.Offers {
+		if offer.OfferID != want[i] {
+			t.Fatalf("order = %v, want %v", offerIDs(comparison.Offers), want)
+		}
+	}
+}
+
+func offerIDs(scored []ScoredOffer) []uint {
+	ids := make([]uint, len(scored))
+	for i := range scored {
+		ids[i] = scored[i].OfferID
+	}
+	return ids
+}
@@ -0,0 +1,69 @@
+package offers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/pricing"
+	"math"
+)
+
+// Quote drafts a pending offer by matching each plan item to the clinic's
+// price list and applying the clinic's discounts. Items without a matching
+// price list entry are left unpriced and returned so staff can fill them
+// in before sending.
+func Quote(plan *models.TreatmentPlan, clinicID uint, priceList []models.PriceList, discounts []models.ClinicDiscount) (*models.ClinicOffer, []uint) {
+	offer := &models.ClinicOffer{
+		TreatmentPlanID: plan.ID,
+		ClinicID:        clinicID,
+		Status:          models.OfferStatusPending,
+	}
+
+	var unmatched []uint
+	listTotal := 0
+	for i := range plan.Items {
+		planItem := &plan.Items[i]
+		item := models.OfferItem{
+			TreatmentItemID: planItem.ID,
+			Specialization:  planItem.Specialization,
+		}
+
+		if entry := pricing.BestMatch(planItem, priceList); entry != nil {
+			item.PriceListID = &entry.ID
+			item.ListPrice = entry.Price
+			item.Price = entry.Price
+			item.WarrantyYears = entry.WarrantyYears
+			listTotal += entry.Price
+		} else {
+			unmatched = append(unmatched, planItem.ID)
+		}
+		offer.Items = append(offer.Items, item)
+	}
+
+	for i := range offer.Items {
+		item := &offer.Items[i]
+		if item.ListPrice == 0 {
+			continue
+		}
+		if percent := discountPercent(discounts, item.Specialization, listTotal); percent > 0 {
+			item.Price = int(math.Round(float64(item.ListPrice) * float64(100-percent) / 100))
+		}
+	}
+
+	ApplyTotals(offer)
+	return offer, unmatched
+}
+
+// discountPercent returns the largest active discount for a specialization.
+// Discounts do not stack.
+func discountPercent(discounts []models.ClinicDiscount, specialization string, listTotal int) int {
+	best := 0
+	for _, d := range discounts {
+		if !d.IsActive || listTotal < d.MinPlanTotal {
+			continue
+		}
+		if d.Specialization != "" && d.Specialization != specialization {
+			continue
+		}
+		best = max(best, d.Percent)
+	}
+	return best
+}
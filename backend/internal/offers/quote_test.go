@@ -0,0 +1,159 @@
+package offers
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestQuoteMatchesPriceList(t *testing.T) {
+	plan := testPlan()
+	plan.Items[0].ProcedureCode = "therapy_caries"
+	priceList := []models.PriceList{
+		{ID: 10, Specialization: models.SpecTherapy, ServiceName: "Пломба", ProcedureCode: "therapy_caries", Price: 5000, WarrantyYears: 2},
+		{ID: 20, Specialization: models.SpecSurgery, ServiceName: "Удаление зуба", Price: 3000},
+	}
+
+	offer, unmatched := Quote(plan, 7, priceList, nil)
+
+	if offer.TreatmentPlanID != plan.ID || offer.ClinicID != 7 || offer.Status != models.OfferStatusPending {
+		t.Errorf("offer = plan %d, clinic %d, status %s", offer.TreatmentPlanID, offer.ClinicID, offer.Status)
+	}
+	if len(offer.Items) != len(plan.Items) {
+		t.Fatalf("quoted %d items, want every plan item", len(offer.Items))
+	}
+
+	// Matched by catalog code and by name; the pulpitis item has no entry
+	if row := offer.Items[0].PriceListID; row == nil || *row != 10 || offer.Items[0].Price != 5000 || offer.Items[0].WarrantyYears != 2 {
+		t.Errorf("item 1 = %+v, want price list row 10", offer.Items[0])
+	}
+	if row := offer.Items[1].PriceListID; row == nil || *row != 20 || offer.Items[1].Price != 3000 {
+		t.Errorf("item 2 = %+v, want price list row 20", offer.Items[1])
+	}
+	if offer.Items[2].PriceListID != nil || offer.Items[2].Price != 0 {
+		t.Errorf("item 3 = %+v, want it unpriced", offer.Items[2])
+	}
+	if want := []uint{3}; !reflect.DeepEqual(unmatched, want) {
+		t.Errorf("unmatched = %v, want %v", unmatched, want)
+	}
+	if offer.TotalCost != 8000 || offer.TherapyCost != 5000 || offer.SurgeryCost != 3000 || offer.DiscountAmount != 0 {
+		t.Errorf("totals = total %d, therapy %d, surgery %d, discount %d", offer.TotalCost, offer.TherapyCost, offer.SurgeryCost, offer.DiscountAmount)
+	}
+}
+
+func TestQuoteAppliesDiscounts(t *testing.T) {
+	priceList := []models.PriceList{
+		{ID: 10, Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", Price: 5000},
+		{ID: 20, Specialization: models.SpecSurgery, ServiceName: "Удаление зуба", Price: 3000},
+		{ID: 30, Specialization: models.SpecTherapy, ServiceName: "Лечение пульпита", Price: 7001},
+	}
+
+	tests := []struct {
+		name      string
+		discounts []models.ClinicDiscount
+		prices    []int
+	}{
+		{"no discounts", nil, []int{5000, 3000, 7001}},
+		{
+			"plan-wide discount, rounded to the ruble",
+			[]models.ClinicDiscount{{Percent: 10, IsActive: true}},
+			[]int{4500, 2700, 6301},
+		},
+		{
+			"discount limited to one specialization",
+			[]models.ClinicDiscount{{Specialization: models.SpecSurgery, Percent: 20, IsActive: true}},
+			[]int{5000, 2400, 7001},
+		},
+		{
+			"discounts do not stack",
+			[]models.ClinicDiscount{
+				{Percent: 5, IsActive: true},
+				{Specialization: models.SpecTherapy, Percent: 10, IsActive: true},
+				{Percent: 3, IsActive: true},
+			},
+			[]int{4500, 2850, 6301},
+		},
+		{
+			"inactive discounts are ignored",
+			[]models.ClinicDiscount{{Percent: 50, IsActive: false}},
+			[]int{5000, 3000, 7001},
+		},
+		{
+			"threshold met by the plan's list total",
+			[]models.ClinicDiscount{{Percent: 10, IsActive: true, MinPlanTotal: 15001}},
+			[]int{4500, 2700, 6301},
+		},
+		{
+			"threshold above the plan's list total",
+			[]models.ClinicDiscount{{Percent: 10, IsActive: true, MinPlanTotal: 15002}},
+			[]int{5000, 3000, 7001},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer, unmatched := Quote(testPlan(), 7, priceList, tt.discounts)
+			if len(unmatched) != 0 {
+				t.Fatalf("unmatched = %v, want none", unmatched)
+			}
+
+			listTotal, total := 0, 0
+			for i, want := range tt.prices {
+				item := offer.Items[i]
+				if item.Price != want {
+					t.Errorf("item %d price = %d, want %d", item.TreatmentItemID, item.Price, want)
+				}
+				listTotal += item.ListPrice
+				total += item.Price
+			}
+			if listTotal != 15001 {
+				t.Errorf("list prices changed: total %d, want 15001", listTotal)
+			}
+			if offer.TotalCost != total || offer.DiscountAmount != listTotal-total {
+				t.Errorf("totals = total %d, discount %d; want %d, %d", offer.TotalCost, offer.DiscountAmount, total, listTotal-total)
+			}
+		})
+	}
+}
+
+func TestQuoteThresholdIgnoresUnmatchedItems(t *testing.T) {
+	// Only the matched item counts towards the threshold
+	priceList := []models.PriceList{{ID: 10, Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", Price: 5000}}
+	discounts := []models.ClinicDiscount{{Percent: 10, IsActive: true, MinPlanTotal: 5001}}
+
+	offer, unmatched := Quote(testPlan(), 7, priceList, discounts)
+	if want := []uint{2, 3}; !reflect.DeepEqual(unmatched, want) {
+		t.Errorf("unmatched = %v, want %v", unmatched, want)
+	}
+	if offer.Items[0].Price != 5000 {
+		t.Errorf("price = %d, want the undiscounted 5000", offer.Items[0].Price)
+	}
+}
+
+func TestDiscountPercent(t *testing.T) {
+	discounts := []models.ClinicDiscount{
+		{Percent: 5, IsActive: true},
+		{Specialization: models.SpecOrthopedics, Percent: 15, IsActive: true, MinPlanTotal: 100000},
+		{Specialization: models.SpecTherapy, Percent: 8, IsActive: true},
+		{Specialization: models.SpecSurgery, Percent: 30, IsActive: false},
+	}
+
+	tests := []struct {
+		specialization string
+		listTotal      int
+		want           int
+	}{
+		{models.SpecTherapy, 1000, 8},
+		{models.SpecSurgery, 1000, 5},
+		{models.SpecOrthopedics, 99999, 5},
+		{models.SpecOrthopedics, 100000, 15},
+		{models.SpecHygiene, 0, 5},
+	}
+	for _, tt := range tests {
+		if got := discountPercent(discounts, tt.specialization, tt.listTotal); got != tt.want {
+			t.Errorf("discountPercent(%s, %d) = %d, want %d", tt.specialization, tt.listTotal, got, tt.want)
+		}
+	}
+	if got := discountPercent(nil, models.SpecTherapy, 1000); got != 0 {
+		t.Errorf("discountPercent without discounts = %d, want 0", got)
+	}
+}
@@ -0,0 +1,165 @@
+package pricing
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"log"
+	"math"
+	"sort"
+)
+
+// Percentiles of matching regional prices used for a plan's cost range
+const (
+	lowPercentile  = 10
+	highPercentile = 90
+)
+
+// Estimator prices treatment plans from the price lists of clinics in the
+// patient's city
+type Estimator struct {
+	repo *repository.Repository
+}
+
+func NewEstimator(repo *repository.Repository) *Estimator {
+	return &Estimator{repo: repo}
+}
+
+// Estimate fills item costs and the per-specialization ranges of a plan
+// from price lists in the city. Items without a matching price list entry
+// keep the analyzer's estimate.
+func (e *Estimator) Estimate(plan *models.TreatmentPlan, city string) error {
+	prices, err := e.repo.GetCityPriceLists(city)
+	if err != nil {
+		return err
+	}
+	estimate(plan, prices)
+	return nil
+}
+
+// RecomputeCity re-estimates the open plans of patients in the city,
+// called after a clinic there changes its price list
+func (e *Estimator) RecomputeCity(city string) error {
+	prices, err := e.repo.GetCityPriceLists(city)
+	if err != nil {
+		return err
+	}
+
+	plans, err := e.repo.GetOpenTreatmentPlansByCity(city)
+	if err != nil {
+		return err
+	}
+
+	for i := range plans {
+		estimate(&plans[i], prices)
+		if err := e.repo.UpdateTreatmentPlanEstimate(&plans[i]); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Re-estimated %d treatment plans in %s", len(plans), city)
+	return nil
+}
+
+// RecomputeAll re-estimates open plans in every city that has them
+func (e *Estimator) RecomputeAll() error {
+	cities, err := e.repo.GetOpenTreatmentPlanCities()
+	if err != nil {
+		return err
+	}
+	for _, city := range cities {
+		if err := e.RecomputeCity(city); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// estimate prices each item at the median of its matching entries and sums
+// the low and high percentiles per specialization
+func estimate(plan *models.TreatmentPlan, prices []models.PriceList) {
+	type costRange struct{ min, max int }
+	ranges := map[string]*costRange{}
+
+	for i := range plan.Items {
+		item := &plan.Items[i]
+
+		low, high := item.EstimatedCost, item.EstimatedCost
+		if matched := matchPrices(item, prices); len(matched) > 0 {
+			item.EstimatedCost = percentile(matched, 50)
+			low = percentile(matched, lowPercentile)
+			high = percentile(matched, highPercentile)
+		}
+
+		r := ranges[item.Specialization]
+		if r == nil {
+			r = &costRange{}
+			ranges[item.Specialization] = r
+		}
+		r.min += low
+		r.max += high
+	}
+
+	get := func(spec string) (bool, int, int) {
+		if r := ranges[spec]; r != nil {
+			return true, r.min, r.max
+		}
+		return false, 0, 0
+	}
+	plan.RequiresTherapy, plan.TherapyMinCost, plan.TherapyMaxCost = get(models.SpecTherapy)
+	plan.RequiresOrthopedics, plan.OrthopedicsMinCost, plan.OrthopedicsMaxCost = get(models.SpecOrthopedics)
+	plan.RequiresSurgery, plan.SurgeryMinCost, plan.SurgeryMaxCost = get(models.SpecSurgery)
+	plan.RequiresHygiene, plan.HygieneMinCost, plan.HygieneMaxCost = get(models.SpecHygiene)
+	plan.RequiresPeriodontics, plan.PeriodonticsMinCost, plan.PeriodonticsMaxCost = get(models.SpecPeriodontics)
+}
+
+// matchPrices returns the prices of entries for the item's catalog code.
+// Items or price lists not yet mapped to the catalog fall back to matching
+// service names within the specialization, preferring exact matches.
+func matchPrices(item *models.TreatmentItem, prices []models.PriceList) []int {
+	if item.ProcedureCode != "" {
+		var coded []int
+		for _, p := range prices {
+			if p.ProcedureCode == item.ProcedureCode && p.Price > 0 {
+				coded = append(coded, p.Price)
+			}
+		}
+		if len(coded) > 0 {
+			return coded
+		}
+	}
+
+	procedure := tokens(item.Procedure)
+
+	var exact, similar []int
+	for _, p := range prices {
+		if p.Specialization != item.Specialization || p.Price <= 0 {
+			continue
+		}
+		score := matchScore(procedure, tokens(p.ServiceName))
+		switch {
+		case score == 1:
+			exact = append(exact, p.Price)
+		case score >= minMatchScore:
+			similar = append(similar, p.Price)
+		}
+	}
+
+	if len(exact) > 0 {
+		return exact
+	}
+	return similar
+}
+
+// percentile interpolates linearly between the closest ranks
+func percentile(values []int, p float64) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	weight := rank - float64(lower)
+
+	value := float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight
+	return int(math.Round(value))
+}
@@ -0,0 +1,127 @@
+package pricing
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		p      float64
+		want   int
+	}{
+		{"single value", []int{5000}, 50, 5000},
+		{"single value low", []int{5000}, lowPercentile, 5000},
+		{"median of odd count", []int{3000, 1000, 2000}, 50, 2000},
+		{"median of even count interpolates", []int{1000, 2000, 3000, 4000}, 50, 2500},
+		{"minimum", []int{4000, 1000, 3000}, 0, 1000},
+		{"maximum", []int{4000, 1000, 3000}, 100, 4000},
+		{"p10 interpolates", []int{1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000, 9000, 10000, 11000}, lowPercentile, 2000},
+		{"p90 interpolates", []int{1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000, 9000, 10000, 11000}, highPercentile, 10000},
+		{"p10 between ranks", []int{1000, 2000, 3000}, lowPercentile, 1200},
+		{"p90 between ranks", []int{1000, 2000, 3000}, highPercentile, 2800},
+		{"rounds to the nearest ruble", []int{1000, 1001}, 50, 1001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.values, tt.p); got != tt.want {
+				t.Errorf("percentile(%v, %v) = %d, want %d", tt.values, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentileLeavesInputUnsorted(t *testing.T) {
+	values := []int{3000, 1000, 2000}
+	percentile(values, 50)
+	if values[0] != 3000 || values[1] != 1000 || values[2] != 2000 {
+		t.Errorf("percentile reordered its input: %v", values)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	prices := []models.PriceList{
+		{Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", ProcedureCode: "therapy_caries", Price: 4000},
+		{Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", ProcedureCode: "therapy_caries", Price: 5000},
+		{Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", ProcedureCode: "therapy_caries", Price: 6000},
+		{Specialization: models.SpecSurgery, ServiceName: "Удаление зуба простое", Price: 3000},
+		{Specialization: models.SpecSurgery, ServiceName: "Удаление зуба простое", Price: 0},
+	}
+	plan := &models.TreatmentPlan{
+		// Stale ranges from an earlier estimate are replaced
+		RequiresHygiene: true,
+		HygieneMinCost:  100,
+		HygieneMaxCost:  200,
+		Items: []models.TreatmentItem{
+			{Specialization: models.SpecTherapy, Procedure: "Лечение кариеса", ProcedureCode: "therapy_caries", EstimatedCost: 1},
+			{Specialization: models.SpecTherapy, Procedure: "Лечение кариеса", ProcedureCode: "therapy_caries", EstimatedCost: 1},
+			{Specialization: models.SpecSurgery, Procedure: "Удаление зуба простое", EstimatedCost: 1},
+			{Specialization: models.SpecOrthopedics, Procedure: "Коронка металлокерамическая", EstimatedCost: 20000},
+		},
+	}
+
+	estimate(plan, prices)
+
+	wantCosts := []int{5000, 5000, 3000, 20000}
+	for i, want := range wantCosts {
+		if got := plan.Items[i].EstimatedCost; got != want {
+			t.Errorf("item %d cost = %d, want %d", i, got, want)
+		}
+	}
+
+	ranges := []struct {
+		name             string
+		requires         bool
+		min, max         int
+		wantRequires     bool
+		wantMin, wantMax int
+	}{
+		{"therapy", plan.RequiresTherapy, plan.TherapyMinCost, plan.TherapyMaxCost, true, 8400, 11600},
+		{"surgery", plan.RequiresSurgery, plan.SurgeryMinCost, plan.SurgeryMaxCost, true, 3000, 3000},
+		// Unmatched items keep the analyzer's estimate as both bounds
+		{"orthopedics", plan.RequiresOrthopedics, plan.OrthopedicsMinCost, plan.OrthopedicsMaxCost, true, 20000, 20000},
+		{"hygiene", plan.RequiresHygiene, plan.HygieneMinCost, plan.HygieneMaxCost, false, 0, 0},
+		{"periodontics", plan.RequiresPeriodontics, plan.PeriodonticsMinCost, plan.PeriodonticsMaxCost, false, 0, 0},
+	}
+	for _, r := range ranges {
+		if r.requires != r.wantRequires || r.min != r.wantMin || r.max != r.wantMax {
+			t.Errorf("%s = (%v, %d, %d), want (%v, %d, %d)", r.name, r.requires, r.min, r.max, r.wantRequires, r.wantMin, r.wantMax)
+		}
+	}
+}
+
+func TestMatchPrices(t *testing.T) {
+	prices := []models.PriceList{
+		{Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", ProcedureCode: "therapy_caries", Price: 5000},
+		{Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", Price: 4500},
+		{Specialization: models.SpecTherapy, ServiceName: "Лечение глубокого кариеса", Price: 7000},
+		{Specialization: models.SpecHygiene, ServiceName: "Лечение кариеса", Price: 100},
+	}
+
+	tests := []struct {
+		name string
+		item models.TreatmentItem
+		want []int
+	}{
+		{"catalog code wins", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Лечение кариеса", ProcedureCode: "therapy_caries"}, []int{5000}},
+		{"unknown code falls back to names", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Лечение кариеса", ProcedureCode: "therapy_other"}, []int{5000, 4500}},
+		{"exact names win over similar ones", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "лечение кариеса"}, []int{5000, 4500}},
+		{"similar names without an exact one", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Лечение глубокого кариеса зуба"}, []int{5000, 4500, 7000}},
+		{"other specializations are ignored", models.TreatmentItem{Specialization: models.SpecSurgery, Procedure: "Лечение кариеса"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchPrices(&tt.item, prices)
+			if len(got) != len(tt.want) {
+				t.Fatalf("matchPrices = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("matchPrices = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
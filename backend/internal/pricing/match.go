@@ -0,0 +1,96 @@
+package pricing
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"strings"
+	"unicode"
+)
+
+// minMatchScore is the token overlap a price list entry needs to count as
+// the same procedure
+const minMatchScore = 0.5
+
+// stemLength truncates words so inflected Russian forms compare equal,
+// e.g. "кариес" and "кариеса"
+const stemLength = 5
+
+// stopWords carry no meaning for matching procedures
+var stopWords = map[string]bool{
+	"и": true, "с": true, "на": true, "за": true, "в": true, "по": true, "для": true,
+}
+
+// tokens normalizes a procedure or service name into word stems
+func tokens(s string) []string {
+	s = strings.ToLower(strings.ReplaceAll(s, "ё", "е"))
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	stems := make([]string, 0, len(words))
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+		if runes := []rune(word); len(runes) > stemLength {
+			word = string(runes[:stemLength])
+		}
+		stems = append(stems, word)
+	}
+	return stems
+}
+
+// matchScore is the Jaccard similarity of the two names' stems
+func matchScore(procedure, service []string) float64 {
+	if len(procedure) == 0 || len(service) == 0 {
+		return 0
+	}
+
+	set := map[string]bool{}
+	for _, t := range procedure {
+		set[t] = true
+	}
+	union := len(set)
+	common := 0
+	seen := map[string]bool{}
+	for _, t := range service {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if set[t] {
+			common++
+		} else {
+			union++
+		}
+	}
+	return float64(common) / float64(union)
+}
+
+// BestMatch returns the entry of a single clinic's price list for an item:
+// an entry with the item's catalog code if there is one, otherwise the
+// service in the item's specialization whose name matches best. It returns
+// nil if nothing matches.
+func BestMatch(item *models.TreatmentItem, entries []models.PriceList) *models.PriceList {
+	if item.ProcedureCode != "" {
+		for i := range entries {
+			if entries[i].ProcedureCode == item.ProcedureCode && entries[i].Price > 0 {
+				return &entries[i]
+			}
+		}
+	}
+
+	procedure := tokens(item.Procedure)
+
+	var best *models.PriceList
+	bestScore := 0.0
+	for i := range entries {
+		e := &entries[i]
+		if e.Specialization != item.Specialization || e.Price <= 0 {
+			continue
+		}
+		if score := matchScore(procedure, tokens(e.ServiceName)); score >= minMatchScore && score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,85 @@
+package pricing
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Лечение кариеса", []string{"лечен", "карие"}},
+		{"лечение  КАРИЕС", []string{"лечен", "карие"}},
+		{"Чистка и полировка", []string{"чистк", "полир"}},
+		{"Удаление зуба (простое), 1 ед.", []string{"удале", "зуба", "прост", "1", "ед"}},
+		{"Пломба ёмкая", []string{"пломб", "емкая"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := tokens(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokens(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		procedure, service string
+		want               float64
+	}{
+		{"Лечение кариеса", "Лечение кариеса", 1},
+		{"Лечение кариеса", "лечение кариесов", 1},
+		{"Лечение кариеса", "Лечение глубокого кариеса", 2.0 / 3},
+		{"Лечение кариеса", "Удаление зуба", 0},
+		{"Лечение кариеса", "", 0},
+		{"", "Лечение кариеса", 0},
+		// Repeated words count once
+		{"Лечение кариеса", "Лечение лечение кариеса", 1},
+	}
+	for _, tt := range tests {
+		if got := matchScore(tokens(tt.procedure), tokens(tt.service)); got != tt.want {
+			t.Errorf("matchScore(%q, %q) = %v, want %v", tt.procedure, tt.service, got, tt.want)
+		}
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	entries := []models.PriceList{
+		{ID: 1, Specialization: models.SpecTherapy, ServiceName: "Лечение глубокого кариеса", Price: 7000},
+		{ID: 2, Specialization: models.SpecTherapy, ServiceName: "Лечение кариеса", Price: 5000},
+		{ID: 3, Specialization: models.SpecTherapy, ServiceName: "Пломба", ProcedureCode: "therapy_caries", Price: 4500},
+		{ID: 4, Specialization: models.SpecTherapy, ServiceName: "Пломба", ProcedureCode: "therapy_filling", Price: 0},
+		{ID: 5, Specialization: models.SpecHygiene, ServiceName: "Профессиональная чистка", Price: 3000},
+		{ID: 6, Specialization: models.SpecTherapy, ServiceName: "Пульпит", Price: 0},
+	}
+
+	tests := []struct {
+		name string
+		item models.TreatmentItem
+		want uint // 0 for no match
+	}{
+		{"catalog code", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Лечение кариеса", ProcedureCode: "therapy_caries"}, 3},
+		{"unpriced coded entry falls back to names", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Пломба", ProcedureCode: "therapy_filling"}, 3},
+		{"exact name beats a similar one", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Лечение кариеса"}, 2},
+		{"best similar name", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Лечение глубокого кариеса зуба"}, 1},
+		{"other specialization", models.TreatmentItem{Specialization: models.SpecSurgery, Procedure: "Лечение кариеса"}, 0},
+		{"too little overlap", models.TreatmentItem{Specialization: models.SpecHygiene, Procedure: "Чистка зубов ультразвуком"}, 0},
+		{"unpriced entries never match", models.TreatmentItem{Specialization: models.SpecTherapy, Procedure: "Пульпит"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BestMatch(&tt.item, entries)
+			switch {
+			case tt.want == 0 && got != nil:
+				t.Errorf("BestMatch = entry %d, want none", got.ID)
+			case tt.want != 0 && got == nil:
+				t.Errorf("BestMatch = none, want entry %d", tt.want)
+			case tt.want != 0 && got.ID != tt.want:
+				t.Errorf("BestMatch = entry %d, want %d", got.ID, tt.want)
+			}
+		})
+	}
+}
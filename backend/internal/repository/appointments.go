@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCancellationWindow is returned when a patient changes an appointment
+// that starts within the clinic's cancellation window
+var ErrCancellationWindow = errors.New("appointment starts within the clinic's cancellation window")
+
+// appointmentTransitions lists the statuses an appointment may move to from
+// each status. Completed, no-show and cancelled appointments are final.
+var appointmentTransitions = map[string][]string{
+	models.AppointmentStatusScheduled: {models.AppointmentStatusConfirmed, models.AppointmentStatusCancelled},
+	models.AppointmentStatusConfirmed: {models.AppointmentStatusCompleted, models.AppointmentStatusNoShow, models.AppointmentStatusCancelled},
+}
+
+// IsAppointmentStatus reports whether status is a known appointment status
+func IsAppointmentStatus(status string) bool {
+	switch status {
+	case models.AppointmentStatusScheduled, models.AppointmentStatusConfirmed, models.AppointmentStatusCompleted,
+		models.AppointmentStatusCancelled, models.AppointmentStatusNoShow:
+		return true
+	}
+	return false
+}
+
+// CanTransitionAppointment reports whether an appointment may move from one
+// status to another
+func CanTransitionAppointment(from, to string) bool {
+	return slices.Contains(appointmentTransitions[from], to)
+}
+
+// ==================== Appointment Operations ====================
+
+// TransitionAppointment moves one of the clinic's appointments to a new
+// status and records the change. Transitions outside the lifecycle return a
+// *TransitionError. Completing an appointment starts treatment on its plan
+// and lead, and completes both once every specialization of the accepted
+// offer has a completed appointment and none are still upcoming.
+func (r *Repository) TransitionAppointment(appointmentID, clinicID uint, to, notes string, actor Actor) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND clinic_id = ?", appointmentID, clinicID).
+			First(&appointment).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+
+		from := appointment.Status
+		if !CanTransitionAppointment(from, to) {
+			return &TransitionError{Entity: "appointment", From: from, To: to}
+		}
+
+		updates := map[string]interface{}{"status": to}
+		if notes != "" {
+			updates["notes"] = notes
+		}
+		if err := tx.Model(&appointment).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		change := newAppointmentChange(&appointment, models.AppointmentChangeStatus, actor, notes)
+		change.ToStatus = to
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+
+		appointment.Status = to
+		if notes != "" {
+			appointment.Notes = notes
+		}
+		if to != models.AppointmentStatusCompleted {
+			return nil
+		}
+		reason := fmt.Sprintf("appointment %d completed", appointment.ID)
+		if err := advanceLead(tx, appointment.ClinicOfferID, models.LeadStatusTreatmentStarted, actor, reason); err != nil {
+			return err
+		}
+		if appointment.TreatmentPlanID != 0 {
+			return advancePlanTreatment(tx, &appointment, actor)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// RescheduleAppointment moves a patient's upcoming appointment to another
+// slot and records the change. The new slot is checked for overlaps under
+// the resource lock, as when booking; a confirmed appointment goes back to
+// scheduled so the clinic confirms the new time.
+func (r *Repository) RescheduleAppointment(appointmentID, patientID, resourceID uint, start time.Time, durationMinutes int, actor Actor, reason string) (*models.Appointment, error) {
+	var appointment *models.Appointment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		appointment, err = lockPatientAppointment(tx, appointmentID, patientID)
+		if err != nil {
+			return err
+		}
+		if err := checkCancellationWindow(tx, appointment, time.Now()); err != nil {
+			return err
+		}
+		if err := lockClinicResource(tx, appointment.ClinicID, resourceID); err != nil {
+			return err
+		}
+
+		end := start.Add(time.Duration(durationMinutes) * time.Minute)
+		var overlapping int64
+		if err := tx.Model(&models.Appointment{}).
+			Where("id <> ? AND resource_id = ? AND status <> ?", appointment.ID, resourceID, models.AppointmentStatusCancelled).
+			Where("appointment_date < ? AND appointment_date + duration_minutes * interval '1 minute' > ?", end, start).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrSlotTaken
+		}
+
+		change := newAppointmentChange(appointment, models.AppointmentChangeRescheduled, actor, reason)
+		change.ToStatus = models.AppointmentStatusScheduled
+		change.ToResourceID = &resourceID
+		change.ToDate = &start
+
+		if err := tx.Model(appointment).Updates(map[string]interface{}{
+			"resource_id":      resourceID,
+			"appointment_date": start,
+			"duration_minutes": durationMinutes,
+			"status":           models.AppointmentStatusScheduled,
+		}).Error; err != nil {
+			if r.isUniqueViolation(err) {
+				return ErrSlotTaken
+			}
+			return err
+		}
+		appointment.ResourceID = &resourceID
+		appointment.AppointmentDate = start
+		appointment.DurationMinutes = durationMinutes
+		appointment.Status = models.AppointmentStatusScheduled
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// CancelAppointment cancels a patient's upcoming appointment and records the
+// change. The slot is freed for other bookings.
+func (r *Repository) CancelAppointment(appointmentID, patientID uint, actor Actor, reason string) (*models.Appointment, error) {
+	var appointment *models.Appointment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		appointment, err = lockPatientAppointment(tx, appointmentID, patientID)
+		if err != nil {
+			return err
+		}
+		if err := checkCancellationWindow(tx, appointment, time.Now()); err != nil {
+			return err
+		}
+		return cancelAppointment(tx, appointment, actor, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// cancelAppointment cancels a locked appointment and records the change
+func cancelAppointment(tx *gorm.DB, appointment *models.Appointment, actor Actor, reason string) error {
+	if !CanTransitionAppointment(appointment.Status, models.AppointmentStatusCancelled) {
+		return &TransitionError{Entity: "appointment", From: appointment.Status, To: models.AppointmentStatusCancelled}
+	}
+
+	change := newAppointmentChange(appointment, models.AppointmentChangeCancelled, actor, reason)
+	change.ToStatus = models.AppointmentStatusCancelled
+
+	if err := tx.Model(appointment).Update("status", models.AppointmentStatusCancelled).Error; err != nil {
+		return err
+	}
+	appointment.Status = models.AppointmentStatusCancelled
+	return tx.Create(change).Error
+}
+
+// UpdateClinicCancellationWindow sets how many hours before an appointment
+// patients may still cancel or reschedule it
+func (r *Repository) UpdateClinicCancellationWindow(clinicID uint, hours int) error {
+	return r.db.Model(&models.Clinic{}).
+		Where("id = ?", clinicID).
+		Update("cancellation_window_hours", hours).Error
+}
+
+// lockPatientAppointment locks one of the patient's appointments that can
+// still be cancelled or moved
+func lockPatientAppointment(tx *gorm.DB, appointmentID, patientID uint) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND patient_id = ?", appointmentID, patientID).
+		First(&appointment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	if !CanTransitionAppointment(appointment.Status, models.AppointmentStatusCancelled) {
+		return nil, &TransitionError{Entity: "appointment", From: appointment.Status, To: models.AppointmentStatusCancelled}
+	}
+	return &appointment, nil
+}
+
+// checkCancellationWindow rejects changes to an appointment starting within
+// its clinic's cancellation window
+func checkCancellationWindow(tx *gorm.DB, appointment *models.Appointment, now time.Time) error {
+	var clinic models.Clinic
+	if err := tx.Select("id", "cancellation_window_hours").First(&clinic, appointment.ClinicID).Error; err != nil {
+		return err
+	}
+	window := time.Duration(clinic.CancellationWindowHours) * time.Hour
+	if now.Add(window).After(appointment.AppointmentDate) {
+		return ErrCancellationWindow
+	}
+	return nil
+}
+
+// newAppointmentChange starts a history entry from the appointment's
+// current state
+func newAppointmentChange(appointment *models.Appointment, action string, actor Actor, reason string) *models.AppointmentChange {
+	change := &models.AppointmentChange{
+		AppointmentID:  appointment.ID,
+		Action:         action,
+		FromStatus:     appointment.Status,
+		FromResourceID: appointment.ResourceID,
+		FromDate:       appointment.AppointmentDate,
+		ActorRole:      actor.Role,
+		Reason:         reason,
+	}
+	if actor.UserID != 0 {
+		userID := actor.UserID
+		change.ActorUserID = &userID
+	}
+	return change
+}
+
+// advancePlanTreatment moves the plan of a completed appointment into
+// treatment, and the plan and lead on to completed when its treatment is done
+func advancePlanTreatment(tx *gorm.DB, appointment *models.Appointment, actor Actor) error {
+	plan, err := lockTreatmentPlan(tx, appointment.TreatmentPlanID)
+	if err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("appointment %d completed", appointment.ID)
+	if plan.Status == models.PlanStatusOfferSelected {
+		if err := applyPlanTransition(tx, plan, models.PlanStatusInTreatment, actor, reason); err != nil {
+			return err
+		}
+	}
+	if plan.Status != models.PlanStatusInTreatment {
+		return nil
+	}
+
+	done, err := planTreatmentDone(tx, plan.ID, appointment.ClinicOfferID)
+	if err != nil || !done {
+		return err
+	}
+	if err := applyPlanTransition(tx, plan, models.PlanStatusCompleted, actor, reason); err != nil {
+		return err
+	}
+	return advanceLead(tx, appointment.ClinicOfferID, models.LeadStatusTreatmentCompleted, actor, reason)
+}
+
+// planTreatmentDone reports whether every specialization the offer covers
+// has a completed appointment on the plan and no appointment is upcoming
+func planTreatmentDone(tx *gorm.DB, planID, offerID uint) (bool, error) {
+	var upcoming int64
+	if err := tx.Model(&models.Appointment{}).
+		Where("treatment_plan_id = ? AND status IN ?", planID,
+			[]string{models.AppointmentStatusScheduled, models.AppointmentStatusConfirmed}).
+		Count(&upcoming).Error; err != nil {
+		return false, err
+	}
+	if upcoming > 0 {
+		return false, nil
+	}
+
+	var covered []string
+	if err := tx.Model(&models.OfferItem{}).
+		Where("clinic_offer_id = ? AND excluded = ?", offerID, false).
+		Distinct().Pluck("specialization", &covered).Error; err != nil {
+		return false, err
+	}
+
+	var completed []string
+	if err := tx.Model(&models.Appointment{}).
+		Where("treatment_plan_id = ? AND status = ?", planID, models.AppointmentStatusCompleted).
+		Distinct().Pluck("specialization", &completed).Error; err != nil {
+		return false, err
+	}
+
+	for _, spec := range covered {
+		if !slices.Contains(completed, spec) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
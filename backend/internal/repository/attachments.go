@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTooManyAttachments is returned when a complaint or review already has
+// the maximum number of attachments
+var ErrTooManyAttachments = errors.New("attachment limit reached")
+
+// ==================== Attachment Operations ====================
+
+// CreateComplaintAttachment attaches a file to one of the patient's active
+// complaints and adds it to the timeline. At most limit files are kept per
+// complaint.
+func (r *Repository) CreateComplaintAttachment(patientID, complaintID uint, attachment *models.Attachment, limit int, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		complaint, err := lockComplaint(tx, "id = ? AND patient_id = ?", complaintID, patientID)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(activeComplaintStatuses, complaint.Status) {
+			return ErrComplaintNotActive
+		}
+		if err := checkAttachmentLimit(tx, "complaint_id = ?", complaint.ID, limit); err != nil {
+			return err
+		}
+
+		attachment.ComplaintID = &complaint.ID
+		if err := tx.Create(attachment).Error; err != nil {
+			return err
+		}
+
+		event := complaintEvent(complaint.ID, models.ComplaintEventAttachmentAdded, actor)
+		event.Body = attachment.FileName
+		return tx.Create(event).Error
+	})
+}
+
+// CreateReviewAttachment attaches a file to one of the patient's reviews. At
+// most limit files are kept per review.
+func (r *Repository) CreateReviewAttachment(patientID, reviewID uint, attachment *models.Attachment, limit int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND patient_id = ?", reviewID, patientID).
+			First(&review).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkAttachmentLimit(tx, "review_id = ?", review.ID, limit); err != nil {
+			return err
+		}
+
+		attachment.ReviewID = &review.ID
+		return tx.Create(attachment).Error
+	})
+}
+
+// GetAttachment retrieves an attachment with the complaint or review it
+// belongs to, for access checks
+func (r *Repository) GetAttachment(attachmentID uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.Preload("Complaint").Preload("Review").First(&attachment, attachmentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// checkAttachmentLimit verifies that the parent matched by query has fewer
+// than limit attachments. The parent row must be locked.
+func checkAttachmentLimit(tx *gorm.DB, query string, parentID uint, limit int) error {
+	var count int64
+	if err := tx.Model(&models.Attachment{}).Where(query, parentID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return ErrTooManyAttachments
+	}
+	return nil
+}
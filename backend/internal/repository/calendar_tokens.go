@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Calendar Feed Operations ====================
+
+// IssueCalendarToken revokes the user's active feed tokens and stores a new
+// one, so each user has at most one working feed URL
+func (r *Repository) IssueCalendarToken(userID uint, tokenHash string) (*models.CalendarToken, error) {
+	token := &models.CalendarToken{
+		UserID:    userID,
+		TokenHash: tokenHash,
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := revokeCalendarTokens(tx, userID, time.Now()); err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// RevokeCalendarTokens revokes the user's active feed tokens
+func (r *Repository) RevokeCalendarTokens(userID uint) error {
+	return revokeCalendarTokens(r.db, userID, time.Now())
+}
+
+// GetUserByCalendarToken retrieves the active user an unrevoked feed token
+// belongs to and records its use
+func (r *Repository) GetUserByCalendarToken(tokenHash string) (*models.User, error) {
+	var token models.CalendarToken
+	err := r.db.Where("token_hash = ? AND revoked_at IS NULL", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	var user models.User
+	err = r.db.Where("id = ? AND is_active = ?", token.UserID, true).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	if err := r.db.Model(&token).Update("last_used_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func revokeCalendarTokens(tx *gorm.DB, userID uint, now time.Time) error {
+	return tx.Model(&models.CalendarToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
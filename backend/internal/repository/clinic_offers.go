@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// offerTransitions lists the statuses an offer may move to from each status.
+// Accepted, rejected, expired and withdrawn offers are final.
+var offerTransitions = map[string][]string{
+	models.OfferStatusPending: {models.OfferStatusSent, models.OfferStatusWithdrawn},
+	models.OfferStatusSent: {
+		models.OfferStatusAccepted,
+		models.OfferStatusRejected,
+		models.OfferStatusExpired,
+		models.OfferStatusWithdrawn,
+	},
+}
+
+// CanTransitionOffer reports whether an offer may move from one status to another
+func CanTransitionOffer(from, to string) bool {
+	return slices.Contains(offerTransitions[from], to)
+}
+
+// offerValidityPassed reports whether a sent offer is past its validity
+func offerValidityPassed(offer *models.ClinicOffer, now time.Time) bool {
+	return offer.ValidUntil != nil && !now.Before(*offer.ValidUntil)
+}
+
+// ==================== Clinic Offer Operations ====================
+
+// GetClinicOfferByID retrieves an offer with its items and prior versions
+func (r *Repository) GetClinicOfferByID(offerID uint) (*models.ClinicOffer, error) {
+	var offer models.ClinicOffer
+	err := r.db.Preload("Items.TreatmentItem").
+		Preload("Revisions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("version ASC")
+		}).
+		First(&offer, offerID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// UpdateClinicOffer replaces the terms and items of a pending or sent offer.
+// Pending drafts are edited in place. A sent offer is revised: its current
+// version is kept as an immutable revision and the version number is
+// bumped; the plan must still accept offers from the clinic.
+func (r *Repository) UpdateClinicOffer(offer *models.ClinicOffer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockClinicOffer(tx, offer.ID)
+		if err != nil {
+			return err
+		}
+
+		columns := []string{
+			"therapy_cost", "orthopedics_cost", "surgery_cost", "hygiene_cost", "periodontics_cost",
+			"total_cost", "discount_amount",
+			"estimated_duration", "installment_months", "warranty_details", "notes", "wait_days",
+		}
+
+		switch current.Status {
+		case models.OfferStatusPending:
+			offer.Version = current.Version
+		case models.OfferStatusSent:
+			if _, err := admitOffer(tx, current.TreatmentPlanID, current.ClinicID); err != nil {
+				return err
+			}
+			if err := snapshotClinicOffer(tx, current); err != nil {
+				return err
+			}
+			offer.Version = current.Version + 1
+			columns = append(columns, "version", "valid_until")
+		default:
+			return &TransitionError{Entity: "clinic offer", From: current.Status, To: current.Status}
+		}
+		offer.Status = current.Status
+
+		// Items are replaced wholesale; hard delete keeps the unique index free
+		if err := tx.Unscoped().Where("clinic_offer_id = ?", offer.ID).Delete(&models.OfferItem{}).Error; err != nil {
+			return err
+		}
+		for i := range offer.Items {
+			offer.Items[i].ID = 0
+			offer.Items[i].ClinicOfferID = offer.ID
+		}
+		if len(offer.Items) > 0 {
+			if err := tx.Create(&offer.Items).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(current).Select(columns).Updates(offer).Error
+	})
+}
+
+// snapshotClinicOffer stores the offer's current version as a revision
+func snapshotClinicOffer(tx *gorm.DB, offer *models.ClinicOffer) error {
+	var items []models.OfferItem
+	if err := tx.Where("clinic_offer_id = ?", offer.ID).Order("id ASC").Find(&items).Error; err != nil {
+		return err
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ClinicOfferRevision{
+		ClinicOfferID:     offer.ID,
+		Version:           offer.Version,
+		TherapyCost:       offer.TherapyCost,
+		OrthopedicsCost:   offer.OrthopedicsCost,
+		SurgeryCost:       offer.SurgeryCost,
+		HygieneCost:       offer.HygieneCost,
+		PeriodonticsCost:  offer.PeriodonticsCost,
+		TotalCost:         offer.TotalCost,
+		DiscountAmount:    offer.DiscountAmount,
+		EstimatedDuration: offer.EstimatedDuration,
+		InstallmentMonths: offer.InstallmentMonths,
+		WarrantyDetails:   offer.WarrantyDetails,
+		Notes:             offer.Notes,
+		WaitDays:          offer.WaitDays,
+		ValidUntil:        offer.ValidUntil,
+		Items:             itemsJSON,
+	}).Error
+}
+
+// SendClinicOffer sends a pending offer to the patient. The plan must still
+// accept offers from the clinic.
+func (r *Repository) SendClinicOffer(offerID uint, validUntil time.Time, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		offer, err := lockClinicOffer(tx, offerID)
+		if err != nil {
+			return err
+		}
+		if !CanTransitionOffer(offer.Status, models.OfferStatusSent) {
+			return &TransitionError{Entity: "clinic offer", From: offer.Status, To: models.OfferStatusSent}
+		}
+
+		plan, err := admitOffer(tx, offer.TreatmentPlanID, offer.ClinicID)
+		if err != nil {
+			return err
+		}
+		if err := markOffersReceived(tx, plan, actor); err != nil {
+			return err
+		}
+
+		return tx.Model(offer).Updates(map[string]interface{}{
+			"status":      models.OfferStatusSent,
+			"valid_until": validUntil,
+		}).Error
+	})
+}
+
+// WithdrawClinicOffer withdraws a sent offer, which the patient keeps
+// seeing as withdrawn. A draft the patient never saw is discarded instead.
+func (r *Repository) WithdrawClinicOffer(offerID uint, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		offer, err := lockClinicOffer(tx, offerID)
+		if err != nil {
+			return err
+		}
+		return withdrawOffer(tx, offer, now)
+	})
+}
+
+// withdrawOffer withdraws a locked offer: a sent offer is marked withdrawn
+// and a pending draft is discarded
+func withdrawOffer(tx *gorm.DB, offer *models.ClinicOffer, now time.Time) error {
+	if !CanTransitionOffer(offer.Status, models.OfferStatusWithdrawn) {
+		return &TransitionError{Entity: "clinic offer", From: offer.Status, To: models.OfferStatusWithdrawn}
+	}
+	if offer.Status == models.OfferStatusPending {
+		return tx.Delete(offer).Error
+	}
+
+	if err := tx.Model(offer).Updates(map[string]interface{}{
+		"status":       models.OfferStatusWithdrawn,
+		"withdrawn_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	offer.Status = models.OfferStatusWithdrawn
+	offer.WithdrawnAt = &now
+	return nil
+}
+
+// ExpireClinicOffers expires sent offers whose validity has passed and
+// returns how many were expired
+func (r *Repository) ExpireClinicOffers(now time.Time) (int64, error) {
+	result := r.db.Model(&models.ClinicOffer{}).
+		Where("status = ? AND valid_until <= ?", models.OfferStatusSent, now).
+		Update("status", models.OfferStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+func lockClinicOffer(tx *gorm.DB, offerID uint) (*models.ClinicOffer, error) {
+	var offer models.ClinicOffer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&offer, offerID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// ==================== Clinic Discount Operations ====================
+
+// GetClinicDiscounts retrieves a clinic's discounts
+func (r *Repository) GetClinicDiscounts(clinicID uint) ([]models.ClinicDiscount, error) {
+	var discounts []models.ClinicDiscount
+	err := r.db.Where("clinic_id = ?", clinicID).
+		Order("percent DESC, id ASC").
+		Find(&discounts).Error
+	return discounts, err
+}
+
+// ReplaceClinicDiscounts replaces all of a clinic's discounts
+func (r *Repository) ReplaceClinicDiscounts(clinicID uint, discounts []models.ClinicDiscount) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("clinic_id = ?", clinicID).Delete(&models.ClinicDiscount{}).Error; err != nil {
+			return err
+		}
+		for i := range discounts {
+			discounts[i].ID = 0
+			discounts[i].ClinicID = clinicID
+		}
+		if len(discounts) == 0 {
+			return nil
+		}
+		return tx.Create(&discounts).Error
+	})
+}
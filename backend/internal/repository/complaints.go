@@ -0,0 +1,444 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrAssigneeNotFound is returned when a complaint is assigned to someone
+	// who is not an active regulator
+	ErrAssigneeNotFound = errors.New("assignee is not an active regulator")
+	// ErrComplaintNotActive is returned when a resolved or closed complaint is
+	// sent to the clinic for a response
+	ErrComplaintNotActive = errors.New("complaint is already resolved")
+	// ErrResolutionRequired is returned when a complaint is resolved, or an
+	// open complaint dismissed, without a resolution
+	ErrResolutionRequired = errors.New("a resolution is required to resolve or dismiss a complaint")
+	// ErrResponseNotRequested is returned when a clinic answers a complaint
+	// no response is pending for
+	ErrResponseNotRequested = errors.New("no clinic response is pending for this complaint")
+)
+
+// complaintTransitions lists the statuses a regulator may move a complaint to
+// from each status. Open complaints may be closed without investigation and
+// resolved ones reopened; closed complaints are final.
+var complaintTransitions = map[string][]string{
+	models.ComplaintStatusOpen:       {models.ComplaintStatusInProgress, models.ComplaintStatusClosed},
+	models.ComplaintStatusInProgress: {models.ComplaintStatusResolved},
+	models.ComplaintStatusResolved:   {models.ComplaintStatusInProgress, models.ComplaintStatusClosed},
+	models.ComplaintStatusClosed:     nil,
+}
+
+// activeComplaintStatuses are the statuses SLA deadlines apply to
+var activeComplaintStatuses = []string{models.ComplaintStatusOpen, models.ComplaintStatusInProgress}
+
+// IsComplaintStatus reports whether status is a known complaint status
+func IsComplaintStatus(status string) bool {
+	_, ok := complaintTransitions[status]
+	return ok
+}
+
+// CanTransitionComplaint reports whether a regulator may move a complaint
+// from one status to another
+func CanTransitionComplaint(from, to string) bool {
+	return slices.Contains(complaintTransitions[from], to)
+}
+
+// ComplaintFilter narrows the complaint list
+type ComplaintFilter struct {
+	Status         string
+	ClinicID       *uint
+	AssignedUserID *uint // 0 for unassigned complaints
+	Overdue        bool  // only complaints past a resolution or clinic response deadline
+}
+
+// ComplaintUpdate changes a complaint's status, assignee or both. A zero
+// AssignedUserID unassigns the complaint.
+type ComplaintUpdate struct {
+	Status         string
+	Resolution     string
+	AssignedUserID *uint
+}
+
+// ==================== Complaint Operations ====================
+
+// CreateComplaint files a patient's complaint about a clinic and opens its
+// timeline
+func (r *Repository) CreateComplaint(complaint *models.Complaint, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var clinic models.Clinic
+		err := tx.Select("id").First(&clinic, complaint.ClinicID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		complaint.Status = models.ComplaintStatusOpen
+		if err := tx.Create(complaint).Error; err != nil {
+			return err
+		}
+
+		event := complaintEvent(complaint.ID, models.ComplaintEventCreated, actor)
+		event.ToStatus = models.ComplaintStatusOpen
+		return tx.Create(event).Error
+	})
+}
+
+// GetAllComplaints retrieves complaints matching filter, newest first
+func (r *Repository) GetAllComplaints(filter ComplaintFilter) ([]models.Complaint, error) {
+	now := time.Now()
+	query := r.db.Preload("Patient").Preload("Clinic").Preload("AssignedUser")
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.ClinicID != nil {
+		query = query.Where("clinic_id = ?", *filter.ClinicID)
+	}
+	if filter.AssignedUserID != nil {
+		if *filter.AssignedUserID == 0 {
+			query = query.Where("assigned_user_id IS NULL")
+		} else {
+			query = query.Where("assigned_user_id = ?", *filter.AssignedUserID)
+		}
+	}
+	if filter.Overdue {
+		query = query.Where(
+			"status IN ? AND (due_at < ? OR (clinic_responded_at IS NULL AND clinic_response_due_at < ?))",
+			activeComplaintStatuses, now, now,
+		)
+	}
+
+	var complaints []models.Complaint
+	if err := query.Order("created_at DESC").Find(&complaints).Error; err != nil {
+		return nil, err
+	}
+	for i := range complaints {
+		flagComplaintOverdue(&complaints[i], now)
+	}
+	return complaints, nil
+}
+
+// GetComplaint retrieves a complaint with its full timeline
+func (r *Repository) GetComplaint(complaintID uint) (*models.Complaint, error) {
+	var complaint models.Complaint
+	err := r.db.Preload("Patient").Preload("Clinic").Preload("AssignedUser").Preload("Attachments").
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at, id")
+		}).
+		First(&complaint, complaintID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	flagComplaintOverdue(&complaint, time.Now())
+	return &complaint, nil
+}
+
+// UpdateComplaint assigns a complaint and/or moves it through its lifecycle.
+// Resolving a complaint notifies the patient.
+func (r *Repository) UpdateComplaint(complaintID uint, update ComplaintUpdate, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		complaint, err := lockComplaint(tx, "id = ?", complaintID)
+		if err != nil {
+			return err
+		}
+
+		if update.AssignedUserID != nil && !sameUserID(complaint.AssignedUserID, *update.AssignedUserID) {
+			var assignee interface{}
+			if *update.AssignedUserID != 0 {
+				if err := checkRegulator(tx, *update.AssignedUserID); err != nil {
+					return err
+				}
+				assignee = *update.AssignedUserID
+			}
+			if err := tx.Model(complaint).Update("assigned_user_id", assignee).Error; err != nil {
+				return err
+			}
+
+			event := complaintEvent(complaint.ID, models.ComplaintEventAssigned, actor)
+			if *update.AssignedUserID != 0 {
+				event.AssignedUserID = update.AssignedUserID
+			}
+			event.Internal = true
+			if err := tx.Create(event).Error; err != nil {
+				return err
+			}
+		}
+
+		if update.Status != "" && update.Status != complaint.Status {
+			if !CanTransitionComplaint(complaint.Status, update.Status) {
+				return &TransitionError{Entity: "complaint", From: complaint.Status, To: update.Status}
+			}
+			dismissed := complaint.Status == models.ComplaintStatusOpen && update.Status == models.ComplaintStatusClosed
+			if update.Resolution == "" && (update.Status == models.ComplaintStatusResolved || dismissed) {
+				return ErrResolutionRequired
+			}
+			return applyComplaintTransition(tx, complaint, update.Status, update.Resolution, actor)
+		}
+		return nil
+	})
+}
+
+// AddComplaintNote adds an internal note to a complaint's timeline
+func (r *Repository) AddComplaintNote(complaintID uint, note string, actor Actor) (*models.ComplaintEvent, error) {
+	var event *models.ComplaintEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockComplaint(tx, "id = ?", complaintID); err != nil {
+			return err
+		}
+		event = complaintEvent(complaintID, models.ComplaintEventNote, actor)
+		event.Body = note
+		event.Internal = true
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// RequestClinicResponse asks the clinic to answer a complaint by due and
+// notifies it. An open complaint moves to in_progress; an earlier answer is
+// kept in the timeline and replaced by the next one.
+func (r *Repository) RequestClinicResponse(complaintID uint, message string, due time.Time, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		complaint, err := lockComplaint(tx, "id = ?", complaintID)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(activeComplaintStatuses, complaint.Status) {
+			return ErrComplaintNotActive
+		}
+		if complaint.Status == models.ComplaintStatusOpen {
+			if err := applyComplaintTransition(tx, complaint, models.ComplaintStatusInProgress, "", actor); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(complaint).Updates(map[string]interface{}{
+			"clinic_response_requested_at": time.Now(),
+			"clinic_response_due_at":       due,
+			"clinic_response":              "",
+			"clinic_responded_at":          nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		event := complaintEvent(complaint.ID, models.ComplaintEventResponseRequested, actor)
+		event.Body = message
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		var clinic models.Clinic
+		if err := tx.Select("id", "user_id").First(&clinic, complaint.ClinicID).Error; err != nil {
+			return err
+		}
+		return notify(tx, clinic.UserID, models.NotificationComplaintResponseRequested,
+			fmt.Sprintf("Response requested for complaint #%d by %s", complaint.ID, due.Format("2006-01-02 15:04")),
+			message, "complaint", complaint.ID)
+	})
+}
+
+// GetPatientComplaints retrieves the patient's complaints with their
+// attachments, newest first. Regulators' timeline is not included.
+func (r *Repository) GetPatientComplaints(patientID uint) ([]models.Complaint, error) {
+	var complaints []models.Complaint
+	err := r.db.Preload("Clinic").Preload("Attachments").
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&complaints).Error
+	return complaints, err
+}
+
+// GetClinicComplaints retrieves the complaints the clinic has been asked to
+// answer, without regulators' internal events
+func (r *Repository) GetClinicComplaints(clinicID uint) ([]models.Complaint, error) {
+	now := time.Now()
+	var complaints []models.Complaint
+	err := r.db.Preload("Patient").
+		Where("clinic_id = ? AND clinic_response_requested_at IS NOT NULL", clinicID).
+		Order("clinic_response_requested_at DESC").
+		Find(&complaints).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range complaints {
+		flagComplaintOverdue(&complaints[i], now)
+	}
+	return complaints, nil
+}
+
+// GetClinicComplaint retrieves a complaint the clinic has been asked to
+// answer, with the timeline entries visible to the clinic
+func (r *Repository) GetClinicComplaint(clinicID, complaintID uint) (*models.Complaint, error) {
+	var complaint models.Complaint
+	err := r.db.Preload("Patient").Preload("Attachments").
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Where("internal = ?", false).Order("created_at, id")
+		}).
+		Where("id = ? AND clinic_id = ? AND clinic_response_requested_at IS NOT NULL", complaintID, clinicID).
+		First(&complaint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	flagComplaintOverdue(&complaint, time.Now())
+	return &complaint, nil
+}
+
+// RespondToComplaint records the clinic's answer to a pending response
+// request and notifies the assigned regulator
+func (r *Repository) RespondToComplaint(clinicID, complaintID uint, response string, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		complaint, err := lockComplaint(tx, "id = ? AND clinic_id = ? AND clinic_response_requested_at IS NOT NULL", complaintID, clinicID)
+		if err != nil {
+			return err
+		}
+		if complaint.ClinicRespondedAt != nil || !slices.Contains(activeComplaintStatuses, complaint.Status) {
+			return ErrResponseNotRequested
+		}
+
+		if err := tx.Model(complaint).Updates(map[string]interface{}{
+			"clinic_response":     response,
+			"clinic_responded_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		event := complaintEvent(complaint.ID, models.ComplaintEventClinicResponded, actor)
+		event.Body = response
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		if complaint.AssignedUserID == nil {
+			return nil
+		}
+		return notify(tx, *complaint.AssignedUserID, models.NotificationComplaintClinicResponded,
+			fmt.Sprintf("Clinic responded to complaint #%d", complaint.ID),
+			response, "complaint", complaint.ID)
+	})
+}
+
+// lockComplaint loads a complaint matching query and locks its row
+func lockComplaint(tx *gorm.DB, query string, args ...interface{}) (*models.Complaint, error) {
+	var complaint models.Complaint
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(query, args...).
+		First(&complaint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &complaint, nil
+}
+
+// applyComplaintTransition moves a locked complaint to a new status and adds
+// it to the timeline. Resolved and dismissed complaints notify the patient.
+func applyComplaintTransition(tx *gorm.DB, complaint *models.Complaint, to, resolution string, actor Actor) error {
+	from := complaint.Status
+	now := time.Now()
+
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case models.ComplaintStatusResolved:
+		updates["resolved_at"] = now
+		updates["resolution"] = resolution
+	case models.ComplaintStatusClosed:
+		updates["closed_at"] = now
+		if resolution != "" {
+			updates["resolution"] = resolution
+		}
+	case models.ComplaintStatusInProgress:
+		updates["resolved_at"] = nil
+	}
+	if err := tx.Model(complaint).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	event := complaintEvent(complaint.ID, models.ComplaintEventStatusChanged, actor)
+	event.FromStatus = from
+	event.ToStatus = to
+	event.Body = resolution
+	if err := tx.Create(event).Error; err != nil {
+		return err
+	}
+	complaint.Status = to
+
+	// Closing a resolved complaint is bookkeeping; the patient already knows
+	dismissed := to == models.ComplaintStatusClosed && from == models.ComplaintStatusOpen
+	if to != models.ComplaintStatusResolved && !dismissed {
+		return nil
+	}
+	var patient models.Patient
+	if err := tx.Select("id", "user_id").First(&patient, complaint.PatientID).Error; err != nil {
+		return err
+	}
+	return notify(tx, patient.UserID, models.NotificationComplaintResolved,
+		fmt.Sprintf("Your complaint #%d has been %s", complaint.ID, to),
+		resolution, "complaint", complaint.ID)
+}
+
+// complaintEvent starts a timeline entry made by actor
+func complaintEvent(complaintID uint, kind string, actor Actor) *models.ComplaintEvent {
+	event := &models.ComplaintEvent{
+		ComplaintID: complaintID,
+		Kind:        kind,
+		ActorRole:   actor.Role,
+	}
+	if actor.UserID != 0 {
+		userID := actor.UserID
+		event.ActorUserID = &userID
+	}
+	return event
+}
+
+// checkRegulator verifies that userID is an active regulator
+func checkRegulator(tx *gorm.DB, userID uint) error {
+	var count int64
+	if err := tx.Model(&models.User{}).
+		Where("id = ? AND role = ? AND is_active = ?", userID, models.RoleRegulator, true).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrAssigneeNotFound
+	}
+	return nil
+}
+
+// sameUserID reports whether an optional user ID already equals id, with 0
+// standing for none
+func sameUserID(current *uint, id uint) bool {
+	if current == nil {
+		return id == 0
+	}
+	return *current == id
+}
+
+// flagComplaintOverdue sets the SLA flags of an active complaint
+func flagComplaintOverdue(complaint *models.Complaint, now time.Time) {
+	if !slices.Contains(activeComplaintStatuses, complaint.Status) {
+		return
+	}
+	complaint.Overdue = complaint.DueAt != nil && now.After(*complaint.DueAt)
+	complaint.ClinicResponseOverdue = complaint.ClinicResponseDueAt != nil &&
+		complaint.ClinicRespondedAt == nil && now.After(*complaint.ClinicResponseDueAt)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"dental-marketplace/backend/internal/models"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -85,3 +86,31 @@ func (r *ConstantsRepository) GetDistrictsByCity(cityID uint) ([]models.District
 	err := r.db.Where("city_id = ? AND is_active = ?", cityID, true).Order("sort_order, name").Find(&districts).Error
 	return districts, err
 }
+
+func (r *ConstantsRepository) GetProcedures() ([]models.Procedure, error) {
+	var procedures []models.Procedure
+	err := r.db.Preload("Synonyms").Where("is_active = ?", true).
+		Order("specialization, sort_order, name").Find(&procedures).Error
+	return procedures, err
+}
+
+// SearchProcedures finds active procedures whose code, name or synonym
+// contains the query, optionally within one specialization
+func (r *ConstantsRepository) SearchProcedures(query, specialization string, limit int) ([]models.Procedure, error) {
+	db := r.db.Preload("Synonyms").Where("is_active = ?", true)
+
+	if specialization != "" {
+		db = db.Where("specialization = ?", specialization)
+	}
+	if query != "" {
+		pattern := "%" + strings.ToLower(query) + "%"
+		db = db.Where("LOWER(code) LIKE ? OR LOWER(name) LIKE ? OR id IN (?)",
+			pattern, pattern,
+			r.db.Model(&models.ProcedureSynonym{}).Select("procedure_id").Where("LOWER(name) LIKE ?", pattern),
+		)
+	}
+
+	var procedures []models.Procedure
+	err := db.Order("specialization, sort_order, name").Limit(limit).Find(&procedures).Error
+	return procedures, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Installment Operations ====================
+
+// GetInstallmentProducts retrieves a clinic's installment products
+func (r *Repository) GetInstallmentProducts(clinicID uint) ([]models.InstallmentProduct, error) {
+	var products []models.InstallmentProduct
+	err := r.db.Where("clinic_id = ?", clinicID).
+		Order("term_months ASC, id ASC").
+		Find(&products).Error
+	return products, err
+}
+
+// GetActiveInstallmentProducts retrieves the active installment products of
+// the given clinics
+func (r *Repository) GetActiveInstallmentProducts(clinicIDs []uint) ([]models.InstallmentProduct, error) {
+	var products []models.InstallmentProduct
+	if len(clinicIDs) == 0 {
+		return products, nil
+	}
+	err := r.db.Where("clinic_id IN ? AND is_active = ?", clinicIDs, true).
+		Order("term_months ASC, id ASC").
+		Find(&products).Error
+	return products, err
+}
+
+// GetInstallmentProductByID retrieves an installment product by ID
+func (r *Repository) GetInstallmentProductByID(productID uint) (*models.InstallmentProduct, error) {
+	var product models.InstallmentProduct
+	err := r.db.First(&product, productID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// ReplaceInstallmentProducts replaces all of a clinic's installment products
+// and keeps the clinic's OffersInstallment flag in line with them
+func (r *Repository) ReplaceInstallmentProducts(clinicID uint, products []models.InstallmentProduct) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("clinic_id = ?", clinicID).Delete(&models.InstallmentProduct{}).Error; err != nil {
+			return err
+		}
+
+		offersInstallment := false
+		for i := range products {
+			products[i].ID = 0
+			products[i].ClinicID = clinicID
+			offersInstallment = offersInstallment || products[i].IsActive
+		}
+		if len(products) > 0 {
+			if err := tx.Create(&products).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Clinic{}).
+			Where("id = ?", clinicID).
+			Update("offers_installment", offersInstallment).Error
+	})
+}
@@ -0,0 +1,396 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStaffNotFound is returned when a lead or task is assigned to someone who
+// is not an active member of the clinic's staff
+var ErrStaffNotFound = errors.New("staff member not found")
+
+// leadTransitions lists the statuses a clinic may move a lead to from each
+// status. Completed and rejected leads are final.
+var leadTransitions = map[string][]string{
+	models.LeadStatusNew:                   {models.LeadStatusContacted, models.LeadStatusConsultationScheduled, models.LeadStatusRejected},
+	models.LeadStatusContacted:             {models.LeadStatusConsultationScheduled, models.LeadStatusRejected},
+	models.LeadStatusConsultationScheduled: {models.LeadStatusContacted, models.LeadStatusTreatmentStarted, models.LeadStatusRejected},
+	models.LeadStatusTreatmentStarted:      {models.LeadStatusTreatmentCompleted, models.LeadStatusRejected},
+}
+
+// leadStages orders the pipeline for automatic advancement
+var leadStages = []string{
+	models.LeadStatusNew,
+	models.LeadStatusContacted,
+	models.LeadStatusConsultationScheduled,
+	models.LeadStatusTreatmentStarted,
+	models.LeadStatusTreatmentCompleted,
+}
+
+// IsLeadStatus reports whether status is a known lead status
+func IsLeadStatus(status string) bool {
+	return status == models.LeadStatusRejected || slices.Contains(leadStages, status)
+}
+
+// CanTransitionLead reports whether a clinic may move a lead from one status
+// to another
+func CanTransitionLead(from, to string) bool {
+	return slices.Contains(leadTransitions[from], to)
+}
+
+// LeadUpdate changes a lead's status, assignee or both. A zero
+// AssignedStaffID unassigns the lead.
+type LeadUpdate struct {
+	Status          string
+	Reason          string
+	AssignedStaffID *uint
+}
+
+// LeadMetrics summarizes a clinic's lead pipeline
+type LeadMetrics struct {
+	ByStatus         map[string]int64 `json:"by_status"`
+	Created          int64            `json:"created"`   // leads opened in the period
+	Converted        int64            `json:"converted"` // of those, leads that started treatment
+	Completed        int64            `json:"completed"` // of those, leads that completed treatment
+	Rejected         int64            `json:"rejected"`  // of those, rejected leads
+	ConversionRate   float64          `json:"conversion_rate"`
+	AvgDaysToConvert float64          `json:"avg_days_to_convert"`
+	OverdueTasks     int64            `json:"overdue_tasks"`
+}
+
+// ==================== Lead Operations ====================
+
+// GetClinicLeads retrieves a clinic's leads, optionally filtered by status
+// and assignee, newest first
+func (r *Repository) GetClinicLeads(clinicID uint, status string, staffID *uint) ([]models.Lead, error) {
+	query := r.db.Preload("Offer").
+		Preload("Patient").
+		Preload("AssignedStaff").
+		Preload("Tasks", "completed_at IS NULL").
+		Where("clinic_id = ?", clinicID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if staffID != nil {
+		query = query.Where("assigned_staff_id = ?", *staffID)
+	}
+
+	var leads []models.Lead
+	err := query.Order("created_at DESC").Find(&leads).Error
+	return leads, err
+}
+
+// GetClinicLead retrieves one of the clinic's leads with its tasks, contact
+// log and status history
+func (r *Repository) GetClinicLead(clinicID, leadID uint) (*models.Lead, error) {
+	var lead models.Lead
+	err := r.db.Preload("Offer.Items").
+		Preload("Patient").
+		Preload("AssignedStaff").
+		Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("completed_at IS NOT NULL, due_at ASC")
+		}).
+		Preload("Contacts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("contacted_at DESC, id DESC")
+		}).
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		Where("id = ? AND clinic_id = ?", leadID, clinicID).
+		First(&lead).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &lead, nil
+}
+
+// UpdateLead applies a clinic's change to one of its leads. Status changes
+// outside the lifecycle return a *TransitionError.
+func (r *Repository) UpdateLead(clinicID, leadID uint, update LeadUpdate, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		lead, err := lockLead(tx, "id = ? AND clinic_id = ?", leadID, clinicID)
+		if err != nil {
+			return err
+		}
+
+		if update.AssignedStaffID != nil {
+			var assignee interface{}
+			if *update.AssignedStaffID != 0 {
+				if err := checkClinicStaff(tx, clinicID, *update.AssignedStaffID); err != nil {
+					return err
+				}
+				assignee = *update.AssignedStaffID
+			}
+			if err := tx.Model(lead).Update("assigned_staff_id", assignee).Error; err != nil {
+				return err
+			}
+		}
+
+		if update.Status != "" && update.Status != lead.Status {
+			if !CanTransitionLead(lead.Status, update.Status) {
+				return &TransitionError{Entity: "lead", From: lead.Status, To: update.Status}
+			}
+			return applyLeadTransition(tx, lead, update.Status, actor, update.Reason)
+		}
+		return nil
+	})
+}
+
+// CreateLeadTask adds a follow-up task to one of the clinic's leads
+func (r *Repository) CreateLeadTask(clinicID uint, task *models.LeadTask) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockLead(tx, "id = ? AND clinic_id = ?", task.LeadID, clinicID); err != nil {
+			return err
+		}
+		if task.AssignedStaffID != nil {
+			if err := checkClinicStaff(tx, clinicID, *task.AssignedStaffID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(task).Error
+	})
+}
+
+// UpdateLeadTask updates a task on one of the clinic's leads
+func (r *Repository) UpdateLeadTask(clinicID uint, task *models.LeadTask) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockLead(tx, "id = ? AND clinic_id = ?", task.LeadID, clinicID); err != nil {
+			return err
+		}
+		if task.AssignedStaffID != nil {
+			if err := checkClinicStaff(tx, clinicID, *task.AssignedStaffID); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.LeadTask{}).
+			Where("id = ? AND lead_id = ?", task.ID, task.LeadID).
+			Select("assigned_staff_id", "title", "notes", "due_at", "completed_at").
+			Updates(task)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// AddLeadContact logs a contact with one of the clinic's leads. The first
+// contact with a new lead moves it to contacted.
+func (r *Repository) AddLeadContact(clinicID uint, contact *models.LeadContact, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		lead, err := lockLead(tx, "id = ? AND clinic_id = ?", contact.LeadID, clinicID)
+		if err != nil {
+			return err
+		}
+		if contact.StaffID != nil {
+			if err := checkClinicStaff(tx, clinicID, *contact.StaffID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(contact).Error; err != nil {
+			return err
+		}
+		if lead.Status == models.LeadStatusNew {
+			return applyLeadTransition(tx, lead, models.LeadStatusContacted, actor, "contact logged")
+		}
+		return nil
+	})
+}
+
+// GetLeadMetrics summarizes the clinic's pipeline. Conversion is measured on
+// the cohort of leads opened between startDate and endDate.
+func (r *Repository) GetLeadMetrics(clinicID uint, startDate, endDate time.Time) (*LeadMetrics, error) {
+	metrics := &LeadMetrics{ByStatus: make(map[string]int64)}
+
+	var byStatus []struct {
+		Status string
+		Count  int64
+	}
+	if err := r.db.Model(&models.Lead{}).
+		Select("status, COUNT(*) AS count").
+		Where("clinic_id = ?", clinicID).
+		Group("status").
+		Scan(&byStatus).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range byStatus {
+		metrics.ByStatus[row.Status] = row.Count
+	}
+
+	var cohort struct {
+		Created        int64
+		Converted      int64
+		Completed      int64
+		Rejected       int64
+		AvgDaysConvert float64
+	}
+	if err := r.db.Model(&models.Lead{}).
+		Select(`COUNT(*) AS created,
+			COUNT(converted_at) AS converted,
+			COUNT(*) FILTER (WHERE status = ?) AS completed,
+			COUNT(*) FILTER (WHERE status = ?) AS rejected,
+			COALESCE(AVG(EXTRACT(EPOCH FROM converted_at - created_at)) / 86400, 0) AS avg_days_convert`,
+			models.LeadStatusTreatmentCompleted, models.LeadStatusRejected).
+		Where("clinic_id = ? AND created_at BETWEEN ? AND ?", clinicID, startDate, endDate).
+		Scan(&cohort).Error; err != nil {
+		return nil, err
+	}
+	metrics.Created = cohort.Created
+	metrics.Converted = cohort.Converted
+	metrics.Completed = cohort.Completed
+	metrics.Rejected = cohort.Rejected
+	metrics.AvgDaysToConvert = cohort.AvgDaysConvert
+	if cohort.Created > 0 {
+		metrics.ConversionRate = float64(cohort.Converted) / float64(cohort.Created) * 100
+	}
+
+	if err := r.db.Model(&models.LeadTask{}).
+		Joins("JOIN leads ON leads.id = lead_tasks.lead_id AND leads.deleted_at IS NULL").
+		Where("leads.clinic_id = ? AND lead_tasks.completed_at IS NULL AND lead_tasks.due_at < ?", clinicID, time.Now()).
+		Count(&metrics.OverdueTasks).Error; err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// ==================== Clinic Staff Operations ====================
+
+// GetClinicStaff retrieves a clinic's staff
+func (r *Repository) GetClinicStaff(clinicID uint) ([]models.ClinicStaff, error) {
+	var staff []models.ClinicStaff
+	err := r.db.Where("clinic_id = ?", clinicID).Order("name ASC, id ASC").Find(&staff).Error
+	return staff, err
+}
+
+// CreateClinicStaff adds a staff member
+func (r *Repository) CreateClinicStaff(member *models.ClinicStaff) error {
+	return r.db.Create(member).Error
+}
+
+// UpdateClinicStaff updates one of the clinic's staff members
+func (r *Repository) UpdateClinicStaff(member *models.ClinicStaff) error {
+	result := r.db.Model(&models.ClinicStaff{}).
+		Where("id = ? AND clinic_id = ?", member.ID, member.ClinicID).
+		Select("name", "position", "email", "phone", "is_active").
+		Updates(member)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// createLead opens a new lead for an accepted offer
+func createLead(tx *gorm.DB, offer *models.ClinicOffer, plan *models.TreatmentPlan) error {
+	return tx.Create(&models.Lead{
+		ClinicID:        offer.ClinicID,
+		ClinicOfferID:   offer.ID,
+		TreatmentPlanID: plan.ID,
+		PatientID:       plan.PatientID,
+		Status:          models.LeadStatusNew,
+	}).Error
+}
+
+// advanceLead moves the lead of an offer forward to a pipeline stage,
+// skipping stages in between. Leads already at or past the stage, and
+// rejected leads, are left alone.
+func advanceLead(tx *gorm.DB, offerID uint, to string, actor Actor, reason string) error {
+	lead, err := lockLead(tx, "clinic_offer_id = ?", offerID)
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	current := slices.Index(leadStages, lead.Status)
+	if current < 0 || current >= slices.Index(leadStages, to) {
+		return nil
+	}
+	return applyLeadTransition(tx, lead, to, actor, reason)
+}
+
+// lockLead loads a lead inside tx and holds its row lock
+func lockLead(tx *gorm.DB, query string, args ...interface{}) (*models.Lead, error) {
+	var lead models.Lead
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&lead).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &lead, nil
+}
+
+// applyLeadTransition moves a locked lead to a new status and records the
+// change
+func applyLeadTransition(tx *gorm.DB, lead *models.Lead, to string, actor Actor, reason string) error {
+	from := lead.Status
+	now := time.Now()
+
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case models.LeadStatusTreatmentStarted:
+		if lead.ConvertedAt == nil {
+			updates["converted_at"] = now
+		}
+	case models.LeadStatusTreatmentCompleted:
+		if lead.ConvertedAt == nil {
+			updates["converted_at"] = now
+		}
+		updates["closed_at"] = now
+	case models.LeadStatusRejected:
+		updates["closed_at"] = now
+		updates["rejection_reason"] = reason
+	}
+	if err := tx.Model(lead).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	change := &models.LeadStatusChange{
+		LeadID:     lead.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorRole:  actor.Role,
+		Reason:     reason,
+	}
+	if actor.UserID != 0 {
+		userID := actor.UserID
+		change.ActorUserID = &userID
+	}
+	if err := tx.Create(change).Error; err != nil {
+		return err
+	}
+
+	lead.Status = to
+	return nil
+}
+
+// checkClinicStaff ensures staffID is an active member of the clinic's staff
+func checkClinicStaff(tx *gorm.DB, clinicID, staffID uint) error {
+	var count int64
+	if err := tx.Model(&models.ClinicStaff{}).
+		Where("id = ? AND clinic_id = ? AND is_active = ?", staffID, clinicID, true).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrStaffNotFound
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Notification Operations ====================
+
+// GetUserNotifications retrieves a user's notifications, newest first
+func (r *Repository) GetUserNotifications(userID uint, unreadOnly bool) ([]models.Notification, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC").Limit(200).Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkNotificationRead marks one of the user's notifications as read
+func (r *Repository) MarkNotificationRead(userID, notificationID uint) error {
+	result := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead marks all of the user's notifications as read
+func (r *Repository) MarkAllNotificationsRead(userID uint) error {
+	return r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+// notify queues a notification for a user inside tx, so it is only sent if
+// the change it reports is committed
+func notify(tx *gorm.DB, userID uint, kind, title, body, entityType string, entityID uint) error {
+	return tx.Create(&models.Notification{
+		UserID:     userID,
+		Kind:       kind,
+		Title:      title,
+		Body:       body,
+		EntityType: entityType,
+		EntityID:   &entityID,
+	}).Error
+}
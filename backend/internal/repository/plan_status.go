@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidTransition matches every *TransitionError
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// TransitionError reports a status change the lifecycle does not allow
+type TransitionError struct {
+	Entity string
+	From   string
+	To     string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s cannot move from %q to %q", e.Entity, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Actor identifies who triggered a status change
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+// SystemActor is used for transitions made by background jobs
+var SystemActor = Actor{Role: "system"}
+
+// planTransitions lists the statuses a treatment plan may move to from each
+// status. Completed and cancelled plans are final.
+var planTransitions = map[string][]string{
+	models.PlanStatusGenerated:       {models.PlanStatusOffersRequested, models.PlanStatusCancelled},
+	models.PlanStatusOffersRequested: {models.PlanStatusOffersReceived, models.PlanStatusCancelled},
+	models.PlanStatusOffersReceived:  {models.PlanStatusOfferSelected, models.PlanStatusCancelled},
+	models.PlanStatusOfferSelected:   {models.PlanStatusInTreatment, models.PlanStatusCancelled},
+	models.PlanStatusInTreatment:     {models.PlanStatusCompleted, models.PlanStatusCancelled},
+}
+
+// CanTransitionPlan reports whether a plan may move from one status to another
+func CanTransitionPlan(from, to string) bool {
+	return slices.Contains(planTransitions[from], to)
+}
+
+// ==================== Treatment Plan Status Operations ====================
+
+// TransitionTreatmentPlan moves a plan to a new status and records the change.
+// Transitions outside the lifecycle return a *TransitionError.
+func (r *Repository) TransitionTreatmentPlan(planID uint, to string, actor Actor, reason string) (*models.TreatmentPlan, error) {
+	var plan *models.TreatmentPlan
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plan, err = transitionPlan(tx, planID, to, actor, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// CancelTreatmentPlan cancels a plan together with everything clinics could
+// still act on: open offers are withdrawn, upcoming appointments cancelled
+// and open leads rejected, all in one transaction.
+func (r *Repository) CancelTreatmentPlan(planID uint, actor Actor, reason string) (*models.TreatmentPlan, error) {
+	var plan *models.TreatmentPlan
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plan, err = transitionPlan(tx, planID, models.PlanStatusCancelled, actor, reason)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		cause := fmt.Sprintf("treatment plan %d cancelled", planID)
+
+		var offers []models.ClinicOffer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("treatment_plan_id = ? AND status IN ?", planID, openOfferStatuses).
+			Find(&offers).Error; err != nil {
+			return err
+		}
+		for i := range offers {
+			if err := withdrawOffer(tx, &offers[i], now); err != nil {
+				return err
+			}
+		}
+
+		var appointments []models.Appointment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("treatment_plan_id = ? AND appointment_date > ? AND status IN ?", planID, now,
+				[]string{models.AppointmentStatusScheduled, models.AppointmentStatusConfirmed}).
+			Find(&appointments).Error; err != nil {
+			return err
+		}
+		for i := range appointments {
+			if err := cancelAppointment(tx, &appointments[i], actor, cause); err != nil {
+				return err
+			}
+		}
+
+		var leads []models.Lead
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("treatment_plan_id = ?", planID).
+			Find(&leads).Error; err != nil {
+			return err
+		}
+		for i := range leads {
+			if !CanTransitionLead(leads[i].Status, models.LeadStatusRejected) {
+				continue
+			}
+			if err := applyLeadTransition(tx, &leads[i], models.LeadStatusRejected, actor, cause); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// GetTreatmentPlanStatusHistory retrieves a plan's transitions, oldest first
+func (r *Repository) GetTreatmentPlanStatusHistory(planID uint) ([]models.TreatmentPlanStatusChange, error) {
+	var changes []models.TreatmentPlanStatusChange
+	err := r.db.Where("treatment_plan_id = ?", planID).
+		Order("created_at ASC, id ASC").
+		Find(&changes).Error
+	return changes, err
+}
+
+// lockTreatmentPlan loads a plan inside tx and holds its row lock until the
+// transaction ends, so concurrent transitions see each other's result
+func lockTreatmentPlan(tx *gorm.DB, planID uint) (*models.TreatmentPlan, error) {
+	var plan models.TreatmentPlan
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plan, planID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// transitionPlan performs a checked transition inside tx
+func transitionPlan(tx *gorm.DB, planID uint, to string, actor Actor, reason string) (*models.TreatmentPlan, error) {
+	plan, err := lockTreatmentPlan(tx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPlanTransition(tx, plan, to, actor, reason); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// applyPlanTransition moves an already locked plan to a new status
+func applyPlanTransition(tx *gorm.DB, plan *models.TreatmentPlan, to string, actor Actor, reason string) error {
+	from := plan.Status
+	if !CanTransitionPlan(from, to) {
+		return &TransitionError{Entity: "treatment plan", From: from, To: to}
+	}
+
+	if err := tx.Model(plan).Update("status", to).Error; err != nil {
+		return err
+	}
+
+	change := &models.TreatmentPlanStatusChange{
+		TreatmentPlanID: plan.ID,
+		FromStatus:      from,
+		ToStatus:        to,
+		ActorRole:       actor.Role,
+		Reason:          reason,
+	}
+	if actor.UserID != 0 {
+		userID := actor.UserID
+		change.ActorUserID = &userID
+	}
+	if err := tx.Create(change).Error; err != nil {
+		return err
+	}
+
+	plan.Status = to
+	return nil
+}
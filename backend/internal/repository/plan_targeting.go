@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrOfferDeadlinePassed is returned for offers sent after the plan's deadline
+var ErrOfferDeadlinePassed = errors.New("offer deadline has passed")
+
+// PlanTargeting restricts which clinics see a plan. Empty fields match any
+// clinic.
+type PlanTargeting struct {
+	City         string
+	District     string
+	PriceSegment string
+}
+
+// ==================== Plan Targeting Operations ====================
+
+// RequestTreatmentPlanOffers publishes a generated plan to the clinics that
+// match targeting. A nil deadline keeps the plan open until an offer is
+// selected.
+func (r *Repository) RequestTreatmentPlanOffers(planID uint, targeting PlanTargeting, deadline *time.Time, actor Actor) (*models.TreatmentPlan, error) {
+	var plan *models.TreatmentPlan
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plan, err = lockTreatmentPlan(tx, planID)
+		if err != nil {
+			return err
+		}
+		if err := applyPlanTransition(tx, plan, models.PlanStatusOffersRequested, actor, ""); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		plan.TargetCity = targeting.City
+		plan.TargetDistrict = targeting.District
+		plan.TargetPriceSegment = targeting.PriceSegment
+		plan.OffersRequestedAt = &now
+		plan.OfferDeadline = deadline
+		return tx.Model(plan).Updates(map[string]interface{}{
+			"target_city":          plan.TargetCity,
+			"target_district":      plan.TargetDistrict,
+			"target_price_segment": plan.TargetPriceSegment,
+			"offers_requested_at":  plan.OffersRequestedAt,
+			"offer_deadline":       plan.OfferDeadline,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// GetDistrictLocations retrieves districts that have coordinates, with their city
+func (r *Repository) GetDistrictLocations() ([]models.District, error) {
+	var districts []models.District
+	err := r.db.Preload("City").
+		Where("is_active = ? AND latitude IS NOT NULL AND longitude IS NOT NULL", true).
+		Find(&districts).Error
+	return districts, err
+}
+
+// PlanTargetsClinic reports whether a plan's targeting and required
+// specializations match a clinic. It mirrors targetedAt for plans already
+// loaded in memory.
+func PlanTargetsClinic(plan *models.TreatmentPlan, clinic *models.Clinic) bool {
+	if plan.TargetCity != "" && plan.TargetCity != clinic.City {
+		return false
+	}
+	if plan.TargetDistrict != "" && plan.TargetDistrict != clinic.District {
+		return false
+	}
+	if plan.TargetPriceSegment != "" && clinic.PriceSegment != "" && plan.TargetPriceSegment != clinic.PriceSegment {
+		return false
+	}
+
+	return (!plan.RequiresTherapy || clinic.HasTherapy) &&
+		(!plan.RequiresOrthopedics || clinic.HasOrthopedics) &&
+		(!plan.RequiresSurgery || clinic.HasSurgery) &&
+		(!plan.RequiresHygiene || clinic.HasHygiene) &&
+		(!plan.RequiresPeriodontics || clinic.HasPeriodontics)
+}
+
+// offerDeadlinePassed reports whether a plan stopped accepting offers
+func offerDeadlinePassed(plan *models.TreatmentPlan, now time.Time) bool {
+	return plan.OfferDeadline != nil && !now.Before(*plan.OfferDeadline)
+}
+
+// targetedAt limits a treatment plan query to plans the clinic may see and
+// still bid on. Clinics without a declared price segment see every segment.
+func targetedAt(clinic *models.Clinic, now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("treatment_plans.status IN ?", offerablePlanStatuses).
+			Where("treatment_plans.offer_deadline IS NULL OR treatment_plans.offer_deadline > ?", now).
+			Where("treatment_plans.target_city = '' OR treatment_plans.target_city = ?", clinic.City).
+			Where("treatment_plans.target_district = '' OR treatment_plans.target_district = ?", clinic.District)
+		if clinic.PriceSegment != "" {
+			db = db.Where("treatment_plans.target_price_segment = '' OR treatment_plans.target_price_segment = ?", clinic.PriceSegment)
+		}
+
+		// Plans needing a specialization the clinic lacks are hidden
+		capabilities := []struct {
+			has    bool
+			column string
+		}{
+			{clinic.HasTherapy, "requires_therapy"},
+			{clinic.HasOrthopedics, "requires_orthopedics"},
+			{clinic.HasSurgery, "requires_surgery"},
+			{clinic.HasHygiene, "requires_hygiene"},
+			{clinic.HasPeriodontics, "requires_periodontics"},
+		}
+		for _, capability := range capabilities {
+			if !capability.has {
+				db = db.Where("treatment_plans."+capability.column+" = ?", false)
+			}
+		}
+		return db
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// openPlanStatuses are plan statuses whose estimates still follow price changes
+var openPlanStatuses = []string{
+	models.PlanStatusGenerated,
+	models.PlanStatusOffersRequested,
+	models.PlanStatusOffersReceived,
+}
+
+// ==================== Pricing Operations ====================
+
+// GetCityPriceLists retrieves the price list entries of all clinics in a city
+func (r *Repository) GetCityPriceLists(city string) ([]models.PriceList, error) {
+	var prices []models.PriceList
+	err := r.db.Joins("JOIN clinics ON clinics.id = price_lists.clinic_id AND clinics.deleted_at IS NULL").
+		Where("clinics.city = ?", city).
+		Find(&prices).Error
+	return prices, err
+}
+
+// GetOpenTreatmentPlansByCity retrieves plans of patients in a city that
+// have not yet been settled on an offer
+func (r *Repository) GetOpenTreatmentPlansByCity(city string) ([]models.TreatmentPlan, error) {
+	var plans []models.TreatmentPlan
+	err := r.db.Preload("Items").
+		Joins("JOIN patients ON patients.id = treatment_plans.patient_id AND patients.deleted_at IS NULL").
+		Where("patients.city = ? AND treatment_plans.status IN ?", city, openPlanStatuses).
+		Find(&plans).Error
+	return plans, err
+}
+
+// GetOpenTreatmentPlanCities lists the cities of patients with open plans
+func (r *Repository) GetOpenTreatmentPlanCities() ([]string, error) {
+	var cities []string
+	err := r.db.Model(&models.TreatmentPlan{}).
+		Joins("JOIN patients ON patients.id = treatment_plans.patient_id AND patients.deleted_at IS NULL").
+		Where("treatment_plans.status IN ? AND patients.city <> ''", openPlanStatuses).
+		Distinct().
+		Pluck("patients.city", &cities).Error
+	return cities, err
+}
+
+// UpdateTreatmentPlanEstimate saves re-estimated plan ranges and item costs
+func (r *Repository) UpdateTreatmentPlanEstimate(plan *models.TreatmentPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TreatmentPlan{}).
+			Where("id = ?", plan.ID).
+			Updates(map[string]interface{}{
+				"requires_therapy":      plan.RequiresTherapy,
+				"requires_orthopedics":  plan.RequiresOrthopedics,
+				"requires_surgery":      plan.RequiresSurgery,
+				"requires_hygiene":      plan.RequiresHygiene,
+				"requires_periodontics": plan.RequiresPeriodontics,
+				"therapy_min_cost":      plan.TherapyMinCost,
+				"therapy_max_cost":      plan.TherapyMaxCost,
+				"orthopedics_min_cost":  plan.OrthopedicsMinCost,
+				"orthopedics_max_cost":  plan.OrthopedicsMaxCost,
+				"surgery_min_cost":      plan.SurgeryMinCost,
+				"surgery_max_cost":      plan.SurgeryMaxCost,
+				"hygiene_min_cost":      plan.HygieneMinCost,
+				"hygiene_max_cost":      plan.HygieneMaxCost,
+				"periodontics_min_cost": plan.PeriodonticsMinCost,
+				"periodontics_max_cost": plan.PeriodonticsMaxCost,
+			}).Error; err != nil {
+			return err
+		}
+
+		for _, item := range plan.Items {
+			if err := tx.Model(&models.TreatmentItem{}).
+				Where("id = ?", item.ID).
+				Update("estimated_cost", item.EstimatedCost).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -4,10 +4,12 @@ import (
 	"dental-marketplace/backend/internal/models"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -15,6 +17,9 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrRecordNotFound    = errors.New("record not found")
+	ErrOfferExists       = errors.New("clinic already has an open offer for this plan")
+	ErrOfferExpired      = errors.New("offer has expired")
+	ErrOfferChanged      = errors.New("offer changed since it was shown")
 )
 
 // Repository handles database operations
@@ -97,6 +102,19 @@ func (r *Repository) GetPatientByUserID(userID uint) (*models.Patient, error) {
 	return &patient, nil
 }
 
+// GetPatientByID retrieves patient profile by ID
+func (r *Repository) GetPatientByID(patientID uint) (*models.Patient, error) {
+	var patient models.Patient
+	err := r.db.First(&patient, patientID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &patient, nil
+}
+
 // UpdatePatientSearchCriteria updates patient's clinic search preferences
 func (r *Repository) UpdatePatientSearchCriteria(patientID uint, city, district, priceSegment string) error {
 	return r.db.Model(&models.Patient{}).Where("id = ?", patientID).
@@ -110,7 +128,7 @@ func (r *Repository) UpdatePatientSearchCriteria(patientID uint, city, district,
 // GetPatientCTScans retrieves all CT scans for a patient
 func (r *Repository) GetPatientCTScans(patientID uint) ([]models.CTScan, error) {
 	var scans []models.CTScan
-	err := r.db.Where("patient_id = ?", patientID).
+	err := r.db.Preload("Metadata").Preload("Job").Where("patient_id = ?", patientID).
 		Order("upload_date DESC").
 		Find(&scans).Error
 	return scans, err
@@ -119,7 +137,7 @@ func (r *Repository) GetPatientCTScans(patientID uint) ([]models.CTScan, error)
 // GetCTScanByID retrieves a CT scan by ID
 func (r *Repository) GetCTScanByID(scanID uint) (*models.CTScan, error) {
 	var scan models.CTScan
-	err := r.db.First(&scan, scanID).Error
+	err := r.db.Preload("Metadata").Preload("Job").First(&scan, scanID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrRecordNotFound
@@ -134,6 +152,40 @@ func (r *Repository) CreateCTScan(scan *models.CTScan) error {
 	return r.db.Create(scan).Error
 }
 
+// SaveCTScanMetadata stores extracted DICOM metadata, replacing any
+// previously extracted metadata for the scan
+func (r *Repository) SaveCTScanMetadata(metadata *models.CTScanMetadata) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("ct_scan_id = ?", metadata.CTScanID).
+			Delete(&models.CTScanMetadata{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(metadata).Error
+	})
+}
+
+// MarkCTScanError moves a scan to the error status with a machine-readable reason
+func (r *Repository) MarkCTScanError(scanID uint, reason, detail string) error {
+	return r.db.Model(&models.CTScan{}).
+		Where("id = ?", scanID).
+		Updates(map[string]interface{}{
+			"status":       models.ScanStatusError,
+			"error_reason": reason,
+			"error_detail": detail,
+		}).Error
+}
+
+// SetCTScanAnonymized records the de-identified copy of a scan
+func (r *Repository) SetCTScanAnonymized(scanID uint, key, checksum string, anonymizedAt time.Time) error {
+	return r.db.Model(&models.CTScan{}).
+		Where("id = ?", scanID).
+		Updates(map[string]interface{}{
+			"anonymized_key":      key,
+			"anonymized_checksum": checksum,
+			"anonymized_at":       anonymizedAt,
+		}).Error
+}
+
 // GetTreatmentPlanByScanID retrieves treatment plan for a CT scan
 func (r *Repository) GetTreatmentPlanByScanID(scanID uint) (*models.TreatmentPlan, error) {
 	var plan models.TreatmentPlan
@@ -147,10 +199,25 @@ func (r *Repository) GetTreatmentPlanByScanID(scanID uint) (*models.TreatmentPla
 	return &plan, nil
 }
 
-// GetTreatmentPlanByID retrieves treatment plan by ID with items and offers
+// GetTreatmentPlanByID retrieves treatment plan by ID with items and all
+// offers, including clinic drafts; patient-facing code uses
+// GetTreatmentPlanForPatient instead
 func (r *Repository) GetTreatmentPlanByID(planID uint) (*models.TreatmentPlan, error) {
+	return r.getTreatmentPlan(r.db.Preload("Offers.Clinic"), planID)
+}
+
+// GetTreatmentPlanForPatient retrieves treatment plan by ID with the offers
+// its patient may see
+func (r *Repository) GetTreatmentPlanForPatient(planID uint) (*models.TreatmentPlan, error) {
+	return r.getTreatmentPlan(r.db.Preload("Offers", patientVisibleOffers).Preload("Offers.Clinic"), planID)
+}
+
+func (r *Repository) getTreatmentPlan(query *gorm.DB, planID uint) (*models.TreatmentPlan, error) {
 	var plan models.TreatmentPlan
-	err := r.db.Preload("Items").Preload("Offers.Clinic").
+	err := query.Preload("Items").
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
 		First(&plan, planID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -161,10 +228,16 @@ func (r *Repository) GetTreatmentPlanByID(planID uint) (*models.TreatmentPlan, e
 	return &plan, nil
 }
 
-// GetPatientTreatmentPlans retrieves all treatment plans for a patient
+// patientVisibleOffers hides offers still pending on the clinic's side
+func patientVisibleOffers(db *gorm.DB) *gorm.DB {
+	return db.Where("status <> ?", models.OfferStatusPending)
+}
+
+// GetPatientTreatmentPlans retrieves all treatment plans for a patient with
+// the offers the patient may see
 func (r *Repository) GetPatientTreatmentPlans(patientID uint) ([]models.TreatmentPlan, error) {
 	var plans []models.TreatmentPlan
-	err := r.db.Preload("Items").Preload("Offers.Clinic").
+	err := r.db.Preload("Items").Preload("Offers", patientVisibleOffers).Preload("Offers.Clinic").
 		Where("patient_id = ?", patientID).
 		Order("created_at DESC").
 		Find(&plans).Error
@@ -174,24 +247,28 @@ func (r *Repository) GetPatientTreatmentPlans(patientID uint) ([]models.Treatmen
 // GetOffersForTreatmentPlan retrieves all clinic offers for a treatment plan
 func (r *Repository) GetOffersForTreatmentPlan(planID uint) ([]models.ClinicOffer, error) {
 	var offers []models.ClinicOffer
-	err := r.db.Preload("Clinic").
-		Where("treatment_plan_id = ? AND status != ?", planID, models.OfferStatusPending).
+	err := r.db.Preload("Clinic").Preload("Items.TreatmentItem").
+		Preload("Revisions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("version ASC")
+		}).
+		Preload("PaymentSchedule.Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("number ASC")
+		}).
+		Where("treatment_plan_id = ?", planID).
+		Scopes(patientVisibleOffers).
 		Order("total_cost ASC").
 		Find(&offers).Error
 	return offers, err
 }
 
-// UpdateTreatmentPlanStatus updates the status of a treatment plan
-func (r *Repository) UpdateTreatmentPlanStatus(planID uint, status string) error {
-	return r.db.Model(&models.TreatmentPlan{}).
-		Where("id = ?", planID).
-		Update("status", status).Error
-}
-
 // GetPatientAppointments retrieves all appointments for a patient
 func (r *Repository) GetPatientAppointments(patientID uint) ([]models.Appointment, error) {
 	var appointments []models.Appointment
 	err := r.db.Preload("Clinic").
+		Preload("Resource").
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
 		Where("patient_id = ?", patientID).
 		Order("appointment_date DESC").
 		Find(&appointments).Error
@@ -203,22 +280,6 @@ func (r *Repository) CreateAppointment(appointment *models.Appointment) error {
 	return r.db.Create(appointment).Error
 }
 
-// CreateReview creates a new review
-func (r *Repository) CreateReview(review *models.Review) error {
-	// Create review
-	if err := r.db.Create(review).Error; err != nil {
-		return err
-	}
-
-	// Update clinic rating and review count
-	return r.updateClinicRating(review.ClinicID)
-}
-
-// CreateComplaint creates a new complaint
-func (r *Repository) CreateComplaint(complaint *models.Complaint) error {
-	return r.db.Create(complaint).Error
-}
-
 // ==================== Clinic Operations ====================
 
 // GetClinicByUserID retrieves clinic profile by user ID
@@ -286,9 +347,16 @@ func (r *Repository) UpdatePriceList(items []models.PriceList) error {
 					return err
 				}
 			} else {
-				// Update existing
-				if err := tx.Save(&item).Error; err != nil {
-					return err
+				// Update existing, never another clinic's row
+				result := tx.Model(&models.PriceList{}).
+					Where("id = ? AND clinic_id = ?", item.ID, item.ClinicID).
+					Select("specialization", "service_name", "procedure_code", "price", "warranty_years").
+					Updates(&item)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					return ErrRecordNotFound
 				}
 			}
 		}
@@ -296,44 +364,122 @@ func (r *Repository) UpdatePriceList(items []models.PriceList) error {
 	})
 }
 
+// GetProceduresByCodes retrieves active catalog procedures by code
+func (r *Repository) GetProceduresByCodes(codes []string) ([]models.Procedure, error) {
+	var procedures []models.Procedure
+	err := r.db.Where("code IN ? AND is_active = ?", codes, true).Find(&procedures).Error
+	return procedures, err
+}
+
 // DeletePriceListItem deletes a price list item
 func (r *Repository) DeletePriceListItem(itemID uint) error {
 	return r.db.Delete(&models.PriceList{}, itemID).Error
 }
 
-// GetIncomingTreatmentPlans retrieves treatment plans for clinic to review
-func (r *Repository) GetIncomingTreatmentPlans(clinicID uint, status string) ([]models.TreatmentPlan, error) {
-	// Get treatment plans that match clinic capabilities and don't have an offer from this clinic yet
+// GetIncomingTreatmentPlans retrieves treatment plans for clinic to review,
+// most recently published first. A positive limit caps how many are loaded.
+func (r *Repository) GetIncomingTreatmentPlans(clinic *models.Clinic, status string, limit int) ([]models.TreatmentPlan, error) {
+	// Get treatment plans targeted at the clinic that don't have an open offer
+	// from this clinic; after a withdrawn or expired offer the clinic may bid again
 	var plans []models.TreatmentPlan
 	
-	query := r.db.Preload("Items").Preload("Offers", "clinic_id = ?", clinicID)
+	query := r.db.Preload("Items").Preload("Offers", "clinic_id = ?", clinic.ID).
+		Scopes(targetedAt(clinic, time.Now()))
 	
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
 
-	err := query.Where("id NOT IN (?)", 
-		r.db.Table("clinic_offers").
+	err := query.Where("id NOT IN (?)",
+		r.db.Model(&models.ClinicOffer{}).
 			Select("treatment_plan_id").
-			Where("clinic_id = ?", clinicID),
-	).Order("created_at DESC").Find(&plans).Error
+			Where("clinic_id = ? AND status IN ?", clinic.ID, openOfferStatuses),
+	).Order("COALESCE(offers_requested_at, created_at) DESC, id DESC").Find(&plans).Error
 
 	return plans, err
 }
 
-// CreateClinicOffer creates a new clinic offer
-func (r *Repository) CreateClinicOffer(offer *models.ClinicOffer) error {
-	return r.db.Create(offer).Error
+// offerablePlanStatuses are plan statuses in which clinics may send offers
+var offerablePlanStatuses = []string{
+	models.PlanStatusOffersRequested,
+	models.PlanStatusOffersReceived,
+}
+
+// openOfferStatuses are offer statuses that count as a clinic's live bid on a plan
+var openOfferStatuses = []string{
+	models.OfferStatusPending,
+	models.OfferStatusSent,
 }
 
-// UpdateClinicOffer updates an existing clinic offer
-func (r *Repository) UpdateClinicOffer(offer *models.ClinicOffer) error {
-	return r.db.Save(offer).Error
+// CreateClinicOffer creates a new clinic offer. The plan must be open for
+// offers and targeted at the clinic, and the clinic may hold only one open
+// offer per plan. The first sent offer moves the plan to offers_received;
+// pending drafts leave it unchanged.
+func (r *Repository) CreateClinicOffer(offer *models.ClinicOffer, actor Actor) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		plan, err := admitOffer(tx, offer.TreatmentPlanID, offer.ClinicID)
+		if err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.ClinicOffer{}).
+			Where("treatment_plan_id = ? AND clinic_id = ? AND status IN ?", plan.ID, offer.ClinicID, openOfferStatuses).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrOfferExists
+		}
+
+		if offer.Status == models.OfferStatusSent {
+			if err := markOffersReceived(tx, plan, actor); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(offer).Error
+	})
+}
+
+// admitOffer locks a plan and checks that the clinic may make an offer on it
+func admitOffer(tx *gorm.DB, planID, clinicID uint) (*models.TreatmentPlan, error) {
+	plan, err := lockTreatmentPlan(tx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	var clinic models.Clinic
+	if err := tx.First(&clinic, clinicID).Error; err != nil {
+		return nil, err
+	}
+	// Plans outside the clinic's targeting are invisible to it
+	if !PlanTargetsClinic(plan, &clinic) {
+		return nil, ErrRecordNotFound
+	}
+	if offerDeadlinePassed(plan, time.Now()) {
+		return nil, ErrOfferDeadlinePassed
+	}
+	if !slices.Contains(offerablePlanStatuses, plan.Status) {
+		return nil, &TransitionError{Entity: "treatment plan", From: plan.Status, To: models.PlanStatusOffersReceived}
+	}
+	return plan, nil
+}
+
+// markOffersReceived moves a plan to offers_received on its first sent offer
+func markOffersReceived(tx *gorm.DB, plan *models.TreatmentPlan, actor Actor) error {
+	if plan.Status == models.PlanStatusOffersReceived {
+		return nil
+	}
+	return applyPlanTransition(tx, plan, models.PlanStatusOffersReceived, actor, "first offer received")
 }
 
 // GetClinicOffers retrieves all offers made by a clinic
 func (r *Repository) GetClinicOffers(clinicID uint, status string) ([]models.ClinicOffer, error) {
-	query := r.db.Preload("TreatmentPlan").Where("clinic_id = ?", clinicID)
+	query := r.db.Preload("TreatmentPlan").Preload("Items").Where("clinic_id = ?", clinicID)
 	
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -344,19 +490,14 @@ func (r *Repository) GetClinicOffers(clinicID uint, status string) ([]models.Cli
 	return offers, err
 }
 
-// GetClinicLeads retrieves accepted offers (leads) for a clinic
-func (r *Repository) GetClinicLeads(clinicID uint) ([]models.ClinicOffer, error) {
-	var offers []models.ClinicOffer
-	err := r.db.Preload("TreatmentPlan.Patient").
-		Where("clinic_id = ? AND status = ?", clinicID, models.OfferStatusAccepted).
-		Order("created_at DESC").
-		Find(&offers).Error
-	return offers, err
-}
-
 // GetClinicAppointments retrieves all appointments for a clinic
 func (r *Repository) GetClinicAppointments(clinicID uint, status string) ([]models.Appointment, error) {
-	query := r.db.Preload("Patient").Where("clinic_id = ?", clinicID)
+	query := r.db.Preload("Patient").
+		Preload("Resource").
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, id ASC")
+		}).
+		Where("clinic_id = ?", clinicID)
 	
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -367,60 +508,95 @@ func (r *Repository) GetClinicAppointments(clinicID uint, status string) ([]mode
 	return appointments, err
 }
 
-// UpdateAppointmentStatus updates appointment status
-func (r *Repository) UpdateAppointmentStatus(appointmentID uint, status, notes string) error {
-	updates := map[string]interface{}{
-		"status": status,
-	}
-	if notes != "" {
-		updates["notes"] = notes
+// GetAppointmentByID retrieves an appointment by ID
+func (r *Repository) GetAppointmentByID(appointmentID uint) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := r.db.First(&appointment, appointmentID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
 	}
-	
-	return r.db.Model(&models.Appointment{}).
-		Where("id = ?", appointmentID).
-		Updates(updates).Error
+	return &appointment, nil
 }
 
-// AcceptClinicOffer marks an offer as accepted and creates appointment
-func (r *Repository) AcceptClinicOffer(offerID, patientID uint) error {
+// AcceptClinicOffer marks an offer as accepted and rejects the plan's other
+// offers. version is the offer version the patient reviewed; a revised offer
+// returns ErrOfferChanged. A nil version accepts the current one.
+func (r *Repository) AcceptClinicOffer(offerID uint, version *int, patientID uint, schedule *models.PaymentSchedule, actor Actor) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Get the offer
-		var offer models.ClinicOffer
-		if err := tx.First(&offer, offerID).Error; err != nil {
+		// Lock the offer so it cannot be revised while it is accepted
+		offer, err := lockClinicOffer(tx, offerID)
+		if err != nil {
 			return err
 		}
 
-		// Update offer status
-		if err := tx.Model(&offer).Update("status", models.OfferStatusAccepted).Error; err != nil {
-			return err
+		if !CanTransitionOffer(offer.Status, models.OfferStatusAccepted) {
+			return &TransitionError{Entity: "clinic offer", From: offer.Status, To: models.OfferStatusAccepted}
+		}
+		if offerValidityPassed(offer, time.Now()) {
+			return ErrOfferExpired
+		}
+		if (version != nil && offer.Version != *version) || (schedule != nil && schedule.TreatmentCost != offer.TotalCost) {
+			return ErrOfferChanged
 		}
 
-		// Reject other offers for the same treatment plan
-		if err := tx.Model(&models.ClinicOffer{}).
-			Where("treatment_plan_id = ? AND id != ?", offer.TreatmentPlanID, offerID).
-			Update("status", models.OfferStatusRejected).Error; err != nil {
+		// Only the plan's own patient may accept; the plan's row lock
+		// serializes concurrent acceptances
+		plan, err := lockTreatmentPlan(tx, offer.TreatmentPlanID)
+		if err != nil {
+			return err
+		}
+		if plan.PatientID != patientID {
+			return ErrRecordNotFound
+		}
+		if err := applyPlanTransition(tx, plan, models.PlanStatusOfferSelected, actor, fmt.Sprintf("offer %d accepted", offerID)); err != nil {
 			return err
 		}
 
-		// Update treatment plan status
-		if err := tx.Model(&models.TreatmentPlan{}).
-			Where("id = ?", offer.TreatmentPlanID).
-			Update("status", models.PlanStatusOfferSelected).Error; err != nil {
+		// Update offer status
+		if err := tx.Model(offer).Update("status", models.OfferStatusAccepted).Error; err != nil {
+			return err
+		}
+		if err := createLead(tx, offer, plan); err != nil {
 			return err
 		}
 
-		// Create appointment
-		appointment := &models.Appointment{
-			PatientID:       patientID,
-			ClinicID:        offer.ClinicID,
-			TreatmentPlanID: offer.TreatmentPlanID,
-			ClinicOfferID:   offerID,
-			AppointmentDate: time.Now().Add(7 * 24 * time.Hour), // Default to 1 week from now
-			Status:          models.AppointmentStatusScheduled,
-			Notes:           "Initial consultation",
+		// Keep the installment schedule the patient chose
+		if schedule != nil {
+			schedule.ClinicOfferID = offer.ID
+			if err := tx.Create(schedule).Error; err != nil {
+				return err
+			}
 		}
-		
-		return tx.Create(appointment).Error
+
+		// Reject other sent offers for the same treatment plan and discard
+		// drafts the patient never saw. The patient books appointments from
+		// the clinic's free slots afterwards.
+		var others []models.ClinicOffer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("treatment_plan_id = ? AND id != ? AND status IN ?", offer.TreatmentPlanID, offerID, openOfferStatuses).
+			Find(&others).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range others {
+			other := &others[i]
+			if other.Status == models.OfferStatusPending {
+				if err := withdrawOffer(tx, other, now); err != nil {
+					return err
+				}
+				continue
+			}
+			if !CanTransitionOffer(other.Status, models.OfferStatusRejected) {
+				return &TransitionError{Entity: "clinic offer", From: other.Status, To: models.OfferStatusRejected}
+			}
+			if err := tx.Model(other).Update("status", models.OfferStatusRejected).Error; err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
@@ -442,10 +618,10 @@ func (r *Repository) GetClinicDashboardMetrics(clinicID uint, startDate, endDate
 		Count(&offersSentCount)
 	metrics["offers_sent"] = offersSentCount
 
-	// Count accepted offers (leads)
+	// Count leads (accepted offers)
 	var leadsCount int64
-	r.db.Model(&models.ClinicOffer{}).
-		Where("clinic_id = ? AND status = ?", clinicID, models.OfferStatusAccepted).
+	r.db.Model(&models.Lead{}).
+		Where("clinic_id = ?", clinicID).
 		Count(&leadsCount)
 	metrics["leads"] = leadsCount
 
@@ -465,6 +641,13 @@ func (r *Repository) GetClinicDashboardMetrics(clinicID uint, startDate, endDate
 		metrics["conversion_rate"] = "0%"
 	}
 
+	// Lead pipeline and conversion to treatment
+	leadMetrics, err := r.GetLeadMetrics(clinicID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	metrics["lead_pipeline"] = leadMetrics
+
 	return metrics, nil
 }
 
@@ -532,39 +715,32 @@ func (r *Repository) GetAllClinicsWithStats() ([]map[string]interface{}, error)
 	return result, nil
 }
 
-// GetAllComplaints retrieves all complaints with optional filters
-func (r *Repository) GetAllComplaints(status string) ([]models.Complaint, error) {
-	query := r.db.Preload("Patient").Preload("Clinic")
-	
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	var complaints []models.Complaint
-	err := query.Order("created_at DESC").Find(&complaints).Error
-	return complaints, err
-}
-
 // ==================== Helper Functions ====================
 
-// updateClinicRating recalculates and updates clinic rating
-func (r *Repository) updateClinicRating(clinicID uint) error {
-	var avgRating float64
-	var reviewCount int64
-
-	r.db.Model(&models.Review{}).
-		Where("clinic_id = ?", clinicID).
-		Count(&reviewCount)
+// updateClinicRating recomputes a clinic's rating and review count from its
+// approved reviews inside tx. The clinic row is locked so concurrent review
+// changes apply in turn.
+func updateClinicRating(tx *gorm.DB, clinicID uint) error {
+	var clinic models.Clinic
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&clinic, clinicID).Error; err != nil {
+		return err
+	}
 
-	r.db.Model(&models.Review{}).
-		Where("clinic_id = ?", clinicID).
-		Select("AVG(rating)").
-		Scan(&avgRating)
+	var stats struct {
+		AvgRating   float64
+		ReviewCount int64
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS avg_rating, COUNT(*) AS review_count").
+		Where("clinic_id = ? AND status = ?", clinicID, models.ReviewStatusApproved).
+		Scan(&stats).Error; err != nil {
+		return err
+	}
 
-	return r.db.Model(&models.Clinic{}).
+	return tx.Model(&models.Clinic{}).
 		Where("id = ?", clinicID).
 		Updates(map[string]interface{}{
-			"rating":       avgRating,
-			"review_count": reviewCount,
+			"rating":       stats.AvgRating,
+			"review_count": stats.ReviewCount,
 		}).Error
 }
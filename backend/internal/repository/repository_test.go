@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/testdb"
+	"errors"
+	"testing"
+	"time"
+)
+
+func offerIDs(offers []models.ClinicOffer) map[uint]string {
+	ids := make(map[uint]string, len(offers))
+	for _, offer := range offers {
+		ids[offer.ID] = offer.Status
+	}
+	return ids
+}
+
+func TestPatientPlanLoadsHidePendingOffers(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewRepository(db)
+
+	patient := testdb.Patient(t, db)
+	plan := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersRequested)
+	sent := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusSent)
+	draft := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusPending)
+	withdrawn := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusWithdrawn)
+
+	visible := func(name string, offers []models.ClinicOffer) {
+		t.Helper()
+		ids := offerIDs(offers)
+		if _, ok := ids[draft.ID]; ok {
+			t.Errorf("%s includes the pending draft", name)
+		}
+		if _, ok := ids[sent.ID]; !ok {
+			t.Errorf("%s is missing the sent offer", name)
+		}
+		if _, ok := ids[withdrawn.ID]; !ok {
+			t.Errorf("%s is missing the withdrawn offer", name)
+		}
+		for _, offer := range offers {
+			if offer.Clinic.ID != offer.ClinicID {
+				t.Errorf("%s: offer %d was loaded without its clinic", name, offer.ID)
+			}
+		}
+	}
+
+	forPatient, err := repo.GetTreatmentPlanForPatient(plan.ID)
+	if err != nil {
+		t.Fatalf("GetTreatmentPlanForPatient: %v", err)
+	}
+	visible("GetTreatmentPlanForPatient", forPatient.Offers)
+
+	plans, err := repo.GetPatientTreatmentPlans(patient.ID)
+	if err != nil {
+		t.Fatalf("GetPatientTreatmentPlans: %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("GetPatientTreatmentPlans returned %d plans, want 1", len(plans))
+	}
+	visible("GetPatientTreatmentPlans", plans[0].Offers)
+
+	offers, err := repo.GetOffersForTreatmentPlan(plan.ID)
+	if err != nil {
+		t.Fatalf("GetOffersForTreatmentPlan: %v", err)
+	}
+	visible("GetOffersForTreatmentPlan", offers)
+
+	// Clinic-side loads still see drafts, which ClinicCanViewPlan relies on
+	full, err := repo.GetTreatmentPlanByID(plan.ID)
+	if err != nil {
+		t.Fatalf("GetTreatmentPlanByID: %v", err)
+	}
+	if _, ok := offerIDs(full.Offers)[draft.ID]; !ok {
+		t.Error("GetTreatmentPlanByID is missing the pending draft")
+	}
+}
+
+func TestIncomingPlansReopenAfterClosedOffers(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewRepository(db)
+
+	clinic := testdb.Clinic(t, db)
+	if err := db.Model(clinic).Update("has_therapy", true).Error; err != nil {
+		t.Fatalf("failed to enable therapy: %v", err)
+	}
+	patient := testdb.Patient(t, db)
+	plan := func() *models.TreatmentPlan {
+		return testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersRequested)
+	}
+
+	fresh := plan()
+	drafted := plan()
+	testdb.Offer(t, db, drafted, clinic, models.OfferStatusPending)
+	sent := plan()
+	testdb.Offer(t, db, sent, clinic, models.OfferStatusSent)
+	withdrawn := plan()
+	testdb.Offer(t, db, withdrawn, clinic, models.OfferStatusWithdrawn)
+	expired := plan()
+	testdb.Offer(t, db, expired, clinic, models.OfferStatusExpired)
+	deleted := plan()
+	if err := db.Delete(testdb.Offer(t, db, deleted, clinic, models.OfferStatusPending)).Error; err != nil {
+		t.Fatalf("failed to delete offer: %v", err)
+	}
+	// Another clinic's open offer does not hide the plan
+	foreign := plan()
+	testdb.Offer(t, db, foreign, testdb.Clinic(t, db), models.OfferStatusSent)
+
+	plans, err := repo.GetIncomingTreatmentPlans(clinic, "", 0)
+	if err != nil {
+		t.Fatalf("GetIncomingTreatmentPlans: %v", err)
+	}
+	incoming := make(map[uint]bool, len(plans))
+	for _, plan := range plans {
+		incoming[plan.ID] = true
+	}
+
+	for _, tc := range []struct {
+		name string
+		plan *models.TreatmentPlan
+		want bool
+	}{
+		{"without offers", fresh, true},
+		{"with a pending draft", drafted, false},
+		{"with a sent offer", sent, false},
+		{"with a withdrawn offer", withdrawn, true},
+		{"with an expired offer", expired, true},
+		{"with a deleted offer", deleted, true},
+		{"with another clinic's offer", foreign, true},
+	} {
+		if incoming[tc.plan.ID] != tc.want {
+			t.Errorf("plan %s: incoming = %v, want %v", tc.name, incoming[tc.plan.ID], tc.want)
+		}
+	}
+}
+
+func TestAcceptClinicOfferRejectsRevisedOffer(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewRepository(db)
+
+	patient := testdb.Patient(t, db)
+	plan := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersReceived)
+	offer := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusSent)
+	if err := db.Model(offer).Updates(map[string]interface{}{"version": 2, "total_cost": 7000}).Error; err != nil {
+		t.Fatalf("failed to revise offer: %v", err)
+	}
+	actor := Actor{UserID: patient.UserID, Role: models.RolePatient}
+
+	stale, current := 1, 2
+	err := repo.AcceptClinicOffer(offer.ID, &stale, patient.ID, nil, actor)
+	if !errors.Is(err, ErrOfferChanged) {
+		t.Fatalf("accepting a stale version: got %v, want ErrOfferChanged", err)
+	}
+	if err := repo.AcceptClinicOffer(offer.ID, &current, patient.ID, nil, actor); err != nil {
+		t.Fatalf("accepting the current version: %v", err)
+	}
+
+	accepted, err := repo.GetClinicOfferByID(offer.ID)
+	if err != nil {
+		t.Fatalf("GetClinicOfferByID: %v", err)
+	}
+	if accepted.Status != models.OfferStatusAccepted {
+		t.Errorf("offer status = %s, want %s", accepted.Status, models.OfferStatusAccepted)
+	}
+}
+
+func TestAcceptClinicOfferWithoutVersion(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewRepository(db)
+
+	patient := testdb.Patient(t, db)
+	plan := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersReceived)
+	offer := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusSent)
+	if err := db.Model(offer).Update("version", 3).Error; err != nil {
+		t.Fatalf("failed to revise offer: %v", err)
+	}
+
+	// Clients that do not send a version accept the offer as it is now
+	if err := repo.AcceptClinicOffer(offer.ID, nil, patient.ID, nil, Actor{UserID: patient.UserID, Role: models.RolePatient}); err != nil {
+		t.Fatalf("accepting without a version: %v", err)
+	}
+	accepted, err := repo.GetClinicOfferByID(offer.ID)
+	if err != nil {
+		t.Fatalf("GetClinicOfferByID: %v", err)
+	}
+	if accepted.Status != models.OfferStatusAccepted {
+		t.Errorf("offer status = %s, want %s", accepted.Status, models.OfferStatusAccepted)
+	}
+}
+
+func TestAcceptClinicOfferClosesOtherOffers(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewRepository(db)
+
+	patient := testdb.Patient(t, db)
+	plan := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersReceived)
+	offer := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusSent)
+	competing := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusSent)
+	draft := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusPending)
+
+	if err := repo.AcceptClinicOffer(offer.ID, nil, patient.ID, nil, Actor{UserID: patient.UserID, Role: models.RolePatient}); err != nil {
+		t.Fatalf("AcceptClinicOffer: %v", err)
+	}
+
+	rejected, err := repo.GetClinicOfferByID(competing.ID)
+	if err != nil {
+		t.Fatalf("GetClinicOfferByID: %v", err)
+	}
+	if rejected.Status != models.OfferStatusRejected {
+		t.Errorf("competing offer status = %s, want %s", rejected.Status, models.OfferStatusRejected)
+	}
+	// Drafts cannot be rejected; they are discarded like a withdrawn draft
+	if _, err := repo.GetClinicOfferByID(draft.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("draft after acceptance: got %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestCancelTreatmentPlanClosesOpenWork(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewRepository(db)
+
+	patient := testdb.Patient(t, db)
+	plan := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOfferSelected)
+	clinic := testdb.Clinic(t, db)
+	accepted := testdb.Offer(t, db, plan, clinic, models.OfferStatusAccepted)
+	sent := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusSent)
+	draft := testdb.Offer(t, db, plan, testdb.Clinic(t, db), models.OfferStatusPending)
+
+	lead := &models.Lead{
+		ClinicID:        clinic.ID,
+		ClinicOfferID:   accepted.ID,
+		TreatmentPlanID: plan.ID,
+		PatientID:       patient.ID,
+		Status:          models.LeadStatusConsultationScheduled,
+	}
+	if err := db.Create(lead).Error; err != nil {
+		t.Fatalf("failed to create lead: %v", err)
+	}
+
+	upcoming := testdb.Appointment(t, db, patient, clinic)
+	past := testdb.Appointment(t, db, patient, clinic)
+	for _, appointment := range []*models.Appointment{upcoming, past} {
+		if err := db.Model(appointment).Updates(map[string]interface{}{
+			"treatment_plan_id": plan.ID,
+			"clinic_offer_id":   accepted.ID,
+		}).Error; err != nil {
+			t.Fatalf("failed to link appointment: %v", err)
+		}
+	}
+	if err := db.Model(past).Update("appointment_date", time.Now().Add(-24*time.Hour)).Error; err != nil {
+		t.Fatalf("failed to move appointment: %v", err)
+	}
+
+	actor := Actor{UserID: patient.UserID, Role: models.RolePatient}
+	cancelled, err := repo.CancelTreatmentPlan(plan.ID, actor, "changed my mind")
+	if err != nil {
+		t.Fatalf("CancelTreatmentPlan: %v", err)
+	}
+	if cancelled.Status != models.PlanStatusCancelled {
+		t.Errorf("plan status = %s, want %s", cancelled.Status, models.PlanStatusCancelled)
+	}
+
+	offerStatus := func(offer *models.ClinicOffer) string {
+		t.Helper()
+		var reloaded models.ClinicOffer
+		if err := db.Unscoped().First(&reloaded, offer.ID).Error; err != nil {
+			t.Fatalf("failed to reload offer: %v", err)
+		}
+		if reloaded.DeletedAt.Valid {
+			return "deleted"
+		}
+		return reloaded.Status
+	}
+	for _, tc := range []struct {
+		name  string
+		offer *models.ClinicOffer
+		want  string
+	}{
+		{"accepted offer", accepted, models.OfferStatusAccepted},
+		{"sent offer", sent, models.OfferStatusWithdrawn},
+		{"pending draft", draft, "deleted"},
+	} {
+		if got := offerStatus(tc.offer); got != tc.want {
+			t.Errorf("%s: status = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	appointmentStatus := func(appointment *models.Appointment) string {
+		t.Helper()
+		var reloaded models.Appointment
+		if err := db.First(&reloaded, appointment.ID).Error; err != nil {
+			t.Fatalf("failed to reload appointment: %v", err)
+		}
+		return reloaded.Status
+	}
+	if got := appointmentStatus(upcoming); got != models.AppointmentStatusCancelled {
+		t.Errorf("upcoming appointment status = %s, want %s", got, models.AppointmentStatusCancelled)
+	}
+	if got := appointmentStatus(past); got != models.AppointmentStatusScheduled {
+		t.Errorf("past appointment status = %s, want it left for the clinic to record", got)
+	}
+
+	var reloadedLead models.Lead
+	if err := db.First(&reloadedLead, lead.ID).Error; err != nil {
+		t.Fatalf("failed to reload lead: %v", err)
+	}
+	if reloadedLead.Status != models.LeadStatusRejected || reloadedLead.ClosedAt == nil {
+		t.Errorf("lead = %s (closed %v), want rejected and closed", reloadedLead.Status, reloadedLead.ClosedAt)
+	}
+
+	// A cancelled plan cannot be cancelled again
+	if _, err := repo.CancelTreatmentPlan(plan.ID, actor, ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("cancelling again: got %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestIncomingPlansLimitKeepsNewest(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewRepository(db)
+
+	clinic := testdb.Clinic(t, db)
+	if err := db.Model(clinic).Update("has_therapy", true).Error; err != nil {
+		t.Fatalf("failed to enable therapy: %v", err)
+	}
+	patient := testdb.Patient(t, db)
+
+	// Published after anything already in the database
+	base := time.Now().Add(time.Hour)
+	published := make([]*models.TreatmentPlan, 3)
+	for i := range published {
+		plan := testdb.Plan(t, db, testdb.Scan(t, db, patient), models.PlanStatusOffersRequested)
+		if err := db.Model(plan).Update("offers_requested_at", base.Add(time.Duration(i)*time.Minute)).Error; err != nil {
+			t.Fatalf("failed to publish plan: %v", err)
+		}
+		published[i] = plan
+	}
+
+	plans, err := repo.GetIncomingTreatmentPlans(clinic, "", 2)
+	if err != nil {
+		t.Fatalf("GetIncomingTreatmentPlans: %v", err)
+	}
+	if len(plans) != 2 || plans[0].ID != published[2].ID || plans[1].ID != published[1].ID {
+		ids := make([]uint, len(plans))
+		for i, plan := range plans {
+			ids[i] = plan.ID
+		}
+		t.Errorf("limited plans = %v, want [%d %d]", ids, published[2].ID, published[1].ID)
+	}
+	for _, plan := range plans {
+		if len(plan.Items) == 0 {
+			t.Errorf("plan %d was loaded without its items", plan.ID)
+		}
+	}
+}
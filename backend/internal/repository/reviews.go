@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/moderation"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrReviewNotAllowed is returned when a patient reviews an appointment
+	// that is not their own completed visit
+	ErrReviewNotAllowed = errors.New("only completed appointments can be reviewed")
+	// ErrReviewExists is returned when an appointment already has a review
+	ErrReviewExists = errors.New("appointment has already been reviewed")
+	// ErrReviewEditWindow is returned when a review is edited after its
+	// edit window closed
+	ErrReviewEditWindow = errors.New("review can no longer be edited")
+)
+
+// reviewTransitions lists the moderation decisions a regulator may make from
+// each status. Decisions can be reversed; edits send a review back to pending.
+var reviewTransitions = map[string][]string{
+	models.ReviewStatusPending:  {models.ReviewStatusApproved, models.ReviewStatusRejected},
+	models.ReviewStatusApproved: {models.ReviewStatusRejected},
+	models.ReviewStatusRejected: {models.ReviewStatusApproved},
+}
+
+// IsReviewStatus reports whether status is a known review status
+func IsReviewStatus(status string) bool {
+	_, ok := reviewTransitions[status]
+	return ok
+}
+
+// CanTransitionReview reports whether a regulator may move a review from one
+// status to another
+func CanTransitionReview(from, to string) bool {
+	return slices.Contains(reviewTransitions[from], to)
+}
+
+// ReviewFilter narrows the moderation queue
+type ReviewFilter struct {
+	Status   string
+	ClinicID *uint
+	Flagged  *bool // only reviews with (or without) auto-flags
+}
+
+// ==================== Review Operations ====================
+
+// CreateReview creates a verified review of one of the patient's completed
+// appointments. The review's clinic is taken from the appointment; it waits
+// for moderation, with auto-flags, before it is published or rated.
+func (r *Repository) CreateReview(review *models.Review) error {
+	if review.AppointmentID == nil {
+		return ErrReviewNotAllowed
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// The appointment's row lock serializes reviews of the same visit
+		var appointment models.Appointment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND patient_id = ?", *review.AppointmentID, review.PatientID).
+			First(&appointment).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if appointment.Status != models.AppointmentStatusCompleted {
+			return ErrReviewNotAllowed
+		}
+
+		var existing int64
+		if err := tx.Unscoped().Model(&models.Review{}).
+			Where("appointment_id = ?", appointment.ID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrReviewExists
+		}
+
+		review.ClinicID = appointment.ClinicID
+		review.VerifiedVisit = true
+		review.Status = models.ReviewStatusPending
+		review.IsPublic = false
+		review.ModerationFlags = reviewFlags(review.Comment)
+		if err := tx.Create(review).Error; err != nil {
+			if r.isUniqueViolation(err) {
+				return ErrReviewExists
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// UpdateReview edits the rating and comment of a patient's review within
+// editWindow of posting it. The edited review goes back to moderation.
+func (r *Repository) UpdateReview(reviewID, patientID uint, rating int, comment string, editWindow time.Duration) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND patient_id = ?", reviewID, patientID).
+			First(&review).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if now.After(review.CreatedAt.Add(editWindow)) {
+			return ErrReviewEditWindow
+		}
+
+		wasApproved := review.Status == models.ReviewStatusApproved
+		flags := reviewFlags(comment)
+		if err := tx.Model(&review).Updates(map[string]interface{}{
+			"rating":            rating,
+			"comment":           comment,
+			"edited_at":         now,
+			"status":            models.ReviewStatusPending,
+			"is_public":         false,
+			"moderation_flags":  flags,
+			"moderation_reason": "",
+			"moderated_at":      nil,
+			"moderator_user_id": nil,
+		}).Error; err != nil {
+			return err
+		}
+		review.Rating = rating
+		review.Comment = comment
+		review.EditedAt = &now
+		review.Status = models.ReviewStatusPending
+		review.IsPublic = false
+		review.ModerationFlags = flags
+		review.ModerationReason = ""
+		review.ModeratedAt = nil
+		review.ModeratorUserID = nil
+
+		if !wasApproved {
+			return nil
+		}
+		return updateClinicRating(tx, review.ClinicID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetPatientReviews retrieves a patient's reviews, newest first
+func (r *Repository) GetPatientReviews(patientID uint) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.Preload("Clinic").Preload("Reply").Preload("Attachments").
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+// GetModerationReviews retrieves one page of reviews for moderation, oldest
+// first, along with the total number of matching reviews
+func (r *Repository) GetModerationReviews(filter ReviewFilter, offset, limit int) ([]models.Review, int64, error) {
+	query := r.db.Model(&models.Review{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.ClinicID != nil {
+		query = query.Where("clinic_id = ?", *filter.ClinicID)
+	}
+	if filter.Flagged != nil {
+		if *filter.Flagged {
+			query = query.Where("moderation_flags <> ''")
+		} else {
+			query = query.Where("COALESCE(moderation_flags, '') = ''")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.Review
+	err := query.Preload("Patient").Preload("Clinic").Preload("Reply").Preload("Attachments").
+		Order("created_at ASC").
+		Offset(offset).Limit(limit).
+		Find(&reviews).Error
+	return reviews, total, err
+}
+
+// ModerateReview approves or rejects a review and recomputes the clinic's
+// rating. Decisions outside the lifecycle return a *TransitionError.
+func (r *Repository) ModerateReview(reviewID uint, to, reason string, actor Actor) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, reviewID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !CanTransitionReview(review.Status, to) {
+			return &TransitionError{Entity: "review", From: review.Status, To: to}
+		}
+
+		now := time.Now()
+		moderatorID := actor.UserID
+		if err := tx.Model(&review).Updates(map[string]interface{}{
+			"status":            to,
+			"is_public":         to == models.ReviewStatusApproved,
+			"moderation_reason": reason,
+			"moderated_at":      now,
+			"moderator_user_id": moderatorID,
+		}).Error; err != nil {
+			return err
+		}
+		review.Status = to
+		review.IsPublic = to == models.ReviewStatusApproved
+		review.ModerationReason = reason
+		review.ModeratedAt = &now
+		review.ModeratorUserID = &moderatorID
+
+		return updateClinicRating(tx, review.ClinicID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetPublishedClinicReviews retrieves one page of a clinic's approved
+// reviews with their replies and attachments, newest first, along with the
+// total count. Attachments are for the clinic's own view only.
+func (r *Repository) GetPublishedClinicReviews(clinicID uint, offset, limit int) ([]models.Review, int64, error) {
+	query := r.db.Model(&models.Review{}).
+		Where("clinic_id = ? AND status = ?", clinicID, models.ReviewStatusApproved)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.Review
+	err := query.Preload("Patient").Preload("Reply").Preload("Attachments").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&reviews).Error
+	return reviews, total, err
+}
+
+// SaveReviewReply sets the clinic's reply to one of its approved reviews,
+// replacing an earlier reply
+func (r *Repository) SaveReviewReply(clinicID, reviewID uint, text string) (*models.ReviewReply, error) {
+	var reply models.ReviewReply
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND clinic_id = ? AND status = ?", reviewID, clinicID, models.ReviewStatusApproved).
+			First(&review).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecordNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("review_id = ?", reviewID).First(&reply).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			reply = models.ReviewReply{ReviewID: reviewID, ClinicID: clinicID, Text: text}
+			return tx.Create(&reply).Error
+		}
+		if err != nil {
+			return err
+		}
+		reply.Text = text
+		return tx.Save(&reply).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// DeleteReviewReply removes the clinic's reply to one of its reviews
+func (r *Repository) DeleteReviewReply(clinicID, reviewID uint) error {
+	result := r.db.Where("review_id = ? AND clinic_id = ?", reviewID, clinicID).
+		Delete(&models.ReviewReply{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// reviewFlags runs the auto-moderation checks over a review's text
+func reviewFlags(comment string) string {
+	return strings.Join(moderation.Scan(comment), ",")
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrScanJobLost means the job was reclaimed by another worker after its
+// lock went stale; the caller's result must be discarded
+var ErrScanJobLost = errors.New("scan job is no longer locked by this worker")
+
+// ==================== Scan Job Operations ====================
+
+// EnqueueScanJob queues analysis of a scan. A scan has at most one job;
+// enqueueing it again returns the existing job.
+func (r *Repository) EnqueueScanJob(scanID uint, runAt time.Time) (*models.ScanJob, error) {
+	job := &models.ScanJob{
+		CTScanID: scanID,
+		Status:   models.ScanJobStatusQueued,
+		RunAt:    runAt,
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ct_scan_id"}},
+		DoNothing: true,
+	}).Create(job).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if job.ID == 0 {
+		if err := r.db.Where("ct_scan_id = ?", scanID).First(job).Error; err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+// ClaimScanJob locks the next due job for a worker and marks its scan as
+// processing. Running jobs locked before staleBefore belong to a crashed
+// worker and are claimed again. SKIP LOCKED lets workers claim concurrently
+// without blocking on each other.
+func (r *Repository) ClaimScanJob(workerID string, now, staleBefore time.Time) (*models.ScanJob, error) {
+	var job models.ScanJob
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.ScanJobStatusQueued, now, models.ScanJobStatusRunning, staleBefore).
+			Order("run_at ASC").
+			First(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+
+		job.Status = models.ScanJobStatusRunning
+		job.Attempts++
+		job.LockedBy = workerID
+		job.LockedAt = &now
+		if err := tx.Model(&job).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_by": job.LockedBy,
+			"locked_at": job.LockedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.CTScan{}).
+			Where("id = ?", job.CTScanID).
+			Update("status", models.ScanStatusProcessing).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// CompleteScanJob stores the generated treatment plan and marks the scan
+// as processed. A plan that already exists for the scan is kept, so the
+// scan gets exactly one plan however often it is analyzed.
+func (r *Repository) CompleteScanJob(job *models.ScanJob, plan *models.TreatmentPlan) error {
+	now := time.Now()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Finishing the job first locks its row, so a worker that lost the
+		// job to a reclaim stops here instead of racing the new owner
+		if err := r.finishScanJob(tx, job, models.ScanJobStatusSucceeded, "", &now); err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.TreatmentPlan{}).
+			Where("ct_scan_id = ?", job.CTScanID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing == 0 {
+			if err := tx.Create(plan).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.CTScan{}).
+			Where("id = ?", job.CTScanID).
+			Updates(map[string]interface{}{
+				"status":       models.ScanStatusCompleted,
+				"AIProcessed":  true, // by field name; gorm names the column a_iprocessed
+				"error_reason": "",
+				"error_detail": "",
+			}).Error
+	})
+}
+
+// RetryScanJob puts a failed job back in the queue until runAt
+func (r *Repository) RetryScanJob(job *models.ScanJob, lastError string, runAt time.Time) error {
+	result := r.db.Model(&models.ScanJob{}).
+		Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).
+		Updates(map[string]interface{}{
+			"status":     models.ScanJobStatusQueued,
+			"run_at":     runAt,
+			"last_error": lastError,
+			"locked_by":  "",
+			"locked_at":  nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrScanJobLost
+	}
+	return nil
+}
+
+// DeadLetterScanJob gives up on a job and moves its scan to the error status
+func (r *Repository) DeadLetterScanJob(job *models.ScanJob, reason, lastError string) error {
+	now := time.Now()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.finishScanJob(tx, job, models.ScanJobStatusDead, lastError, &now); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.CTScan{}).
+			Where("id = ?", job.CTScanID).
+			Updates(map[string]interface{}{
+				"status":       models.ScanStatusError,
+				"error_reason": reason,
+				"error_detail": lastError,
+			}).Error
+	})
+}
+
+// finishScanJob moves a job to a terminal status and releases its lock,
+// failing with ErrScanJobLost if another worker holds the job
+func (r *Repository) finishScanJob(tx *gorm.DB, job *models.ScanJob, status, lastError string, finishedAt *time.Time) error {
+	result := tx.Model(&models.ScanJob{}).
+		Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"last_error":  lastError,
+			"locked_by":   "",
+			"locked_at":   nil,
+			"finished_at": finishedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrScanJobLost
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+	ErrUploadNotActive      = errors.New("upload session is not active")
+)
+
+// ==================== Upload Session Operations ====================
+
+// CreateUploadSession creates a new resumable upload session
+func (r *Repository) CreateUploadSession(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetUploadSession retrieves an upload session by ID with its chunks
+func (r *Repository) GetUploadSession(sessionID uint) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.db.Preload("Chunks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("chunk_offset ASC")
+	}).First(&session, sessionID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AppendUploadChunk records a stored chunk and advances the session offset.
+// The session row is locked so concurrent PATCH requests cannot both claim
+// the same offset.
+func (r *Repository) AppendUploadChunk(sessionID uint, chunk *models.UploadChunk, expiresAt time.Time) (*models.UploadSession, error) {
+	var session models.UploadSession
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sessionID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+
+		if session.Status != models.UploadStatusActive || time.Now().After(session.ExpiresAt) {
+			return ErrUploadNotActive
+		}
+		if session.Offset != chunk.Offset || chunk.Offset+chunk.Size > session.TotalSize {
+			return ErrUploadOffsetMismatch
+		}
+
+		chunk.UploadSessionID = session.ID
+		if err := tx.Create(chunk).Error; err != nil {
+			return err
+		}
+
+		session.Offset += chunk.Size
+		session.ExpiresAt = expiresAt
+		return tx.Model(&session).Updates(map[string]interface{}{
+			"upload_offset": session.Offset,
+			"expires_at":    session.ExpiresAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// CompleteUploadSession creates the CT scan for a fully received upload
+// and links it to the session in one transaction
+func (r *Repository) CompleteUploadSession(sessionID uint, scan *models.CTScan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var session models.UploadSession
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sessionID).Error
+		if err != nil {
+			return err
+		}
+
+		if session.Status != models.UploadStatusActive {
+			return ErrUploadNotActive
+		}
+
+		if err := tx.Create(scan).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&session).Updates(map[string]interface{}{
+			"status":     models.UploadStatusCompleted,
+			"ct_scan_id": scan.ID,
+		}).Error
+	})
+}
+
+// UpdateUploadSessionStatus sets the status of an upload session
+func (r *Repository) UpdateUploadSessionStatus(sessionID uint, status string) error {
+	return r.db.Model(&models.UploadSession{}).
+		Where("id = ?", sessionID).
+		Update("status", status).Error
+}
+
+// ExpireUploadSession marks an active session past its expiry as expired.
+// The status and expiry are re-checked in the UPDATE itself, so a session
+// that was completed, aborted or extended by a new chunk since it was read
+// is left alone; expired reports whether the row was changed.
+func (r *Repository) ExpireUploadSession(sessionID uint, now time.Time) (expired bool, err error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ? AND expires_at < ?", sessionID, models.UploadStatusActive, now).
+		Update("status", models.UploadStatusExpired)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetExpiredUploadSessions retrieves active sessions past their expiry
+func (r *Repository) GetExpiredUploadSessions(now time.Time, limit int) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	err := r.db.Preload("Chunks").
+		Where("status = ? AND expires_at < ?", models.UploadStatusActive, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// DeleteUploadChunks removes chunk records of a session
+func (r *Repository) DeleteUploadChunks(sessionID uint) error {
+	return r.db.Where("upload_session_id = ?", sessionID).
+		Delete(&models.UploadChunk{}).Error
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSlotTaken is returned when a booking overlaps a live appointment
+var ErrSlotTaken = errors.New("appointment slot is already taken")
+
+// ==================== Scheduling Operations ====================
+
+// GetClinicResources retrieves a clinic's chairs and doctors
+func (r *Repository) GetClinicResources(clinicID uint, activeOnly bool) ([]models.ClinicResource, error) {
+	query := r.db.Where("clinic_id = ?", clinicID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var resources []models.ClinicResource
+	err := query.Order("id ASC").Find(&resources).Error
+	return resources, err
+}
+
+// CreateClinicResource adds a chair or doctor
+func (r *Repository) CreateClinicResource(resource *models.ClinicResource) error {
+	return r.db.Create(resource).Error
+}
+
+// UpdateClinicResource updates a clinic's chair or doctor
+func (r *Repository) UpdateClinicResource(resource *models.ClinicResource) error {
+	result := r.db.Model(&models.ClinicResource{}).
+		Where("id = ? AND clinic_id = ?", resource.ID, resource.ClinicID).
+		Select("kind", "name", "specialization", "is_active").
+		Updates(resource)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetClinicWorkingHours retrieves a clinic's weekly working hours
+func (r *Repository) GetClinicWorkingHours(clinicID uint) ([]models.ClinicWorkingHours, error) {
+	var hours []models.ClinicWorkingHours
+	err := r.db.Where("clinic_id = ?", clinicID).
+		Order("weekday ASC, opens_at ASC, id ASC").
+		Find(&hours).Error
+	return hours, err
+}
+
+// ReplaceClinicWorkingHours replaces a clinic's weekly working hours and,
+// when timeZone is set, the time zone they are in
+func (r *Repository) ReplaceClinicWorkingHours(clinicID uint, timeZone string, hours []models.ClinicWorkingHours) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if timeZone != "" {
+			if err := tx.Model(&models.Clinic{}).Where("id = ?", clinicID).Update("time_zone", timeZone).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("clinic_id = ?", clinicID).Delete(&models.ClinicWorkingHours{}).Error; err != nil {
+			return err
+		}
+		for i := range hours {
+			hours[i].ID = 0
+			hours[i].ClinicID = clinicID
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+}
+
+// GetClinicBlockedDates retrieves a clinic's blocked dates between from and
+// to, inclusive
+func (r *Repository) GetClinicBlockedDates(clinicID uint, from, to time.Time) ([]models.ClinicBlockedDate, error) {
+	var blocked []models.ClinicBlockedDate
+	err := r.db.Where("clinic_id = ? AND date BETWEEN ? AND ?", clinicID,
+		from.Format(time.DateOnly), to.Format(time.DateOnly)).
+		Order("date ASC, id ASC").
+		Find(&blocked).Error
+	return blocked, err
+}
+
+// CreateClinicBlockedDate blocks a day for the clinic or one of its resources
+func (r *Repository) CreateClinicBlockedDate(blocked *models.ClinicBlockedDate) error {
+	return r.db.Create(blocked).Error
+}
+
+// DeleteClinicBlockedDate unblocks a day
+func (r *Repository) DeleteClinicBlockedDate(clinicID, blockedID uint) error {
+	result := r.db.Where("id = ? AND clinic_id = ?", blockedID, clinicID).Delete(&models.ClinicBlockedDate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetBookedAppointments retrieves a clinic's appointments that still hold
+// their slot and overlap from..to
+func (r *Repository) GetBookedAppointments(clinicID uint, from, to time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("clinic_id = ? AND resource_id IS NOT NULL AND status <> ?", clinicID, models.AppointmentStatusCancelled).
+		Where("appointment_date < ? AND appointment_date + duration_minutes * interval '1 minute' > ?", to, from).
+		Order("appointment_date ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// BookAppointment creates an appointment on a resource. The resource row is
+// locked so concurrent bookings of it are serialized, and overlaps with live
+// appointments are rejected; the partial unique index on resource and start
+// time backs this up at the database level. The offer's lead moves to
+// consultation scheduled.
+func (r *Repository) BookAppointment(appointment *models.Appointment) error {
+	if appointment.ResourceID == nil {
+		return ErrRecordNotFound
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockClinicResource(tx, appointment.ClinicID, *appointment.ResourceID); err != nil {
+			return err
+		}
+
+		end := appointment.AppointmentDate.Add(time.Duration(appointment.DurationMinutes) * time.Minute)
+		var overlapping int64
+		if err := tx.Model(&models.Appointment{}).
+			Where("resource_id = ? AND status <> ?", *appointment.ResourceID, models.AppointmentStatusCancelled).
+			Where("appointment_date < ? AND appointment_date + duration_minutes * interval '1 minute' > ?", end, appointment.AppointmentDate).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrSlotTaken
+		}
+
+		if err := tx.Create(appointment).Error; err != nil {
+			if r.isUniqueViolation(err) {
+				return ErrSlotTaken
+			}
+			return err
+		}
+		return advanceLead(tx, appointment.ClinicOfferID, models.LeadStatusConsultationScheduled,
+			SystemActor, fmt.Sprintf("appointment %d booked", appointment.ID))
+	})
+}
+
+// lockClinicResource locks an active resource of the clinic
+func lockClinicResource(tx *gorm.DB, clinicID, resourceID uint) error {
+	var resource models.ClinicResource
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND clinic_id = ? AND is_active = ?", resourceID, clinicID, true).
+		First(&resource).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func (r *Repository) isUniqueViolation(err error) bool {
+	if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
@@ -0,0 +1,164 @@
+package scans
+
+import (
+	"context"
+	"crypto/sha256"
+	"dental-marketplace/backend/internal/dicom"
+	"dental-marketplace/backend/internal/models"
+	"dental-marketplace/backend/internal/repository"
+	"dental-marketplace/backend/internal/storage"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// Ingestor inspects newly stored CT scans, indexes their DICOM metadata,
+// produces the de-identified copy that clinics receive and queues the scan
+// for analysis
+type Ingestor struct {
+	repo    *repository.Repository
+	store   storage.Storage
+	profile *dicom.Profile
+}
+
+func NewIngestor(repo *repository.Repository, store storage.Storage, profile *dicom.Profile) *Ingestor {
+	return &Ingestor{
+		repo:    repo,
+		store:   store,
+		profile: profile,
+	}
+}
+
+// Ingest extracts DICOM metadata from a stored scan, de-identifies it and
+// queues it for analysis. Files that are not valid DICOM move the scan to
+// the error status with a machine-readable reason; only storage and
+// database failures are returned as errors.
+func (i *Ingestor) Ingest(ctx context.Context, scan *models.CTScan) error {
+	if err := i.extractMetadata(ctx, scan); err != nil {
+		return i.fail(scan, err)
+	}
+	if err := i.anonymize(ctx, scan); err != nil {
+		return i.fail(scan, err)
+	}
+
+	job, err := i.repo.EnqueueScanJob(scan.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	scan.Job = job
+	return nil
+}
+
+// fail records DICOM errors on the scan and passes other errors through
+func (i *Ingestor) fail(scan *models.CTScan, err error) error {
+	dicomErr, ok := dicom.AsError(err)
+	if !ok {
+		return err
+	}
+
+	if err := i.repo.MarkCTScanError(scan.ID, dicomErr.Reason, dicomErr.Detail); err != nil {
+		return err
+	}
+	scan.Status = models.ScanStatusError
+	scan.ErrorReason = dicomErr.Reason
+	scan.ErrorDetail = dicomErr.Detail
+	return nil
+}
+
+func (i *Ingestor) extractMetadata(ctx context.Context, scan *models.CTScan) error {
+	obj, err := i.store.Get(ctx, scan.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to open scan %d: %w", scan.ID, err)
+	}
+	defer obj.Close()
+
+	summary, err := dicom.Inspect(obj)
+	if err != nil {
+		return fmt.Errorf("failed to inspect scan %d: %w", scan.ID, err)
+	}
+
+	metadata := &models.CTScanMetadata{
+		CTScanID:          scan.ID,
+		StudyInstanceUID:  summary.StudyInstanceUID,
+		StudyDate:         summary.StudyDate,
+		Modality:          summary.Modality,
+		Manufacturer:      summary.Manufacturer,
+		ManufacturerModel: summary.ManufacturerModel,
+		TransferSyntaxUID: summary.TransferSyntaxUID,
+		SliceThickness:    summary.SliceThickness,
+		VoxelSizeX:        summary.VoxelSizeX,
+		VoxelSizeY:        summary.VoxelSizeY,
+		VoxelSizeZ:        summary.VoxelSizeZ,
+		Rows:              summary.Rows,
+		Columns:           summary.Columns,
+		SeriesCount:       summary.SeriesCount,
+		InstanceCount:     summary.InstanceCount,
+		FrameCount:        summary.FrameCount,
+	}
+
+	if err := i.repo.SaveCTScanMetadata(metadata); err != nil {
+		return err
+	}
+	scan.Metadata = metadata
+	return nil
+}
+
+// anonymize writes the de-identified copy next to the original. The copy
+// is spooled to disk first because storage needs the object size up front.
+func (i *Ingestor) anonymize(ctx context.Context, scan *models.CTScan) error {
+	obj, err := i.store.Get(ctx, scan.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to open scan %d: %w", scan.ID, err)
+	}
+	defer obj.Close()
+
+	tmp, err := os.CreateTemp("", "scan-anonymized-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	if err := dicom.Deidentify(obj, io.MultiWriter(tmp, hasher), i.profile); err != nil {
+		return fmt.Errorf("failed to de-identify scan %d: %w", scan.ID, err)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key, err := storage.NewKey(fmt.Sprintf("anonymized/%d", scan.PatientID), scan.FileName)
+	if err != nil {
+		return err
+	}
+	if err := i.store.Put(ctx, key, tmp, size, scan.ContentType); err != nil {
+		return fmt.Errorf("failed to store anonymized scan %d: %w", scan.ID, err)
+	}
+
+	checksum := hex.EncodeToString(hasher.Sum(nil))
+	now := time.Now()
+	if err := i.repo.SetCTScanAnonymized(scan.ID, key, checksum, now); err != nil {
+		i.store.Delete(ctx, key)
+		return err
+	}
+
+	// Replace a copy made with an earlier profile
+	if scan.AnonymizedKey != "" && scan.AnonymizedKey != key {
+		if err := i.store.Delete(ctx, scan.AnonymizedKey); err != nil {
+			log.Printf("failed to delete previous anonymized copy %s: %v", scan.AnonymizedKey, err)
+		}
+	}
+
+	scan.AnonymizedKey = key
+	scan.AnonymizedChecksum = checksum
+	scan.AnonymizedAt = &now
+	return nil
+}
@@ -0,0 +1,224 @@
+package scheduling
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	_ "time/tzdata" // clinic time zones must resolve without system zoneinfo
+)
+
+const (
+	MinDuration = 15 * time.Minute
+	MaxDuration = 4 * time.Hour
+	// MaxRange bounds how far ahead slots are listed in one request
+	MaxRange = 31 * 24 * time.Hour
+)
+
+// ErrSlotUnavailable is returned when a requested slot is not free
+var ErrSlotUnavailable = errors.New("slot is not available")
+
+// Slot is a free interval on one resource
+type Slot struct {
+	ResourceID   uint      `json:"resource_id"`
+	ResourceName string    `json:"resource_name"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+}
+
+// Calendar is a clinic's availability and its live bookings
+type Calendar struct {
+	Clinic    *models.Clinic
+	Resources []models.ClinicResource
+	Hours     []models.ClinicWorkingHours
+	Blocked   []models.ClinicBlockedDate
+	Booked    []models.Appointment // appointments that still hold their slot
+}
+
+// Location returns the clinic's time zone, falling back to UTC
+func Location(clinic *models.Clinic) *time.Location {
+	loc, err := time.LoadLocation(clinic.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ParseClock parses an HH:MM time of day into minutes after midnight
+func ParseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// FreeSlots lists free slots of the given length for a specialization
+// between from and to, earliest first. Slots start at each opening time and
+// follow back to back; slots starting before now are skipped.
+func (c *Calendar) FreeSlots(specialization string, from, to time.Time, duration time.Duration, now time.Time) []Slot {
+	if !clinicProvides(c.Clinic, specialization) || duration <= 0 {
+		return nil
+	}
+
+	loc := Location(c.Clinic)
+	from, to = from.In(loc), to.In(loc)
+
+	var slots []Slot
+	for day := startOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for i := range c.Resources {
+			resource := &c.Resources[i]
+			if !servesSpecialization(resource, specialization) {
+				continue
+			}
+			for _, slot := range c.daySlots(resource, day, duration) {
+				if slot.StartsAt.Before(from) || slot.EndsAt.After(to) || slot.StartsAt.Before(now) {
+					continue
+				}
+				slots = append(slots, slot)
+			}
+		}
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		if !slots[i].StartsAt.Equal(slots[j].StartsAt) {
+			return slots[i].StartsAt.Before(slots[j].StartsAt)
+		}
+		return slots[i].ResourceID < slots[j].ResourceID
+	})
+	return slots
+}
+
+// CheckSlot reports whether a booking matches a free slot: the resource
+// must serve the specialization and start must be on the slot grid of its
+// working hours, not blocked, not booked and not in the past
+func (c *Calendar) CheckSlot(specialization string, resourceID uint, start time.Time, duration time.Duration, now time.Time) error {
+	if !clinicProvides(c.Clinic, specialization) || !start.After(now) {
+		return ErrSlotUnavailable
+	}
+	for i := range c.Resources {
+		resource := &c.Resources[i]
+		if resource.ID != resourceID || !servesSpecialization(resource, specialization) {
+			continue
+		}
+		day := startOfDay(start.In(Location(c.Clinic)))
+		for _, slot := range c.daySlots(resource, day, duration) {
+			if slot.StartsAt.Equal(start) {
+				return nil
+			}
+		}
+	}
+	return ErrSlotUnavailable
+}
+
+// daySlots lists the free slots of one resource on one local day
+func (c *Calendar) daySlots(resource *models.ClinicResource, day time.Time, duration time.Duration) []Slot {
+	if c.blocked(resource.ID, day) {
+		return nil
+	}
+
+	var slots []Slot
+	for _, hours := range c.hoursFor(resource.ID, day.Weekday()) {
+		opens, err := ParseClock(hours.OpensAt)
+		if err != nil {
+			continue
+		}
+		closes, err := ParseClock(hours.ClosesAt)
+		if err != nil {
+			continue
+		}
+
+		end := atMinute(day, closes)
+		for start := atMinute(day, opens); !start.Add(duration).After(end); start = start.Add(duration) {
+			if c.booked(resource.ID, start, start.Add(duration)) {
+				continue
+			}
+			slots = append(slots, Slot{
+				ResourceID:   resource.ID,
+				ResourceName: resource.Name,
+				StartsAt:     start,
+				EndsAt:       start.Add(duration),
+			})
+		}
+	}
+	return slots
+}
+
+// hoursFor returns a resource's own hours on a weekday, or the clinic's
+// hours when the resource has none of its own on any day
+func (c *Calendar) hoursFor(resourceID uint, weekday time.Weekday) []models.ClinicWorkingHours {
+	var own, clinic []models.ClinicWorkingHours
+	hasOwn := false
+	for _, h := range c.Hours {
+		switch {
+		case h.ResourceID != nil && *h.ResourceID == resourceID:
+			hasOwn = true
+			if time.Weekday(h.Weekday) == weekday {
+				own = append(own, h)
+			}
+		case h.ResourceID == nil && time.Weekday(h.Weekday) == weekday:
+			clinic = append(clinic, h)
+		}
+	}
+	if hasOwn {
+		return own
+	}
+	return clinic
+}
+
+func (c *Calendar) blocked(resourceID uint, day time.Time) bool {
+	date := day.Format(time.DateOnly)
+	for _, b := range c.Blocked {
+		if b.Date.Format(time.DateOnly) != date {
+			continue
+		}
+		if b.ResourceID == nil || *b.ResourceID == resourceID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Calendar) booked(resourceID uint, start, end time.Time) bool {
+	for _, a := range c.Booked {
+		if a.ResourceID == nil || *a.ResourceID != resourceID {
+			continue
+		}
+		bookedEnd := a.AppointmentDate.Add(time.Duration(a.DurationMinutes) * time.Minute)
+		if a.AppointmentDate.Before(end) && start.Before(bookedEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+func servesSpecialization(resource *models.ClinicResource, specialization string) bool {
+	return resource.IsActive && (resource.Specialization == "" || resource.Specialization == specialization)
+}
+
+func clinicProvides(clinic *models.Clinic, specialization string) bool {
+	switch specialization {
+	case models.SpecTherapy:
+		return clinic.HasTherapy
+	case models.SpecOrthopedics:
+		return clinic.HasOrthopedics
+	case models.SpecSurgery:
+		return clinic.HasSurgery
+	case models.SpecHygiene:
+		return clinic.HasHygiene
+	case models.SpecPeriodontics:
+		return clinic.HasPeriodontics
+	}
+	return false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// atMinute returns the wall-clock time minutes after midnight of day, so
+// working hours keep their meaning across daylight saving changes
+func atMinute(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
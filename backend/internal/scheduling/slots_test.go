@@ -0,0 +1,247 @@
+package scheduling
+
+import (
+	"dental-marketplace/backend/internal/models"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var moscow = time.FixedZone("MSK", 3*60*60)
+
+// monday is a Monday in the clinic's time zone
+var monday = time.Date(2026, time.March, 2, 0, 0, 0, 0, moscow)
+
+func at(day time.Time, hour, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, moscow)
+}
+
+func resourceID(id uint) *uint {
+	return &id
+}
+
+// testCalendar has two chairs sharing the clinic's Monday hours
+func testCalendar() *Calendar {
+	return &Calendar{
+		Clinic: &models.Clinic{TimeZone: "Europe/Moscow", HasTherapy: true, HasSurgery: true},
+		Resources: []models.ClinicResource{
+			{ID: 1, Name: "Кресло 1", IsActive: true},
+			{ID: 2, Name: "Кресло 2", Specialization: models.SpecTherapy, IsActive: true},
+		},
+		Hours: []models.ClinicWorkingHours{
+			{Weekday: int(time.Monday), OpensAt: "09:00", ClosesAt: "11:00"},
+		},
+	}
+}
+
+// slotStarts lists slots as resource and local start time
+func slotStarts(slots []Slot) []string {
+	starts := make([]string, len(slots))
+	for i, s := range slots {
+		starts[i] = fmt.Sprintf("%d@%s", s.ResourceID, s.StartsAt.In(moscow).Format("15:04"))
+	}
+	return starts
+}
+
+func TestFreeSlots(t *testing.T) {
+	tests := []struct {
+		name           string
+		specialization string
+		duration       time.Duration
+		change         func(c *Calendar)
+		want           []string
+	}{
+		{
+			name:           "back to back across working hours",
+			specialization: models.SpecTherapy,
+			duration:       30 * time.Minute,
+			want:           []string{"1@09:00", "2@09:00", "1@09:30", "2@09:30", "1@10:00", "2@10:00", "1@10:30", "2@10:30"},
+		},
+		{
+			name:           "resource of another specialization",
+			specialization: models.SpecSurgery,
+			duration:       time.Hour,
+			want:           []string{"1@09:00", "1@10:00"},
+		},
+		{
+			name:           "slot crossing closing time is dropped",
+			specialization: models.SpecSurgery,
+			duration:       45 * time.Minute,
+			want:           []string{"1@09:00", "1@09:45"},
+		},
+		{
+			name:           "split hours",
+			specialization: models.SpecSurgery,
+			duration:       time.Hour,
+			change: func(c *Calendar) {
+				c.Hours = []models.ClinicWorkingHours{
+					{Weekday: int(time.Monday), OpensAt: "09:00", ClosesAt: "10:30"},
+					{Weekday: int(time.Monday), OpensAt: "14:00", ClosesAt: "15:00"},
+				}
+			},
+			want: []string{"1@09:00", "1@14:00"},
+		},
+		{
+			name:           "resource's own hours replace the clinic's",
+			specialization: models.SpecTherapy,
+			duration:       time.Hour,
+			change: func(c *Calendar) {
+				c.Hours = append(c.Hours, models.ClinicWorkingHours{ResourceID: resourceID(2), Weekday: int(time.Monday), OpensAt: "12:00", ClosesAt: "13:00"})
+			},
+			want: []string{"1@09:00", "1@10:00", "2@12:00"},
+		},
+		{
+			name:           "bookings overlapping a slot",
+			specialization: models.SpecTherapy,
+			duration:       30 * time.Minute,
+			change: func(c *Calendar) {
+				c.Booked = []models.Appointment{
+					// Overlaps the 09:00 and 09:30 slots of chair 1
+					{ResourceID: resourceID(1), AppointmentDate: at(monday, 9, 15), DurationMinutes: 30},
+					// Ends as the 10:30 slot of chair 2 starts
+					{ResourceID: resourceID(2), AppointmentDate: at(monday, 10, 0), DurationMinutes: 30},
+					// Holds no chair
+					{AppointmentDate: at(monday, 9, 0), DurationMinutes: 120},
+				}
+			},
+			want: []string{"2@09:00", "2@09:30", "1@10:00", "1@10:30", "2@10:30"},
+		},
+		{
+			name:           "blocked resource",
+			specialization: models.SpecTherapy,
+			duration:       time.Hour,
+			change: func(c *Calendar) {
+				c.Blocked = []models.ClinicBlockedDate{{ResourceID: resourceID(1), Date: time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)}}
+			},
+			want: []string{"2@09:00", "2@10:00"},
+		},
+		{
+			name:           "blocked clinic",
+			specialization: models.SpecTherapy,
+			duration:       time.Hour,
+			change: func(c *Calendar) {
+				c.Blocked = []models.ClinicBlockedDate{{Date: time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)}}
+			},
+			want: []string{},
+		},
+		{
+			name:           "inactive resource",
+			specialization: models.SpecTherapy,
+			duration:       time.Hour,
+			change:         func(c *Calendar) { c.Resources[0].IsActive = false },
+			want:           []string{"2@09:00", "2@10:00"},
+		},
+		{
+			name:           "specialization the clinic does not provide",
+			specialization: models.SpecOrthopedics,
+			duration:       time.Hour,
+			want:           []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCalendar()
+			if tt.change != nil {
+				tt.change(c)
+			}
+			// The whole week, seen from a week earlier
+			got := slotStarts(c.FreeSlots(tt.specialization, monday, monday.AddDate(0, 0, 7), tt.duration, monday.AddDate(0, 0, -7)))
+			if len(got) != len(tt.want) {
+				t.Fatalf("slots = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("slots = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFreeSlotsWithinRange(t *testing.T) {
+	c := testCalendar()
+	c.Resources = c.Resources[:1]
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		now      time.Time
+		want     []string
+	}{
+		{"slots must fit the range", at(monday, 9, 30), at(monday, 10, 30), monday, []string{"1@09:30", "1@10:00"}},
+		{"slots that started are skipped", monday, monday.AddDate(0, 0, 1), at(monday, 9, 10), []string{"1@09:30", "1@10:00", "1@10:30"}},
+		{"range given in another zone", at(monday, 10, 0).UTC(), at(monday, 11, 0).UTC(), monday, []string{"1@10:00", "1@10:30"}},
+		{"closed day", monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 2), monday, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slotStarts(c.FreeSlots(models.SpecTherapy, tt.from, tt.to, 30*time.Minute, tt.now))
+			if len(got) != len(tt.want) {
+				t.Fatalf("slots = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("slots = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckSlot(t *testing.T) {
+	c := testCalendar()
+	c.Booked = []models.Appointment{{ResourceID: resourceID(1), AppointmentDate: at(monday, 10, 0), DurationMinutes: 30}}
+	now := monday.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name           string
+		specialization string
+		resourceID     uint
+		start          time.Time
+		now            time.Time
+		ok             bool
+	}{
+		{"free slot", models.SpecTherapy, 1, at(monday, 9, 30), now, true},
+		{"free slot given in UTC", models.SpecTherapy, 2, at(monday, 10, 0).UTC(), now, true},
+		{"off the slot grid", models.SpecTherapy, 1, at(monday, 9, 15), now, false},
+		{"booked", models.SpecTherapy, 1, at(monday, 10, 0), now, false},
+		{"crosses closing time", models.SpecTherapy, 1, at(monday, 11, 0), now, false},
+		{"in the past", models.SpecTherapy, 1, at(monday, 9, 30), at(monday, 9, 30), false},
+		{"resource of another specialization", models.SpecSurgery, 2, at(monday, 9, 30), now, false},
+		{"unknown resource", models.SpecTherapy, 3, at(monday, 9, 30), now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.CheckSlot(tt.specialization, tt.resourceID, tt.start, 30*time.Minute, tt.now)
+			if tt.ok && err != nil {
+				t.Errorf("CheckSlot: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrSlotUnavailable) {
+				t.Errorf("CheckSlot = %v, want ErrSlotUnavailable", err)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		clock string
+		want  int
+		ok    bool
+	}{
+		{"00:00", 0, true},
+		{"09:30", 570, true},
+		{"23:59", 1439, true},
+		{"24:00", 0, false},
+		{"9:30", 570, true},
+		{"09:60", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseClock(tt.clock)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseClock(%q) = %d, %v; want %d, ok %v", tt.clock, got, err, tt.want, tt.ok)
+		}
+	}
+}
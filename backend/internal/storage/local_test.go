@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+	s, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/files/", "test-secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	return s
+}
+
+// signedParams extracts the key, expiry and signature from a signed URL
+func signedParams(t *testing.T, signedURL string) (key, expires, signature string) {
+	t.Helper()
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatalf("parse signed URL: %v", err)
+	}
+	key = strings.TrimPrefix(u.Path, "/files/")
+	return key, u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestLocalSignedURLRoundTrip(t *testing.T) {
+	s := newTestLocalStorage(t)
+
+	signedURL, err := s.SignedURL(context.Background(), "scans/7/plan v1.pdf", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	if !strings.HasPrefix(signedURL, "http://localhost:8080/files/scans/7/plan%20v1.pdf?") {
+		t.Errorf("SignedURL = %q", signedURL)
+	}
+
+	key, expires, signature := signedParams(t, signedURL)
+	if key != "scans/7/plan v1.pdf" {
+		t.Errorf("key = %q", key)
+	}
+	if err := s.VerifySignature(key, expires, signature); err != nil {
+		t.Errorf("VerifySignature: %v", err)
+	}
+}
+
+func TestLocalVerifySignatureRejects(t *testing.T) {
+	s := newTestLocalStorage(t)
+
+	signedURL, err := s.SignedURL(context.Background(), "scans/7/a.dcm", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	key, expires, signature := signedParams(t, signedURL)
+
+	past := strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+	later := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	other, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/files", "other-secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		expires   string
+		signature string
+	}{
+		{"expired", key, past, s.sign(key, past)},
+		{"tampered key", "scans/8/a.dcm", expires, signature},
+		{"tampered expiry", key, later, signature},
+		{"tampered signature", key, expires, flipHexDigit(signature)},
+		{"truncated signature", key, expires, signature[:len(signature)-2]},
+		{"empty signature", key, expires, ""},
+		{"malformed expiry", key, "soon", signature},
+		{"foreign secret", key, expires, other.sign(key, expires)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.VerifySignature(tt.key, tt.expires, tt.signature); err != ErrInvalidSignature {
+				t.Errorf("VerifySignature error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func flipHexDigit(s string) string {
+	b := []byte(s)
+	if b[0] == '0' {
+		b[0] = '1'
+	} else {
+		b[0] = '0'
+	}
+	return string(b)
+}
+
+func TestLocalSignedURLRejectsInvalidKey(t *testing.T) {
+	s := newTestLocalStorage(t)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/./b"} {
+		if _, err := s.SignedURL(context.Background(), key, time.Minute); err != ErrInvalidKey {
+			t.Errorf("SignedURL(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestLocalPutGetDelete(t *testing.T) {
+	s := newTestLocalStorage(t)
+	ctx := context.Background()
+	content := []byte("dicom bytes")
+
+	if err := s.Put(ctx, "scans/1/a.dcm", bytes.NewReader(content), int64(len(content)), ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put(ctx, "scans/1/b.dcm", bytes.NewReader(content), int64(len(content))+1, ""); err == nil {
+		t.Error("Put with a short body succeeded")
+	}
+	if _, err := s.Get(ctx, "scans/1/b.dcm"); err != ErrObjectNotFound {
+		t.Errorf("short write left an object behind: %v", err)
+	}
+
+	obj, err := s.Get(ctx, "scans/1/a.dcm")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(obj)
+	obj.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("Get = %q, want %q", got, content)
+	}
+
+	if err := s.Delete(ctx, "scans/1/a.dcm"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "scans/1/a.dcm"); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, "../outside"); err != ErrInvalidKey {
+		t.Errorf("Get traversal error = %v, want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Package testdb provides a migrated database and fixtures for tests that
+// exercise repository queries. Such tests need PostgreSQL and are skipped
+// unless TEST_DATABASE_URL is set, e.g.
+//
+//	TEST_DATABASE_URL="host=localhost user=postgres password=postgres dbname=dental_test sslmode=disable" go test ./...
+//
+// Each test runs in a transaction that is rolled back when it ends, so tests
+// do not see each other's rows. Fixtures get unique names so they do not
+// clash with data already in the database.
+package testdb
+
+import (
+	"dental-marketplace/backend/internal/database/migrations"
+	"dental-marketplace/backend/internal/models"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	once    sync.Once
+	db      *gorm.DB
+	openErr error
+
+	sequence atomic.Int64
+)
+
+// Open returns a transaction on the test database, migrating the database
+// on first use. The transaction is rolled back when the test ends;
+// repository transactions inside it become savepoints.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	once.Do(func() {
+		db, openErr = gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+			NowFunc: func() time.Time {
+				return time.Now().UTC()
+			},
+		})
+		if openErr == nil {
+			openErr = migrations.RunAll(db)
+		}
+	})
+	if openErr != nil {
+		t.Fatalf("failed to open test database: %v", openErr)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin test transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+	})
+	return tx
+}
+
+// unique returns a name that no other fixture in this or an earlier run uses
+func unique(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), sequence.Add(1))
+}
+
+// create inserts value or fails the test
+func create(t testing.TB, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("failed to create %T: %v", value, err)
+	}
+}
+
+// User creates an active user with the given role
+func User(t testing.TB, db *gorm.DB, role string) *models.User {
+	t.Helper()
+	name := unique(role)
+	user := &models.User{
+		Username:     name,
+		PasswordHash: "-",
+		Role:         role,
+		Email:        name + "@example.test",
+		IsActive:     true,
+	}
+	create(t, db, user)
+	return user
+}
+
+// Patient creates a patient in Moscow with its user
+func Patient(t testing.TB, db *gorm.DB) *models.Patient {
+	t.Helper()
+	user := User(t, db, models.RolePatient)
+	patient := &models.Patient{
+		UserID:       user.ID,
+		FirstName:    "Test",
+		LastName:     user.Username,
+		City:         "Москва",
+		PriceSegment: "medium",
+	}
+	create(t, db, patient)
+	return patient
+}
+
+// Clinic creates a clinic in Moscow with its user
+func Clinic(t testing.TB, db *gorm.DB) *models.Clinic {
+	t.Helper()
+	user := User(t, db, models.RoleClinic)
+	clinic := &models.Clinic{
+		UserID:        user.ID,
+		Name:          user.Username,
+		LicenseNumber: user.Username,
+		City:          "Москва",
+		PriceSegment:  "medium",
+		TimeZone:      "Europe/Moscow",
+	}
+	create(t, db, clinic)
+	return clinic
+}
+
+// Scan creates a processed CT scan of the patient
+func Scan(t testing.TB, db *gorm.DB, patient *models.Patient) *models.CTScan {
+	t.Helper()
+	scan := &models.CTScan{
+		PatientID:   patient.ID,
+		UploadDate:  time.Now(),
+		Status:      models.ScanStatusCompleted,
+		AIProcessed: true,
+		StorageKey:  unique("scans") + ".dcm",
+		FileName:    "scan.dcm",
+	}
+	create(t, db, scan)
+	return scan
+}
+
+// Plan creates a treatment plan with one therapy item for the scan
+func Plan(t testing.TB, db *gorm.DB, scan *models.CTScan, status string) *models.TreatmentPlan {
+	t.Helper()
+	plan := &models.TreatmentPlan{
+		PatientID:       scan.PatientID,
+		CTScanID:        scan.ID,
+		Status:          status,
+		RequiresTherapy: true,
+		TherapyMinCost:  4000,
+		TherapyMaxCost:  6000,
+		Items: []models.TreatmentItem{{
+			Specialization: models.SpecTherapy,
+			ToothNumber:    "16",
+			Diagnosis:      "Кариес",
+			Procedure:      "Лечение кариеса",
+			ProcedureCode:  "therapy_caries",
+			Urgency:        "medium",
+			EstimatedCost:  5000,
+		}},
+	}
+	create(t, db, plan)
+	return plan
+}
+
+// Offer creates an offer of the clinic on the plan in the given status
+func Offer(t testing.TB, db *gorm.DB, plan *models.TreatmentPlan, clinic *models.Clinic, status string) *models.ClinicOffer {
+	t.Helper()
+	validUntil := time.Now().Add(7 * 24 * time.Hour)
+	offer := &models.ClinicOffer{
+		TreatmentPlanID: plan.ID,
+		ClinicID:        clinic.ID,
+		Status:          status,
+		Version:         1,
+		ValidUntil:      &validUntil,
+		TherapyCost:     5000,
+		TotalCost:       5000,
+	}
+	create(t, db, offer)
+	return offer
+}
+
+// Appointment creates a scheduled appointment of the patient at the clinic
+func Appointment(t testing.TB, db *gorm.DB, patient *models.Patient, clinic *models.Clinic) *models.Appointment {
+	t.Helper()
+	appointment := &models.Appointment{
+		PatientID:       patient.ID,
+		ClinicID:        clinic.ID,
+		AppointmentDate: time.Now().Add(14 * 24 * time.Hour).Truncate(time.Hour),
+		DurationMinutes: 60,
+		Specialization:  models.SpecTherapy,
+		Status:          models.AppointmentStatusScheduled,
+	}
+	create(t, db, appointment)
+	return appointment
+}
@@ -0,0 +1,34 @@
+package virusscan
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// eicarSignature is the industry-standard antivirus test string
+var eicarSignature = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+// FakeScanner reports every file clean except ones containing the EICAR test
+// string, so rejection can be exercised. It is used in development and tests
+// until a real engine is configured.
+type FakeScanner struct{}
+
+func NewFakeScanner() *FakeScanner {
+	return &FakeScanner{}
+}
+
+func (s *FakeScanner) Name() string {
+	return "fake"
+}
+
+func (s *FakeScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(data, eicarSignature) {
+		return &Result{Threat: "EICAR-Test-File"}, nil
+	}
+	return &Result{Clean: true}, nil
+}
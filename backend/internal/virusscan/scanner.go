@@ -0,0 +1,19 @@
+package virusscan
+
+import (
+	"context"
+	"io"
+)
+
+// Scanner checks an uploaded file for malware before it is stored.
+// Implementations wrap an antivirus engine such as a ClamAV daemon.
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
+
+// Result is the verdict on one file
+type Result struct {
+	Clean  bool
+	Threat string // signature name when the file is infected
+}
@@ -0,0 +1,13 @@
+package virusscan
+
+import "fmt"
+
+// New returns the scanner configured by name
+func New(name string) (Scanner, error) {
+	switch name {
+	case "fake", "":
+		return NewFakeScanner(), nil
+	default:
+		return nil, fmt.Errorf("unknown virus scanner: %s", name)
+	}
+}